@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// defaults of the answers of the add wizard, from the flags of add
+type WizardOptions struct {
+	Tags   []string
+	Note   string
+	Source string
+}
+
+// print question with its default, and read the answer from in. An empty
+// answer is the default, and "-" clears it. Returns io.EOF at the end of in.
+func prompt(in *bufio.Scanner, question, def string) (string, error) {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	if !in.Scan() {
+		fmt.Println()
+		if err := in.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	switch answer := strings.TrimSpace(in.Text()); answer {
+	case "":
+		return def, nil
+	case "-":
+		return "", nil
+	default:
+		return answer, nil
+	}
+}
+
+// add words in a guided terminal flow: the translation of each word is
+// fetched and shown for confirmation, and the note, tags and source are
+// asked before the word is added. The words are asked for until an empty
+// word if none are given.
+func (w *WordDB) AddInteractive(words []string, defaults WizardOptions) error {
+	in := bufio.NewScanner(os.Stdin)
+	fmt.Println("press Enter to accept the [default], - to clear it, Ctrl-D to quit")
+	ask := len(words) == 0
+	for {
+		var word string
+		switch {
+		case len(words) > 0:
+			word, words = words[0], words[1:]
+		case ask:
+			answer, err := prompt(in, "word (empty to finish)", "")
+			if errors.Is(err, io.EOF) || (err == nil && answer == "") {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			word = answer
+		default:
+			return nil
+		}
+		if err := w.addInteractive(in, word, defaults); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// the translation of a word stored, into the language of the translate config
+func (w *WordDB) storedTranslation(word worddb.Word) (string, error) {
+	if !w.otherLang() {
+		return word.ZhTrans.String, nil
+	}
+	translations, err := w.Store.Translations(w.Ctx, word.Word)
+	if err != nil {
+		return "", err
+	}
+	return translations[strings.ToLower(config.Translate.Lang)], nil
+}
+
+func (w *WordDB) addInteractive(in *bufio.Scanner, word string, defaults WizardOptions) error {
+	word = wordstore.NormalizeWord(word)
+	if !wordstore.IsValidWord(word) {
+		fmt.Printf("'%s' is not a valid word\n", word)
+		return nil
+	}
+	words, variants := w.lemmatize(w.Ctx, []string{word})
+	word = words[0]
+
+	opts := wordstore.AddOptions{Tags: defaults.Tags, DeckID: w.DeckID, Note: defaults.Note, Source: defaults.Source, Variants: variants}
+	var trans, phonetic, tags string
+	existing, err := w.Store.Get(w.Ctx, word)
+	switch {
+	case err == nil:
+		if trans, err = w.storedTranslation(existing); err != nil {
+			return err
+		}
+		phonetic = existing.Phonetic.String
+		if opts.Note == "" {
+			opts.Note = existing.Note.String
+		}
+		wordTags, err := w.Store.WordTags(w.Ctx)
+		if err != nil {
+			return err
+		}
+		tags = strings.Join(wordTags[word], ",")
+	case errors.Is(err, wordstore.ErrNotFound):
+		if w.Translator != nil {
+			if trans, err = w.Translator.Translate(w.Ctx, word); err != nil {
+				fmt.Printf("translate '%s': %v\n", word, err)
+			}
+		}
+		if w.Phonetics != nil {
+			phonetic, _ = w.Phonetics.Translate(w.Ctx, word)
+		}
+	default:
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("  %s", word)
+	if phonetic != "" {
+		fmt.Printf("  %s", phonetic)
+	}
+	if level := wordstore.Level(word); level != "" {
+		fmt.Printf("  %s", level)
+	}
+	fmt.Println()
+	if trans != "" {
+		fmt.Printf("  %s\n", trans)
+	} else {
+		fmt.Println("  (no translation)")
+	}
+	if existing.Word != "" {
+		fmt.Print("  already added")
+		if tags != "" {
+			fmt.Printf(", tags %s", tags)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+
+	answer, err := prompt(in, "translation", trans)
+	if err != nil {
+		return err
+	}
+	if opts.Note, err = prompt(in, "note", opts.Note); err != nil {
+		return err
+	}
+	answerTags, err := prompt(in, "tags, comma separated", strings.Join(opts.Tags, ","))
+	if err != nil {
+		return err
+	}
+	opts.Tags = parseTags(answerTags)
+	if opts.Source, err = prompt(in, "source", opts.Source); err != nil {
+		return err
+	}
+	confirm, err := prompt(in, fmt.Sprintf("add '%s'? [Y/n]", word), "")
+	if err != nil {
+		return err
+	}
+	if confirm = strings.ToLower(confirm); confirm != "" && confirm != "y" && confirm != "yes" {
+		fmt.Printf("skip '%s'\n", word)
+		return nil
+	}
+
+	if existing.Word == "" {
+		if phonetic != "" {
+			opts.Phonetics = map[string]string{word: phonetic}
+		}
+		translations := map[string]string{}
+		if answer != "" {
+			translations[word] = answer
+		}
+		return w.addWords(words, opts, translations)
+	}
+	if err := w.addWords(words, opts, nil); err != nil {
+		return err
+	}
+	if answer != trans {
+		return w.SetTranslation(word, answer)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// fixed ids, so importing a newer export into anki updates the same note type and deck
+const (
+	ankiModelID = 1607392319
+	ankiDeckID  = 2059400110
+)
+
+// a front/back note of an anki deck
+type ankiNote struct {
+	Front string
+	Back  string
+}
+
+// schema of the anki2 collection, as written by genanki
+const ankiSchema = `
+CREATE TABLE col (
+    id integer primary key, crt integer not null, mod integer not null, scm integer not null,
+    ver integer not null, dty integer not null, usn integer not null, ls integer not null,
+    conf text not null, models text not null, decks text not null, dconf text not null, tags text not null
+);
+CREATE TABLE notes (
+    id integer primary key, guid text not null, mid integer not null, mod integer not null,
+    usn integer not null, tags text not null, flds text not null, sfld integer not null,
+    csum integer not null, flags integer not null, data text not null
+);
+CREATE TABLE cards (
+    id integer primary key, nid integer not null, did integer not null, ord integer not null,
+    mod integer not null, usn integer not null, type integer not null, queue integer not null,
+    due integer not null, ivl integer not null, factor integer not null, reps integer not null,
+    lapses integer not null, left integer not null, odue integer not null, odid integer not null,
+    flags integer not null, data text not null
+);
+CREATE TABLE revlog (
+    id integer primary key, cid integer not null, usn integer not null, ease integer not null,
+    ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null,
+    type integer not null
+);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn on notes (usn);
+CREATE INDEX ix_cards_usn on cards (usn);
+CREATE INDEX ix_revlog_usn on revlog (usn);
+CREATE INDEX ix_cards_nid on cards (nid);
+CREATE INDEX ix_cards_sched on cards (did, queue, due);
+CREATE INDEX ix_revlog_cid on revlog (cid);
+CREATE INDEX ix_notes_csum on notes (csum);
+`
+
+func ankiField(name string, ord int) map[string]any {
+	return map[string]any{
+		"name": name, "ord": ord, "sticky": false, "rtl": false,
+		"font": "Arial", "size": 20, "media": []any{},
+	}
+}
+
+func ankiCol(deckName string, now time.Time) (conf, models, decks, dconf string, err error) {
+	sec := now.Unix()
+	m := map[string]any{
+		strconv.Itoa(ankiModelID): map[string]any{
+			"id": ankiModelID, "name": "w2r", "type": 0, "mod": sec, "usn": -1,
+			"sortf": 0, "did": ankiDeckID, "tags": []any{}, "vers": []any{},
+			"flds": []any{ankiField("Front", 0), ankiField("Back", 1)},
+			"tmpls": []any{map[string]any{
+				"name": "Card 1", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "",
+				"qfmt": "{{Front}}",
+				"afmt": "{{FrontSide}}<hr id=answer>{{Back}}",
+			}},
+			"css":       ".card { font-family: arial; font-size: 24px; text-align: center; }",
+			"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+			"latexPost": "\\end{document}",
+			"req":       []any{[]any{0, "all", []any{0}}},
+		},
+	}
+	deck := func(id int, name string) map[string]any {
+		return map[string]any{
+			"id": id, "name": name, "desc": "", "mod": sec, "usn": -1, "collapsed": false,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0},
+			"timeToday": []int{0, 0}, "dyn": 0, "conf": 1, "extendNew": 10, "extendRev": 50,
+		}
+	}
+	d := map[string]any{
+		"1":                      deck(1, "Default"),
+		strconv.Itoa(ankiDeckID): deck(ankiDeckID, deckName),
+	}
+	dc := map[string]any{
+		"1": map[string]any{
+			"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60, "autoplay": true,
+			"timer": 0, "replayq": true, "dyn": false,
+			"new": map[string]any{
+				"delays": []int{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500,
+				"order": 1, "perDay": 20, "separate": true, "bury": true,
+			},
+			"rev": map[string]any{
+				"perDay": 100, "ease4": 1.3, "fuzz": 0.05, "maxIvl": 36500, "bury": true,
+				"minSpace": 1, "ivlFct": 1,
+			},
+			"lapse": map[string]any{
+				"delays": []int{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0,
+			},
+		},
+	}
+	c := map[string]any{
+		"activeDecks": []int{1}, "curDeck": 1, "newSpread": 0, "collapseTime": 1200,
+		"timeLim": 0, "estTimes": true, "dueCounts": true, "curModel": nil,
+		"nextPos": 1, "sortType": "noteFld", "sortBackwards": false, "addToCur": true,
+	}
+
+	var out [4][]byte
+	for i, v := range []any{c, m, d, dc} {
+		if out[i], err = json.Marshal(v); err != nil {
+			return
+		}
+	}
+	return string(out[0]), string(out[1]), string(out[2]), string(out[3]), nil
+}
+
+// stable guid of a note, derived from its front
+func ankiGUID(front string) string {
+	sum := sha1.Sum([]byte(front))
+	return base64.RawStdEncoding.EncodeToString(sum[:8])
+}
+
+// checksum of the sort field, first 8 hex digits of sha1
+func ankiChecksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// write the anki2 collection database at path
+func writeAnkiCollection(path, deckName string, notes []ankiNote) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(ankiSchema); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	conf, models, decks, dconf, err := ankiCol(deckName, now)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		now.Unix(), now.UnixMilli(), now.UnixMilli(), conf, models, decks, dconf)
+	if err != nil {
+		return err
+	}
+
+	baseID := now.UnixMilli()
+	for i, note := range notes {
+		noteID := baseID + int64(i)
+		_, err = tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, '', ?, ?, ?, 0, '')`,
+			noteID, ankiGUID(note.Front), ankiModelID, now.Unix(),
+			note.Front+"\x1f"+note.Back, note.Front, ankiChecksum(note.Front))
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			noteID, noteID, ankiDeckID, now.Unix(), i+1)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// write an anki package (.apkg) with notes, which is a zip of the collection database
+func writeApkg(out io.Writer, deckName string, notes []ankiNote) error {
+	dir, err := os.MkdirTemp("", "w2r-apkg")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	colPath := filepath.Join(dir, "collection.anki2")
+	if err := writeAnkiCollection(colPath, deckName, notes); err != nil {
+		return err
+	}
+	col, err := os.Open(colPath)
+	if err != nil {
+		return err
+	}
+	defer col.Close()
+
+	zw := zip.NewWriter(out)
+	f, err := zw.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, col); err != nil {
+		return err
+	}
+	f, err = zw.Create("media")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "{}"); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// export all words as an anki deck, word on front and translation on back
+func (w *WordDB) ExportApkg(out io.Writer, deckName string) error {
+	queries := worddb.New(w.Db)
+	words, err := queries.Listword(w.Ctx, w.DeckID)
+	if err != nil {
+		return err
+	}
+
+	notes := make([]ankiNote, 0, len(words))
+	for _, word := range words {
+		notes = append(notes, ankiNote{
+			Front: html.EscapeString(word.Word),
+			Back:  html.EscapeString(word.ZhTrans.String),
+		})
+	}
+	return writeApkg(out, deckName, notes)
+}
@@ -0,0 +1,644 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// json representation of a word in api responses
+type apiWord struct {
+	Word        string   `json:"word"`
+	ZhTrans     string   `json:"zh_trans"`
+	Phonetic    string   `json:"phonetic,omitempty"`
+	Level       string   `json:"level,omitempty"`
+	AddedCount  int64    `json:"added_count"`
+	LookupCount int64    `json:"lookup_count"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	Tags        []string `json:"tags"`
+	Note        string   `json:"note,omitempty"`
+	Context     string   `json:"context,omitempty"`
+	// translations into other languages by code, of single words
+	Translations map[string]string `json:"translations,omitempty"`
+	// where the word is from, like book titles, of single words
+	Sources []string `json:"sources,omitempty"`
+	// new, learning, known or mastered, and whether it's archived, of single
+	// words
+	Mastery  string `json:"mastery,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
+}
+
+func newAPIWord(word worddb.Word, tags []string) apiWord {
+	if tags == nil {
+		tags = []string{}
+	}
+	return apiWord{
+		Word:        word.Word,
+		ZhTrans:     word.ZhTrans.String,
+		Phonetic:    word.Phonetic.String,
+		Level:       word.Level.String,
+		AddedCount:  word.AddedCount.Int64,
+		LookupCount: word.LookupCount.Int64,
+		CreatedAt:   formatTimestamp(word.CreatedAt),
+		UpdatedAt:   formatTimestamp(word.UpdatedAt),
+		Tags:        tags,
+		Note:        word.Note.String,
+		Context:     word.Context.String,
+	}
+}
+
+// body of POST /api/words and PUT /api/words/{word}, lang selects another
+// language than the default one of zh_trans
+type apiWordRequest struct {
+	Word    string   `json:"word"`
+	ZhTrans string   `json:"zh_trans"`
+	Lang    string   `json:"lang"`
+	Tags    []string `json:"tags"`
+	Note    string   `json:"note"`
+	Context string   `json:"context"`
+	Source  string   `json:"source"`
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Printf("write json response: %v", err)
+	}
+}
+
+func writeJSONError(rw http.ResponseWriter, status int, msg string) {
+	writeJSON(rw, status, map[string]string{"error": msg})
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// register json api handlers for word CRUD, review and sync, replies which
+// only change with the data are cached
+func (w *WordDB) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/words", w.cached(w.apiListWords))
+	mux.HandleFunc("POST /api/words", w.apiCreateWord)
+	mux.HandleFunc("GET /api/words/{word}", w.cached(w.apiGetWord))
+	mux.HandleFunc("PUT /api/words/{word}", w.apiUpdateWord)
+	mux.HandleFunc("DELETE /api/words/{word}", w.apiDeleteWord)
+	mux.HandleFunc("POST /api/words/{word}/enrich", w.apiEnrichWord)
+	mux.HandleFunc("GET /api/words/{word}/sentences", w.cached(w.apiListSentences))
+	mux.HandleFunc("POST /api/words/{word}/sentences", w.apiAddSentence)
+	mux.HandleFunc("DELETE /api/sentences/{id}", w.apiDeleteSentence)
+	mux.HandleFunc("GET /api/words/{word}/related", w.apiRelatedWords)
+	mux.HandleFunc("GET /api/tags", w.cached(w.apiListTags))
+	mux.HandleFunc("GET /api/sources", w.cached(w.apiListSources))
+	mux.HandleFunc("GET /api/search", w.cached(w.apiSearch))
+	mux.HandleFunc("GET /api/review/next", w.apiNextReview)
+	mux.HandleFunc("POST /api/review/answer", w.apiAnswerReview)
+	mux.HandleFunc("GET /api/review/history", w.apiReviewHistory)
+	mux.HandleFunc("GET /api/review/retention", w.apiRetention)
+	mux.HandleFunc("POST /api/sync", w.apiSync)
+	mux.HandleFunc("GET /api/stats", w.apiStats)
+	mux.HandleFunc("GET /api/wotd", w.apiWordOfTheDay)
+}
+
+// reply word with its tags
+func (w *WordDB) writeAPIWord(rw http.ResponseWriter, r *http.Request, status int, word string) {
+	result, err := w.Store.Get(r.Context(), word)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	tags, err := w.Store.WordTags(r.Context())
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	translations, err := w.Store.Translations(r.Context(), word)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sources, err := w.Store.WordSources(r.Context(), word)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	mastery, archived, err := w.Store.WordMastery(r.Context(), word)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	reply := newAPIWord(result, tags[word])
+	reply.Translations = translations
+	reply.Sources = sources
+	reply.Mastery, reply.Archived = mastery, archived
+	writeJSON(rw, status, reply)
+}
+
+// deck selected by ?deck=name, or the deck of the server, it replies 404 if
+// the deck doesn't exist
+func (w *WordDB) apiDeck(rw http.ResponseWriter, r *http.Request) (int64, bool) {
+	name := r.URL.Query().Get("deck")
+	if name == "" {
+		return w.DeckID, true
+	}
+	id, err := w.Store.DeckID(r.Context(), name)
+	if errors.Is(err, wordstore.ErrDeckNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return 0, false
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return 0, false
+	}
+	return id, true
+}
+
+func (w *WordDB) apiListWords(rw http.ResponseWriter, r *http.Request) {
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+	words, err := w.Store.List(r.Context(), wordstore.ListOptions{
+		Tag:      r.URL.Query().Get("tag"),
+		Level:    strings.ToUpper(r.URL.Query().Get("level")),
+		Source:   r.URL.Query().Get("source"),
+		Archived: r.URL.Query().Get("archived") == "1",
+		Mastery:  r.URL.Query().Get("mastery"),
+		DeckID:   deckID,
+	})
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	tags, err := w.Store.WordTags(r.Context())
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := make([]apiWord, 0, len(words))
+	for _, word := range words {
+		results = append(results, newAPIWord(word, tags[word.Word]))
+	}
+	writeJSON(rw, http.StatusOK, results)
+}
+
+// full-text search with ?q=
+func (w *WordDB) apiSearch(rw http.ResponseWriter, r *http.Request) {
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+	words, err := searchWords(r.Context(), w.Db, r.URL.Query().Get("q"), deckID)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	tags, err := w.Store.WordTags(r.Context())
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := make([]apiWord, 0, len(words))
+	for _, word := range words {
+		results = append(results, newAPIWord(word, tags[word.Word]))
+	}
+	writeJSON(rw, http.StatusOK, results)
+}
+
+func (w *WordDB) apiListTags(rw http.ResponseWriter, r *http.Request) {
+	queries := worddb.New(w.Db)
+	tags, err := queries.ListTags(r.Context())
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	type apiTag struct {
+		Name      string `json:"name"`
+		WordCount int64  `json:"word_count"`
+	}
+	results := make([]apiTag, 0, len(tags))
+	for _, tag := range tags {
+		results = append(results, apiTag{Name: tag.Name, WordCount: tag.WordCount})
+	}
+	writeJSON(rw, http.StatusOK, results)
+}
+
+func (w *WordDB) apiListSources(rw http.ResponseWriter, r *http.Request) {
+	sources, err := w.Store.Sources(r.Context())
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	type apiSource struct {
+		Source    string `json:"source"`
+		WordCount int64  `json:"word_count"`
+	}
+	results := make([]apiSource, 0, len(sources))
+	for _, source := range sources {
+		results = append(results, apiSource{Source: source.Source, WordCount: source.WordCount})
+	}
+	writeJSON(rw, http.StatusOK, results)
+}
+
+// totals, words added per day and the most looked up words, ?top=n sets the
+// number of words
+func (w *WordDB) apiStats(rw http.ResponseWriter, r *http.Request) {
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+	stats, err := w.Store.Stats(r.Context(), deckID)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	perDay, err := w.addedPerDay(r.Context(), deckID)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	top := min(max(queryInt(r.URL.Query(), "top", 10), 0), 100)
+	lookups, err := w.Store.Queries().ListTopLookups(r.Context(), worddb.ListTopLookupsParams{DeckID: deckID, Limit: int64(top)})
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	streak, err := w.streak(r.Context())
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	type apiLookup struct {
+		Word        string `json:"word"`
+		ZhTrans     string `json:"zh_trans"`
+		LookupCount int64  `json:"lookup_count"`
+	}
+	result := struct {
+		Words       int64 `json:"words"`
+		Translated  int64 `json:"translated"`
+		Due         int64 `json:"due"`
+		Tags        int64 `json:"tags"`
+		AddedCount  int64 `json:"added_count"`
+		LookupCount int64 `json:"lookup_count"`
+		// words by cefr level, "" for words without one
+		Levels map[string]int64 `json:"levels"`
+		// words by mastery state, new, learning, known or mastered
+		Mastery  map[string]int64 `json:"mastery"`
+		Archived int64            `json:"archived"`
+		// words added by local date of the server, like 2006-01-02
+		AddedPerDay map[string]int64 `json:"added_per_day"`
+		TopLookups  []apiLookup      `json:"top_lookups"`
+		// of all decks
+		Streak Streak `json:"streak"`
+	}{
+		Words:       stats.Words,
+		Translated:  stats.Translated,
+		Due:         stats.Due,
+		Tags:        stats.Tags,
+		AddedCount:  stats.AddedCount,
+		LookupCount: stats.LookupCount,
+		Levels:      stats.Levels,
+		Mastery:     stats.Mastery,
+		Archived:    stats.Archived,
+		AddedPerDay: perDay,
+		TopLookups:  make([]apiLookup, 0, len(lookups)),
+		Streak:      streak,
+	}
+	for _, row := range lookups {
+		result.TopLookups = append(result.TopLookups, apiLookup{Word: row.Word, ZhTrans: row.ZhTrans.String, LookupCount: row.LookupCount.Int64})
+	}
+	writeJSON(rw, http.StatusOK, result)
+}
+
+// the word of the day, or 404 if the deck has no words
+func (w *WordDB) apiWordOfTheDay(rw http.ResponseWriter, r *http.Request) {
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+	wotd, err := w.wordOfTheDay(r.Context(), deckID)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(rw, http.StatusOK, wotd)
+}
+
+// {word} of the url, normalized like the stored words
+func pathWord(r *http.Request) string {
+	return wordstore.NormalizeWord(r.PathValue("word"))
+}
+
+func (w *WordDB) apiGetWord(rw http.ResponseWriter, r *http.Request) {
+	w.writeAPIWord(rw, r, http.StatusOK, pathWord(r))
+}
+
+// create a word, or increase added_count if the word already exists
+func (w *WordDB) apiCreateWord(rw http.ResponseWriter, r *http.Request) {
+	var req apiWordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	word := wordstore.NormalizeWord(req.Word)
+	if !wordstore.IsValidWord(word) {
+		writeJSONError(rw, http.StatusBadRequest, "invalid word")
+		return
+	}
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+
+	results, err := w.Store.Add(r.Context(), []string{word}, wordstore.AddOptions{
+		Translations: map[string]string{word: req.ZhTrans},
+		Tags:         req.Tags,
+		DeckID:       deckID,
+		Note:         req.Note,
+		Context:      req.Context,
+		Source:       req.Source,
+	})
+	if err == nil {
+		err = results[0].Err
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if results[0].Created {
+		status = http.StatusCreated
+	}
+	w.writeAPIWord(rw, r, status, word)
+}
+
+// update translation of a word, or its translation into req.Lang
+func (w *WordDB) apiUpdateWord(rw http.ResponseWriter, r *http.Request) {
+	var req apiWordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	word := pathWord(r)
+	var err error
+	if req.Lang != "" && !strings.EqualFold(req.Lang, w.TransLang) {
+		err = w.Store.SetLangTranslation(r.Context(), req.Lang, word, req.ZhTrans)
+	} else {
+		err = w.Store.SetTranslation(r.Context(), word, req.ZhTrans)
+	}
+	if errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.writeAPIWord(rw, r, http.StatusOK, word)
+}
+
+// definition, mnemonic and example sentences of a word by the llm, cached
+// unless ?force=true
+func (w *WordDB) apiEnrichWord(rw http.ResponseWriter, r *http.Request) {
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+	e, err := w.enrich(r.Context(), pathWord(r), force)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(rw, http.StatusOK, newEnrichment(e))
+}
+
+type apiSentence struct {
+	ID       int64  `json:"id"`
+	Sentence string `json:"sentence"`
+	Source   string `json:"source,omitempty"`
+}
+
+// example sentences of a word, or 404 if the word doesn't exist
+func (w *WordDB) writeAPISentences(rw http.ResponseWriter, r *http.Request, status int, word string) {
+	if _, err := w.Store.Get(r.Context(), word); errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	sentences, err := w.Store.Sentences(r.Context(), word)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	results := make([]apiSentence, 0, len(sentences))
+	for _, s := range sentences {
+		results = append(results, apiSentence{ID: s.ID, Sentence: s.Sentence, Source: s.Source.String})
+	}
+	writeJSON(rw, status, results)
+}
+
+func (w *WordDB) apiListSentences(rw http.ResponseWriter, r *http.Request) {
+	w.writeAPISentences(rw, r, http.StatusOK, pathWord(r))
+}
+
+// add a sentence to a word, and reply all its sentences, 201 if the sentence
+// is new
+func (w *WordDB) apiAddSentence(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Sentence string `json:"sentence"`
+		Source   string `json:"source"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if strings.TrimSpace(req.Sentence) == "" {
+		writeJSONError(rw, http.StatusBadRequest, "sentence is required")
+		return
+	}
+
+	word := pathWord(r)
+	added, err := w.Store.AddSentence(r.Context(), word, req.Sentence, req.Source)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	w.writeAPISentences(rw, r, status, word)
+}
+
+func (w *WordDB) apiDeleteSentence(rw http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(rw, http.StatusBadRequest, "invalid sentence id")
+		return
+	}
+	err = w.Store.DeleteSentence(r.Context(), id)
+	if errors.Is(err, wordstore.ErrSentenceNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+type apiRelatedWord struct {
+	Word  string `json:"word"`
+	Added bool   `json:"added"`
+}
+
+// synonyms and antonyms of a word, fetched from datamuse the first time or
+// if ?refresh=true
+func (w *WordDB) apiRelatedWords(rw http.ResponseWriter, r *http.Request) {
+	refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh"))
+	related, err := w.related(r.Context(), pathWord(r), refresh)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusBadGateway, err.Error())
+		return
+	}
+	results := map[string][]apiRelatedWord{
+		"synonyms": {},
+		"antonyms": {},
+	}
+	for _, rel := range related {
+		key := rel.Kind + "s"
+		results[key] = append(results[key], apiRelatedWord{Word: rel.Related, Added: rel.Added != 0})
+	}
+	writeJSON(rw, http.StatusOK, results)
+}
+
+func (w *WordDB) apiDeleteWord(rw http.ResponseWriter, r *http.Request) {
+	err := w.Store.Delete(r.Context(), pathWord(r))
+	if errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// reply the most overdue card with the number of due cards, or 204 if
+// nothing is due
+func (w *WordDB) apiNextReview(rw http.ResponseWriter, r *http.Request) {
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+	queries := worddb.New(w.Db)
+	now := time.Now().UTC()
+	words, err := queries.ListDueWords(r.Context(), worddb.ListDueWordsParams{DueAt: now, DeckID: deckID, Limit: 1})
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(words) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	due, err := queries.CountDueWords(r.Context(), worddb.CountDueWordsParams{DueAt: now, DeckID: deckID})
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, struct {
+		Word    string `json:"word"`
+		ZhTrans string `json:"zh_trans"`
+		Due     int64  `json:"due"`
+	}{words[0].Word, words[0].ZhTrans.String, due})
+}
+
+// record a grade of word, grade is 1~4 or again|hard|good|easy
+func (w *WordDB) apiAnswerReview(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Word  string `json:"word"`
+		Grade string `json:"grade"`
+		// milliseconds from showing the word to the answer, for the review log
+		LatencyMs int64 `json:"latency_ms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(rw, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	g, ok := parseGrade(req.Grade)
+	if !ok {
+		writeJSONError(rw, http.StatusBadRequest, "invalid grade")
+		return
+	}
+
+	// trashed words can't be reviewed
+	word := wordstore.NormalizeWord(req.Word)
+	if _, err := w.Store.Get(r.Context(), word); errors.Is(err, wordstore.ErrNotFound) {
+		writeJSONError(rw, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// words never reviewed have no review row yet
+	review, err := worddb.New(w.Db).GetReview(r.Context(), word)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	card := Card{Ease: review.Ease, IntervalDays: review.IntervalDays, Repetitions: review.Repetitions}
+	next, err := w.gradeWord(r.Context(), word, card, g, time.Duration(req.LatencyMs)*time.Millisecond)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	reply := struct {
+		Word         string `json:"word"`
+		IntervalDays int64  `json:"interval_days"`
+		// new, learning, known or mastered, mastered words are archived
+		Mastery string `json:"mastery"`
+		// box of the leitner system, if it's the algorithm of the config
+		Box int `json:"box,omitempty"`
+	}{Word: word, IntervalDays: next.IntervalDays, Mastery: next.Mastery()}
+	if config.Review.Algorithm == algorithmLeitner {
+		reply.Box = next.Box()
+	}
+	writeJSON(rw, http.StatusOK, reply)
+}
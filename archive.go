@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log"
+)
+
+func (w *WordDB) Archive(words []string) error {
+	n, err := w.Store.Archive(w.Ctx, words)
+	if err != nil {
+		return err
+	}
+	log.Printf("archive %d word(s)", n)
+	return nil
+}
+
+func (w *WordDB) Unarchive(words []string) error {
+	n, err := w.Store.Unarchive(w.Ctx, words)
+	if err != nil {
+		return err
+	}
+	log.Printf("unarchive %d word(s)", n)
+	return nil
+}
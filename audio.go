@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+var errNoAudio = errors.New("no pronunciation audio found")
+
+// directory of cached audio files
+func audioCacheDir() (string, error) {
+	if config.Audio.CacheDir != "" {
+		return config.Audio.CacheDir, nil
+	}
+	cacheDir, err := xdgDir("XDG_CACHE_HOME", ".cache")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "w2r", "audio"), nil
+}
+
+// url of the pronunciation of word in the accent of the config, from the
+// free dictionary api, or youdao which has every word if it has none or fails.
+// Words of other languages than english are only pronounced by youdao, and
+// only of the languages it has.
+func audioURL(ctx context.Context, word string) (string, error) {
+	entries, err := dictionaryAPILookup(ctx, word)
+	if err != nil && !errors.Is(err, errNotInFreeDict) {
+		log.Printf("audio of '%s': %v, trying youdao", word, err)
+	}
+	// the files are named like apple-us.mp3, another accent is better than
+	// none
+	var fallback string
+	for _, entry := range entries {
+		for _, phonetic := range entry.Phonetics {
+			if phonetic.Audio == "" {
+				continue
+			}
+			if strings.HasSuffix(phonetic.Audio, "-"+config.Audio.Accent+".mp3") {
+				return phonetic.Audio, nil
+			}
+			if fallback == "" {
+				fallback = phonetic.Audio
+			}
+		}
+	}
+	if fallback != "" {
+		return fallback, nil
+	}
+	le, ok := youdaoLang()
+	if !ok {
+		return "", fmt.Errorf("%w for '%s', youdao has no %s pronunciations", errNoAudio, word, config.Language)
+	}
+	// type 1 is the uk accent, 2 the us one
+	accent := "2"
+	if config.Audio.Accent == "uk" {
+		accent = "1"
+	}
+	return "https://dict.youdao.com/dictvoice?type=" + accent + "&le=" + le + "&audio=" + url.QueryEscape(word), nil
+}
+
+// path of the cached pronunciation of word, downloaded first if it's not
+// cached yet
+func audioFile(ctx context.Context, word string) (string, error) {
+	// the word is part of the path
+	if !wordstore.IsValidWord(word) {
+		return "", fmt.Errorf("%w for '%s'", errNoAudio, word)
+	}
+	dir, err := audioCacheDir()
+	if err != nil {
+		return "", err
+	}
+	// words of other languages have no accents, and may be english words too
+	suffix := config.Audio.Accent
+	if !wordstore.IsEnglish() {
+		suffix = config.Language
+	}
+	path := filepath.Join(dir, word+"-"+suffix+".mp3")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Translate.Timeout)
+	defer cancel()
+	u, err := audioURL(ctx, word)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := doRemote(req, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "audio/") && ct != "application/octet-stream" {
+		return "", fmt.Errorf("%w for '%s', %s is %s", errNoAudio, word, u, ct)
+	}
+
+	// written to a temporary file first, so a failed download isn't cached
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, word+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// command playing the mp3 file at path, the player of the config, or afplay
+// on macos, a media player of powershell on windows, and the first of some
+// common players found elsewhere
+func playerCommand(ctx context.Context, path string) (*exec.Cmd, error) {
+	if args := strings.Fields(config.Audio.Player); len(args) > 0 {
+		return exec.CommandContext(ctx, args[0], append(args[1:], path)...), nil
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.CommandContext(ctx, "afplay", path), nil
+	case "windows":
+		// the path is passed in the environment to avoid quoting
+		cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsPlay)
+		cmd.Env = append(os.Environ(), "W2R_AUDIO="+path)
+		return cmd, nil
+	}
+	for _, args := range [][]string{
+		{"mpv", "--no-video", "--really-quiet"},
+		{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+		{"mpg123", "-q"},
+		{"cvlc", "--play-and-exit", "--quiet"},
+	} {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return exec.CommandContext(ctx, args[0], append(args[1:], path)...), nil
+		}
+	}
+	return nil, errors.New("no audio player found, install mpv, ffplay or mpg123, or set player in [audio] of the config file")
+}
+
+const windowsPlay = `Add-Type -AssemblyName PresentationCore
+$player = New-Object System.Windows.Media.MediaPlayer
+$player.Open([Uri]$env:W2R_AUDIO)
+while (-not $player.NaturalDuration.HasTimeSpan) { Start-Sleep -Milliseconds 50 }
+$player.Play()
+Start-Sleep -Milliseconds ($player.NaturalDuration.TimeSpan.TotalMilliseconds + 100)`
+
+func playAudio(ctx context.Context, path string) error {
+	cmd, err := playerCommand(ctx, path)
+	if err != nil {
+		return err
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// pronounce words one by one, with their ipa if they are in the database.
+// A failed word doesn't stop the rest.
+func (w *WordDB) Say(words []string) error {
+	failed := 0
+	for _, word := range words {
+		line := word
+		if result, err := w.Store.Get(w.Ctx, word); err == nil && result.Phonetic.Valid {
+			line += " " + result.Phonetic.String
+		}
+		fmt.Println(line)
+		path, err := audioFile(w.Ctx, word)
+		if err == nil {
+			err = playAudio(w.Ctx, path)
+		}
+		if err != nil {
+			failed++
+			log.Printf("say '%s': %v", word, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to say %d of %d word(s)", failed, len(words))
+	}
+	return nil
+}
+
+// GET /audio/{word}.mp3, the cached pronunciation of word, downloaded on the
+// first request
+func serveAudio(rw http.ResponseWriter, r *http.Request) {
+	word, ok := strings.CutSuffix(r.PathValue("file"), ".mp3")
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	path, err := audioFile(r.Context(), wordstore.NormalizeWord(word))
+	if errors.Is(err, errNoAudio) {
+		http.Error(rw, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("audio of '%s': %v", word, err)
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+	rw.Header().Set("Content-Type", "audio/mpeg")
+	rw.Header().Set("Cache-Control", "max-age=86400")
+	http.ServeFile(rw, r, path)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// credentials of the web server, authentication is disabled if both are empty
+type AuthConfig struct {
+	// user:password for http basic auth
+	BasicAuth string
+	// token for "Authorization: Bearer <token>", also accepted as the basic
+	// auth password of any user, so browsers can log in with it
+	Token string
+}
+
+func (a AuthConfig) Enabled() bool {
+	return a.BasicAuth != "" || a.Token != ""
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// check the Authorization header of r
+func (a AuthConfig) authorized(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return a.Token != "" && secureEqual(token, a.Token)
+	}
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	if a.BasicAuth != "" && secureEqual(user+":"+password, a.BasicAuth) {
+		return true
+	}
+	return a.Token != "" && secureEqual(password, a.Token)
+}
+
+// wrap h to reject unauthorized requests, h is returned as is if
+// authentication is disabled
+func (a AuthConfig) Wrap(h http.Handler) http.Handler {
+	if !a.Enabled() {
+		return h
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !a.authorized(r) {
+			unauthorized(rw, r)
+			return
+		}
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// reply 401, asking browsers for basic auth
+func unauthorized(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("WWW-Authenticate", `Basic realm="w2r", charset="UTF-8"`)
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeJSONError(rw, http.StatusUnauthorized, "unauthorized")
+	} else {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+	}
+}
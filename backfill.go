@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// options of translating words in the database
+type BackfillOptions struct {
+	// fetch ipa instead of translations
+	Phonetic bool
+	// the words without translation, or ipa, instead of the given words
+	Missing bool
+	// max requests per second to the provider
+	Rate float64
+	// attempts after a failed request, waiting longer each time
+	Retries int
+	// max number of words, 0 for all
+	Limit int
+}
+
+// whether a failed translation or ipa lookup may succeed by trying again, like on network
+// errors, 429 Too Many Requests or server errors
+func retryable(err error) bool {
+	var statusErr *httpStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.code == 429 || statusErr.code >= 500
+	case errors.Is(err, errNoTranslation), errors.Is(err, errNoPhonetic), errors.Is(err, errNotInDict),
+		errors.Is(err, errNotInFreeDict), errors.Is(err, errNoAPIKey), errors.Is(err, errUnsupportedLang), errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+// translate word by t, retrying retryable errors after 1s, 2s, 4s...
+func translateRetry(ctx context.Context, t Translator, word string, retries int) (string, error) {
+	wait := time.Second
+	for attempt := 0; ; attempt++ {
+		trans, err := t.Translate(ctx, word)
+		if err == nil || attempt >= retries || !retryable(err) {
+			return trans, err
+		}
+		log.Printf("'%s': %v, retrying in %s", word, err, wait)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// translate words by t, or look up their ipa if opts.Phonetic, or of the
+// words without one if opts.Missing, at most opts.Rate requests per second,
+// and store the results in one transaction at the end. On Ctrl-C the results
+// so far are stored.
+func (w *WordDB) Backfill(t Translator, words []string, opts BackfillOptions) error {
+	what := "translation"
+	if opts.Phonetic {
+		what = "ipa"
+	} else if w.otherLang() {
+		what = config.Translate.Lang + " translation"
+	}
+	if opts.Missing {
+		limit := int64(opts.Limit)
+		if limit <= 0 {
+			limit = -1
+		}
+		var err error
+		switch {
+		case opts.Phonetic:
+			words, err = w.Store.Queries().ListWordsWithoutPhonetic(w.Ctx, worddb.ListWordsWithoutPhoneticParams{DeckID: w.DeckID, Limit: limit})
+		case w.otherLang():
+			words, err = w.Store.Queries().ListWordsWithoutLangTranslation(w.Ctx, worddb.ListWordsWithoutLangTranslationParams{Lang: strings.ToLower(config.Translate.Lang), DeckID: w.DeckID, Limit: limit})
+		default:
+			words, err = w.Store.Queries().ListUntranslatedWords(w.Ctx, worddb.ListUntranslatedWordsParams{DeckID: w.DeckID, Limit: limit})
+		}
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			log.Printf("all words have %s", what)
+			return nil
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(w.Ctx, os.Interrupt)
+	defer stop()
+	tick := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+	defer tick.Stop()
+	results := make(map[string]string)
+	failed := 0
+loop:
+	for i, word := range words {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				break loop
+			case <-tick.C:
+			}
+		}
+		result, err := translateRetry(ctx, t, word, opts.Retries)
+		switch {
+		case ctx.Err() != nil:
+			break loop
+		case errors.Is(err, errNoAPIKey), errors.Is(err, errUnsupportedLang):
+			return err
+		case err != nil:
+			failed++
+			log.Printf("%s of '%s': %v", what, word, err)
+		case result != "":
+			results[word] = result
+			fmt.Printf("%s: %s\n", word, result)
+		}
+	}
+	if ctx.Err() != nil {
+		log.Printf("interrupted, saving the results so far")
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("no %s found for %d word(s)", what, len(words))
+	}
+	// saved even if interrupted, so w.Ctx rather than ctx
+	var set int
+	var err error
+	switch {
+	case opts.Phonetic:
+		set, err = w.Store.SetPhonetics(w.Ctx, results)
+	case w.otherLang():
+		set, err = w.Store.SetLangTranslations(w.Ctx, config.Translate.Lang, results)
+	default:
+		set, err = w.Store.SetTranslations(w.Ctx, results)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("set %s of %d of %d word(s), %d failed", what, set, len(words), failed)
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// backups are kept in a directory next to the database, like
+// ~/.local/share/w2r/word.sqlite.backups/20240102-150405.000.sqlite
+func (w *WordDB) backupDir() string {
+	return w.Path + ".backups"
+}
+
+// timestamped path of a new backup in backupDir
+func (w *WordDB) newBackupPath(prefix string) (string, error) {
+	if err := os.MkdirAll(w.backupDir(), 0o700); err != nil {
+		return "", err
+	}
+	return filepath.Join(w.backupDir(), prefix+time.Now().Format("20060102-150405.000")+".sqlite"), nil
+}
+
+// copy the database to path, or a timestamped file in backupDir if empty
+func (w *WordDB) Backup(path string) error {
+	// checked before creating backupDir, which is no directory for a url
+	if wordstore.IsRemote(w.Path) {
+		return fmt.Errorf("backup: %w", wordstore.ErrRemote)
+	}
+	if path == "" {
+		var err error
+		if path, err = w.newBackupPath(""); err != nil {
+			return err
+		}
+	}
+	if err := w.Store.Backup(w.Ctx, path); err != nil {
+		return err
+	}
+	log.Printf("backup database to %s", path)
+	return nil
+}
+
+// back up before a destructive operation, if enabled by --auto-backup, the
+// hosting service of a remote database keeps its own backups
+func (w *WordDB) autoBackup() error {
+	if !w.AutoBackup || wordstore.IsRemote(w.Path) {
+		return nil
+	}
+	return w.Backup("")
+}
+
+// replace the database with a backup, the current database is backed up
+// first, so the restore can be reverted too
+func (w *WordDB) RestoreBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	if err := w.Backup(""); err != nil {
+		return err
+	}
+	if err := w.Store.RestoreBackup(w.Ctx, path); err != nil {
+		return err
+	}
+	log.Printf("restore database from %s", path)
+	return nil
+}
+
+// copy the database to remote storage, see parseRemote
+func (w *WordDB) BackupRemote(remote string) error {
+	storage, key, err := parseRemote(remote)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "w2r-backup-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// a consistent copy first, the database may be written while uploading
+	path := filepath.Join(dir, "word.sqlite")
+	if err := w.Store.Backup(w.Ctx, path); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	key = remoteBackupKey(key)
+	if err := storage.Put(w.Ctx, key, data); err != nil {
+		return err
+	}
+	log.Printf("backup database to %s", key)
+	return nil
+}
+
+// replace the database with a backup in remote storage, like RestoreBackup
+func (w *WordDB) RestoreRemote(remote string) error {
+	storage, key, err := parseRemote(remote)
+	if err != nil {
+		return err
+	}
+	data, err := storage.Get(w.Ctx, key)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "w2r-restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "word.sqlite")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	return w.RestoreBackup(path)
+}
+
+// BackupSchedule is how often the web server backs up the database, and how
+// many of the scheduled backups are kept.
+type BackupSchedule struct {
+	Interval time.Duration
+	Keep     int
+}
+
+// scheduled backups in backupDir, the oldest first
+func (w *WordDB) scheduledBackups() ([]string, error) {
+	return filepath.Glob(filepath.Join(w.backupDir(), "scheduled-*.sqlite"))
+}
+
+// back up the database, and remove the oldest scheduled backups but keep
+func (w *WordDB) scheduledBackup(ctx context.Context, keep int) error {
+	path, err := w.newBackupPath("scheduled-")
+	if err != nil {
+		return err
+	}
+	if err := w.Store.Backup(ctx, path); err != nil {
+		return err
+	}
+	log.Printf("scheduled backup of database to %s", path)
+
+	backups, err := w.scheduledBackups()
+	if err != nil {
+		return err
+	}
+	for _, old := range backups[:max(len(backups)-keep, 0)] {
+		if err := os.Remove(old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// back up the database every interval until ctx is done, the first backup is
+// due one interval after the latest scheduled backup, so restarting the
+// server doesn't restart the schedule
+func (w *WordDB) runScheduledBackups(ctx context.Context, schedule BackupSchedule) {
+	var next time.Duration
+	if backups, _ := w.scheduledBackups(); len(backups) > 0 {
+		if info, err := os.Stat(backups[len(backups)-1]); err == nil {
+			next = max(time.Until(info.ModTime().Add(schedule.Interval)), 0)
+		}
+	}
+
+	timer := time.NewTimer(next)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		// keep the schedule after failures, the next backup may succeed
+		if err := w.scheduledBackup(ctx, schedule.Keep); err != nil {
+			log.Printf("scheduled backup: %v", err)
+		}
+		timer.Reset(schedule.Interval)
+	}
+}
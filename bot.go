@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// text formatting of a chat platform
+type botMarkup interface {
+	escape(s string) string
+	bold(s string) string
+	// hidden until tapped, for the answer of a flashcard
+	spoiler(s string) string
+}
+
+// commands of the chat bots, prefix is like /, plainAdd tells words may be
+// sent without the add command
+func botHelp(prefix string, plainAdd bool) string {
+	help := fmt.Sprintf(`%[1]sadd <words> - add words, separated by spaces, commas or lines
+%[1]slist [n] - the most recently added words
+%[1]squiz - a flashcard of a word due for review
+%[1]shelp - this message`, prefix)
+	if plainAdd {
+		help = "Send words to add them, or use the commands:\n" + help
+	}
+	return help
+}
+
+// reply of the chat bots to text, a command starting with prefix or words to
+// add to deckID, new words are translated by t if it's not nil
+func (w *WordDB) botReply(ctx context.Context, t Translator, deckID int64, prefix, text string, m botMarkup) (string, error) {
+	if !strings.HasPrefix(text, prefix) {
+		return w.botAdd(ctx, t, deckID, text, m)
+	}
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	// commands in telegram groups are like /list@w2rbot
+	cmd, _, _ := strings.Cut(strings.TrimPrefix(fields[0], prefix), "@")
+	switch cmd {
+	case "add":
+		return w.botAdd(ctx, t, deckID, strings.Join(fields[1:], " "), m)
+	case "list":
+		n := 10
+		if len(fields) > 1 {
+			n, _ = strconv.Atoi(fields[1])
+		}
+		return w.botList(ctx, deckID, min(max(n, 1), 50), m)
+	case "quiz":
+		return w.botQuiz(ctx, deckID, m)
+	}
+	return m.escape(botHelp(prefix, true)), nil
+}
+
+func (w *WordDB) botAdd(ctx context.Context, t Translator, deckID int64, text string, m botMarkup) (string, error) {
+	var words, invalid []string
+	for _, s := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		if word := wordstore.NormalizeWord(s); wordstore.IsValidWord(word) {
+			words = append(words, word)
+		} else {
+			invalid = append(invalid, s)
+		}
+	}
+
+	var lines []string
+	if len(words) > 0 {
+		words, variants := w.lemmatize(ctx, words)
+		opts := wordstore.AddOptions{Translations: w.translateNew(ctx, t, words), DeckID: deckID, Variants: variants}
+		results, err := w.Store.Add(ctx, words, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, result := range results {
+			switch {
+			case result.Err != nil:
+				lines = append(lines, fmt.Sprintf("failed to add %s: %s", m.bold(result.Word), m.escape(result.Err.Error())))
+			case result.Created:
+				line := "added " + m.bold(result.Word)
+				if trans := opts.Translations[result.Word]; trans != "" {
+					line += ": " + m.escape(trans)
+				}
+				lines = append(lines, line)
+			default:
+				lines = append(lines, m.bold(result.Word)+" is already added, added_count++")
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		lines = append(lines, m.escape("not valid words: "+strings.Join(invalid, ", ")))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (w *WordDB) botList(ctx context.Context, deckID int64, n int, m botMarkup) (string, error) {
+	words, err := w.Store.List(ctx, wordstore.ListOptions{Sort: "date", Limit: n, DeckID: deckID})
+	if err != nil {
+		return "", err
+	}
+	if len(words) == 0 {
+		return "no words yet", nil
+	}
+	lines := make([]string, 0, len(words))
+	for _, word := range words {
+		lines = append(lines, strings.TrimSpace(m.bold(word.Word)+" "+m.escape(word.ZhTrans.String)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// the most overdue word, or a random translated word if nothing is due, with
+// its translation hidden
+func (w *WordDB) botQuiz(ctx context.Context, deckID int64, m botMarkup) (string, error) {
+	queries := w.Store.Queries()
+	due, err := queries.ListDueWords(ctx, worddb.ListDueWordsParams{DueAt: time.Now().UTC(), DeckID: deckID, Limit: 1})
+	if err != nil {
+		return "", err
+	}
+	word, zhTrans := "", ""
+	if len(due) > 0 {
+		word, zhTrans = due[0].Word, due[0].ZhTrans.String
+	} else {
+		words, err := queries.ListQuizWords(ctx, worddb.ListQuizWordsParams{DeckID: deckID, Limit: 1})
+		if err != nil {
+			return "", err
+		}
+		if len(words) == 0 {
+			return "no words yet", nil
+		}
+		word, zhTrans = words[0].Word, words[0].ZhTrans.String
+	}
+	if zhTrans == "" {
+		zhTrans = "(no translation)"
+	}
+	return m.bold(word) + "\n" + m.spoiler(zhTrans), nil
+}
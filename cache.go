@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// responses of an older build or config of the server are never reused
+var serverStarted = time.Now()
+
+// a response writer dropping the cache headers of errors, so they aren't
+// revalidated as if they were the page
+type cacheWriter struct {
+	http.ResponseWriter
+}
+
+func (c cacheWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest {
+		c.Header().Del("ETag")
+		c.Header().Del("Last-Modified")
+		c.Header().Del("Cache-Control")
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c cacheWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// whether the copy of the client, told by If-None-Match or else
+// If-Modified-Since, is still fresh
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+			// weak comparison, the same page gzipped or not is the same
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.After(since)
+}
+
+// wrap h, a page or api reply which only changes with the data, to reply 304
+// Not Modified if the data hasn't changed since the client got it. The ETag
+// and Last-Modified are of the data revision, the day, as some pages show
+// the progress of today, and the start of the server. Clients always
+// revalidate, so changes show up at once.
+func (w *WordDB) cached(h http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h(rw, r)
+			return
+		}
+		revision, updated, err := w.Store.Revision(r.Context())
+		if err != nil {
+			h(rw, r)
+			return
+		}
+		today := startOfDay(time.Now())
+		etag := fmt.Sprintf(`W/"%d-%s-%d"`, revision, today.Format("20060102"), serverStarted.Unix())
+		modified := updated
+		for _, t := range []time.Time{today, serverStarted} {
+			if t.After(modified) {
+				modified = t
+			}
+		}
+		modified = modified.UTC().Truncate(time.Second)
+
+		header := rw.Header()
+		header.Set("ETag", etag)
+		header.Set("Last-Modified", modified.Format(http.TimeFormat))
+		// private, pages may need authentication
+		header.Set("Cache-Control", "private, no-cache")
+		if notModified(r, etag, modified) {
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		h(cacheWriter{rw}, r)
+	}
+}
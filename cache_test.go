@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	const etag = `W/"42-20240101-1704096000"`
+	modified := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no validators", nil, false},
+		{"same etag", map[string]string{"If-None-Match": etag}, true},
+		{"strong etag", map[string]string{"If-None-Match": `"42-20240101-1704096000"`}, true},
+		{"one of etags", map[string]string{"If-None-Match": `W/"41-20240101-1704096000", ` + etag}, true},
+		{"any etag", map[string]string{"If-None-Match": "*"}, true},
+		{"older revision", map[string]string{"If-None-Match": `W/"41-20240101-1704096000"`}, false},
+		{"etag before date", map[string]string{"If-None-Match": `W/"41-20240101-1704096000"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, false},
+		{"same date", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"later date", map[string]string{"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, true},
+		{"earlier date", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, false},
+		{"invalid date", map[string]string{"If-Modified-Since": "yesterday"}, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		for key, value := range tt.headers {
+			r.Header.Set(key, value)
+		}
+		if got := notModified(r, etag, modified); got != tt.want {
+			t.Errorf("%s: notModified = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCacheWriter(t *testing.T) {
+	tests := []struct {
+		status      int
+		wantHeaders bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusCreated, true},
+		{http.StatusNotFound, false},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		rec.Header().Set("ETag", `W/"1"`)
+		rec.Header().Set("Last-Modified", "Mon, 01 Jan 2024 08:00:00 GMT")
+		rec.Header().Set("Cache-Control", "private, no-cache")
+		cacheWriter{rec}.WriteHeader(tt.status)
+		if got := rec.Header().Get("ETag") != ""; got != tt.wantHeaders {
+			t.Errorf("status %d: cache headers kept = %v, want %v", tt.status, got, tt.wantHeaders)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// a word captured by a browser extension or bookmarklet, with the sentence
+// around it and the url of the page
+type captureRequest struct {
+	Word    string `json:"word"`
+	Context string `json:"context"`
+	URL     string `json:"url"`
+}
+
+// allow the pages of any origin to call the capture endpoint, the token in
+// the Authorization header guards it rather than cookies
+func setCORSHeaders(rw http.ResponseWriter) {
+	rw.Header().Set("Access-Control-Allow-Origin", "*")
+	rw.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	rw.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	rw.Header().Set("Access-Control-Max-Age", "86400")
+}
+
+// add a word captured in a browser, for POST /api/capture. It's served
+// outside of the authentication of the server, since cors preflight requests
+// carry no credentials, and requires the bearer token even if the server
+// allows basic auth, so web pages can't add words to an open server. The
+// sentence is added as example sentence, and the url is the source of the
+// word and the sentence.
+func (w *WordDB) captureHandler(token string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(rw)
+		switch r.Method {
+		case http.MethodOptions:
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodPost:
+		default:
+			rw.Header().Set("Allow", "POST, OPTIONS")
+			writeJSONError(rw, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if token == "" {
+			writeJSONError(rw, http.StatusForbidden, "capture requires a token, run the server with -token")
+			return
+		}
+		if !(AuthConfig{Token: token}).authorized(r) {
+			writeJSONError(rw, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		var req captureRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(rw, http.StatusBadRequest, "invalid json body")
+			return
+		}
+		word := wordstore.NormalizeWord(req.Word)
+		if !wordstore.IsValidWord(word) {
+			writeJSONError(rw, http.StatusBadRequest, "invalid word")
+			return
+		}
+		deckID, ok := w.apiDeck(rw, r)
+		if !ok {
+			return
+		}
+		source := strings.TrimSpace(req.URL)
+
+		results, err := w.Store.Add(r.Context(), []string{word}, wordstore.AddOptions{DeckID: deckID, Source: source})
+		if err == nil {
+			err = results[0].Err
+		}
+		if err == nil && strings.TrimSpace(req.Context) != "" {
+			_, err = w.Store.AddSentence(r.Context(), word, req.Context, source)
+		}
+		if err != nil {
+			writeJSONError(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		status := http.StatusOK
+		if results[0].Created {
+			status = http.StatusCreated
+		}
+		w.writeAPIWord(rw, r, status, word)
+	})
+}
+
+// add a word with a plain link, for GET /add?w=word&token=..., and show a
+// tiny confirmation page, for bookmarklets, shortcuts and launchers which
+// can't send json. The token may be in the query or the Authorization header,
+// and it's required like for capture, so pages can't add words by linking to
+// the server. Optional parameters are context, source, tag, deck, and t=1 to
+// translate a new word with the provider of the config.
+func (w *WordDB) quickAddHandler(tmpl *template.Template, token string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		data := struct {
+			Word    string
+			ZhTrans string
+			Created bool
+			Error   string
+		}{}
+		reply := func(status int) {
+			rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+			rw.WriteHeader(status)
+			if err := tmpl.ExecuteTemplate(rw, "add.html", data); err != nil {
+				http.Error(rw, err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		auth := AuthConfig{Token: token}
+		switch {
+		case token == "":
+			data.Error = "quick add requires a token, run the server with -token"
+			reply(http.StatusForbidden)
+			return
+		case !auth.authorized(r) && !secureEqual(query.Get("token"), token):
+			data.Error = "unauthorized"
+			reply(http.StatusUnauthorized)
+			return
+		}
+		data.Word = wordstore.NormalizeWord(query.Get("w"))
+		if !wordstore.IsValidWord(data.Word) {
+			data.Error = "invalid word: " + query.Get("w")
+			reply(http.StatusBadRequest)
+			return
+		}
+		deckID := w.DeckID
+		if deck := query.Get("deck"); deck != "" {
+			var err error
+			if deckID, err = w.Store.DeckID(r.Context(), deck); err != nil {
+				data.Error = err.Error()
+				reply(http.StatusNotFound)
+				return
+			}
+		}
+
+		words, variants := w.lemmatize(r.Context(), []string{data.Word})
+		data.Word = words[0]
+		opts := wordstore.AddOptions{
+			Tags:     parseTags(query.Get("tag")),
+			DeckID:   deckID,
+			Context:  strings.TrimSpace(query.Get("context")),
+			Source:   strings.TrimSpace(query.Get("source")),
+			Variants: variants,
+		}
+		if query.Get("t") == "1" {
+			t, err := getTranslator(config.Translate.Provider)
+			if err != nil {
+				data.Error = err.Error()
+				reply(http.StatusInternalServerError)
+				return
+			}
+			opts.Translations = w.translateNew(r.Context(), t, words)
+		}
+		results, err := w.Store.Add(r.Context(), words, opts)
+		if err == nil {
+			err = results[0].Err
+		}
+		if err != nil {
+			data.Error = err.Error()
+			reply(http.StatusInternalServerError)
+			return
+		}
+		data.Created = results[0].Created
+		if word, err := w.Store.Get(r.Context(), data.Word); err == nil {
+			data.ZhTrans = word.ZhTrans.String
+		}
+		status := http.StatusOK
+		if data.Created {
+			status = http.StatusCreated
+		}
+		reply(status)
+	})
+}
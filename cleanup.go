@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// options of finding and merging duplicate words
+type CleanupOptions struct {
+	// only the duplicates of these reasons, all if empty
+	Reasons []string
+	// list the duplicates without merging them
+	DryRun bool
+	// merge all duplicates without asking
+	Yes bool
+}
+
+// a line about a word for the cleanup proposals: its translation and counts
+func (w *WordDB) cleanupLine(word string) string {
+	result, err := w.Store.Get(w.Ctx, word)
+	if err != nil {
+		return word
+	}
+	trans, _ := w.storedTranslation(result)
+	if trans == "" {
+		trans = "-"
+	}
+	return fmt.Sprintf("%-20s added %d, lookup %d  %s", word, result.AddedCount.Int64, result.LookupCount.Int64, trans)
+}
+
+// find near duplicate words, like plurals, words in different case and
+// typos, and merge the ones confirmed into the words they duplicate. The
+// merges are done at the end in one go, so a single undo reverts them.
+func (w *WordDB) Cleanup(opts CleanupOptions) error {
+	duplicates, err := w.Store.FindDuplicates(w.Ctx)
+	if err != nil {
+		return err
+	}
+	if len(opts.Reasons) > 0 {
+		duplicates = slices.DeleteFunc(duplicates, func(d wordstore.Duplicate) bool {
+			return !slices.Contains(opts.Reasons, d.Reason)
+		})
+	}
+	if len(duplicates) == 0 {
+		log.Printf("no duplicate words found")
+		return nil
+	}
+	if opts.DryRun {
+		for _, d := range duplicates {
+			fmt.Printf("%-5s %s -> %s\n", d.Reason, d.Word, d.Into)
+		}
+		return nil
+	}
+
+	var merges []wordstore.Duplicate
+	if opts.Yes {
+		merges = duplicates
+	} else {
+		in := bufio.NewScanner(os.Stdin)
+		fmt.Printf("%d possible duplicate(s), y merges the first word into the second, r the second into the first, q stops asking\n", len(duplicates))
+	ask:
+		for i, d := range duplicates {
+			fmt.Printf("\n[%d/%d] %s\n  %s\n  %s\n", i+1, len(duplicates), d.Reason, w.cleanupLine(d.Word), w.cleanupLine(d.Into))
+			answer, err := prompt(in, fmt.Sprintf("merge '%s' into '%s'? [y/N/r/q]", d.Word, d.Into), "")
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(answer) {
+			case "y", "yes":
+				merges = append(merges, d)
+			case "r":
+				d.Word, d.Into = d.Into, d.Word
+				merges = append(merges, d)
+			case "q":
+				break ask
+			}
+		}
+	}
+	if len(merges) == 0 {
+		log.Printf("nothing merged")
+		return nil
+	}
+
+	if err := w.Store.MergeWords(w.Ctx, merges); err != nil {
+		return err
+	}
+	for _, d := range merges {
+		log.Printf("merge '%s' into '%s'", d.Word, d.Into)
+	}
+	log.Printf("merged %d word(s), the merged words are in the trash, 'w2r undo' reverts", len(merges))
+	return nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// options of watching the clipboard for copied words
+type WatchOptions struct {
+	// how often the clipboard is read
+	Interval time.Duration
+	// ask before adding each word
+	Confirm bool
+	// show a desktop notification of each added word
+	Notify bool
+	// tags of the added words
+	Tags []string
+}
+
+// longest text copied which is taken for a word
+const maxClipboardWord = 50
+
+// the command printing the text of the clipboard: pbpaste on macos,
+// powershell on windows, and wl-paste on wayland, xclip or xsel on linux and
+// the bsds, the first one installed
+func clipboardCommand() ([]string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbpaste"}}
+	case "windows":
+		candidates = [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-Command", "Get-Clipboard -Raw"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-paste", "--no-newline"})
+		}
+		candidates = append(candidates,
+			[]string{"xclip", "-selection", "clipboard", "-out"},
+			[]string{"xsel", "--clipboard", "--output"})
+	}
+	var names []string
+	for _, args := range candidates {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return args, nil
+		}
+		names = append(names, args[0])
+	}
+	return nil, fmt.Errorf("no clipboard tool found, install %s", strings.Join(names, " or "))
+}
+
+// the text of the clipboard, read with the command args
+func readClipboard(ctx context.Context, args []string) (string, error) {
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// the word of text copied to the clipboard, if it's a single valid word. Text
+// like sentences, code or urls is left out, and so are words with capitals
+// inside like camelCase, which are names in code rather than words.
+func clipboardWord(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxClipboardWord || strings.ContainsFunc(text, unicode.IsSpace) {
+		return "", false
+	}
+	for i, r := range text {
+		if i > 0 && unicode.IsUpper(r) && strings.ContainsFunc(text[:i], unicode.IsLower) {
+			return "", false
+		}
+	}
+	word := wordstore.NormalizeWord(text)
+	if utf8.RuneCountInString(word) < 2 || !wordstore.IsValidWord(word) {
+		return "", false
+	}
+	return word, true
+}
+
+// watch the clipboard until interrupted, and add the words copied, so looking
+// up a word anywhere records it. The text on the clipboard when it starts,
+// words copied again before the clipboard changes, and the words of the known
+// and ignore lists are skipped.
+func (w *WordDB) WatchClipboard(opts WatchOptions) error {
+	args, err := clipboardCommand()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(w.Ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// an empty clipboard is an error for some tools
+	last, _ := readClipboard(ctx, args)
+	log.Printf("watching the clipboard with %s, press Ctrl-C to stop", args[0])
+	tick := time.NewTicker(opts.Interval)
+	defer tick.Stop()
+	answers := bufio.NewScanner(os.Stdin)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+		text, err := readClipboard(ctx, args)
+		if err != nil || text == last {
+			continue
+		}
+		last = text
+		word, ok := clipboardWord(text)
+		if !ok {
+			continue
+		}
+		listed, err := w.Store.ListedWords(ctx)
+		if err != nil {
+			return err
+		}
+		if list, ok := listed[word]; ok {
+			log.Printf("skip '%s' of the %s list", word, list)
+			continue
+		}
+		if opts.Confirm {
+			fmt.Printf("add '%s'? [Y/n] ", word)
+			if !answers.Scan() {
+				return answers.Err()
+			}
+			if answer := strings.ToLower(strings.TrimSpace(answers.Text())); answer != "" && answer != "y" && answer != "yes" {
+				continue
+			}
+		}
+
+		if err := w.AddWords([]string{word}, opts.Tags, "", "", ""); err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		if opts.Notify {
+			body := word
+			if result, err := w.Store.Get(ctx, word); err == nil && result.ZhTrans.String != "" {
+				body += ": " + result.ZhTrans.String
+			}
+			if err := notifyDesktop(ctx, "w2r: word added", body); err != nil {
+				log.Printf("notify: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,1202 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// returned by commands after printing usage on invalid arguments
+var errUsage = errors.New("invalid arguments")
+
+// a subcommand of w2r, each subcommand parses its own flags and positional args
+type command struct {
+	name string
+	help string
+	run  func(w *WordDB, args []string) error
+	// run with -remote, nil if the command needs the database
+	remote remoteCommand
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "init", help: "init database", run: cmdInit},
+		{name: "add", help: "add new words", run: cmdAdd, remote: remoteAdd},
+		{name: "del", help: "delete words, moving them to trash", run: cmdDel, remote: remoteDel},
+		{name: "trash", help: "list or empty deleted words", run: cmdTrash},
+		{name: "restore", help: "restore deleted words from trash, or the database from a backup file", run: cmdRestore},
+		{name: "backup", help: "back up the database", run: cmdBackup},
+		{name: "doctor", help: "check integrity, vacuum and show statistics of the database", run: cmdDoctor},
+		{name: "undo", help: "revert the last add, del, restore, set-trans, translate, import, merge, cleanup or sync", run: cmdUndo},
+		{name: "set-trans", help: "set translation of a word", run: cmdSetTrans, remote: remoteSetTrans},
+		{name: "translate", help: "fetch translations of words, or of all words without one", run: cmdTranslate},
+		{name: "ipa", help: "fetch ipa pronunciations of words, or of all words without one", run: cmdIPA},
+		{name: "list", help: "show summary", run: cmdList, remote: remoteList},
+		{name: "export", help: "export all words", run: cmdExport},
+		{name: "import", help: "import words from csv/tsv file", run: cmdImport},
+		{name: "merge", help: "merge words of another w2r database", run: cmdMerge},
+		{name: "archive", help: "archive words, leaving them out of lists, reviews and quizzes, or list archived words", run: cmdArchive},
+		{name: "cleanup", help: "find duplicate words like plurals, case variants and typos, and merge them", run: cmdCleanup},
+		{name: "sync", help: "sync words with a w2r server", run: cmdSync},
+		{name: "review", help: "review due words", run: cmdReview, remote: remoteReview},
+		{name: "quiz", help: "multiple-choice quiz of translations", run: cmdQuiz},
+		{name: "spell", help: "spelling test, type the word of a translation", run: cmdSpell},
+		{name: "enrich", help: "generate definitions, mnemonics and example sentences of words with an llm", run: cmdEnrich, remote: remoteEnrich},
+		{name: "search", help: "full-text search words, translations, notes and context", run: cmdSearch, remote: remoteSearch},
+		{name: "dict", help: "look up words in offline dictionary", run: cmdDict},
+		{name: "say", help: "play the pronunciation of words", run: cmdSay},
+		{name: "sentence", help: "list, add, fetch or delete example sentences of a word", run: cmdSentence, remote: remoteSentence},
+		{name: "related", help: "show synonyms and antonyms of a word", run: cmdRelated, remote: remoteRelated},
+		{name: "scan", help: "find the words of a text not in the database, and choose which to add", run: cmdScan},
+		{name: "coverage", help: "show how much of a text is covered by the words in the database and known words", run: cmdCoverage},
+		{name: "known", help: "list, add or remove words known already, which scan, coverage and quiz skip", run: cmdKnown},
+		{name: "ignore", help: "list, add or remove words to ignore like names, which scan, coverage and quiz skip", run: cmdIgnore},
+		{name: "quick", help: "add a selected word and notify its translation, for text services", run: cmdQuick},
+		{name: "watch-clipboard", help: "add the words copied to the clipboard until interrupted", run: cmdWatchClipboard},
+		{name: "tags", help: "show all tags", run: cmdTags, remote: remoteTags},
+		{name: "sources", help: "show where words are from, like books, and the number of their words", run: cmdSources, remote: remoteSources},
+		{name: "stats", help: "show totals, words added over time and the most looked up words", run: cmdStats, remote: remoteStats},
+		{name: "digest", help: "print or mail a digest of new and due words", run: cmdDigest},
+		{name: "wotd", help: "show the word of the day, a due or least practiced word", run: cmdWotd, remote: remoteWotd},
+		{name: "deck", help: "list, create, rename or merge decks", run: cmdDeck},
+		{name: "share", help: "share words by a read-only link to the web server, like the words of a deck or a tag for a teacher", run: cmdShare},
+		{name: "user", help: "list, add or delete the users of serve -users, set their passwords or create their api tokens", run: cmdUser},
+		{name: "mcp", help: "serve tools for llm assistants over the model context protocol on stdio", run: cmdMCP},
+		{name: "discord", help: "register the slash commands of the discord bot", run: cmdDiscord},
+		{name: "serve", help: "run webserver", run: cmdServe},
+		{name: "version", help: "show version", run: cmdVersion},
+	}
+}
+
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func progName() string {
+	return filepath.Base(os.Args[0])
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [-v] [--db path | --remote url] [--deck name] [--auto-backup] <command> [flags] [args]\n\nCommands:\n", progName())
+	// the help of commands lines up after the longest name
+	width := 0
+	for _, c := range commands {
+		width = max(width, len(c.name))
+	}
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-*s %s\n", width, c.name, c.help)
+	}
+	fmt.Fprintf(out, "\nRun '%s <command> -h' for help on a command.\n", progName())
+}
+
+// create the flag set of a subcommand, argsUsage describes the positional args
+func newFlagSet(name, argsUsage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n", progName(), name, argsUsage)
+		if c := findCommand(name); c != nil {
+			fmt.Fprintf(fs.Output(), "\n%s\n", c.help)
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse flags of a subcommand, the flag package has already printed the error
+// and usage, so it's returned as errUsage, or flag.ErrHelp for -h
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return errUsage
+	}
+	return err
+}
+
+// parse args with flags allowed after positional args, like
+// 'w2r add mitigate --context "..."', returns the positional args
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := parseFlags(fs, args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// everything after "--" is positional
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func cmdInit(w *WordDB, args []string) error {
+	fs := newFlagSet("init", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return w.Init()
+}
+
+func cmdAdd(w *WordDB, args []string) error {
+	fs := newFlagSet("add", "<word>[,<word>...] ... | - | -i [<word>...]")
+	fetch := fs.Bool("t", false, "fetch translation of new words from online dictionary")
+	provider := fs.String("provider", config.Translate.Provider, "translation provider, "+translatorNames())
+	fs.StringVar(&config.Translate.Lang, "lang", config.Translate.Lang, "language of translations like zh-CN or fr, default lang in [translate] of the config")
+	ipa := fs.String("ipa", config.Translate.Phonetic, "source of ipa fetched with -t, "+phoneticSourceNames()+", empty for none")
+	fs.StringVar(&config.Translate.OfflineDict, "offline-dict", config.Translate.OfflineDict, "ECDICT sqlite or StarDict .ifo file, for the offline provider")
+	tags := fs.String("tag", "", "comma separated tags of the words")
+	note := fs.String("note", "", "personal note of the words")
+	context := fs.String("context", "", "sentence where the words were seen")
+	source := fs.String("source", "", "where the words are from, like a book title and chapter")
+	fs.BoolVar(&config.Lemmatize, "lemma", config.Lemmatize, "add inflected forms like running or ran as their lemma run")
+	interactive := fs.Bool("i", false, "add words step by step, confirming the fetched translation and asking the note, tags and source")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// read from stdin with '-', or when piped without args
+	var words []string
+	if *interactive {
+		words = filterWords(strings.Join(args, ","))
+		*fetch = true
+	} else if (len(args) == 1 && args[0] == "-") || (len(args) == 0 && stdinIsPipe()) {
+		words, err = readWords(os.Stdin)
+		if err != nil {
+			return err
+		}
+	} else if len(args) > 0 {
+		words = filterWords(strings.Join(args, ","))
+	} else {
+		fs.Usage()
+		return errUsage
+	}
+
+	if *fetch {
+		t, err := getTranslator(*provider)
+		if err != nil {
+			return err
+		}
+		w.Translator = t
+		if *ipa != "" {
+			if w.Phonetics, err = getPhoneticSource(*ipa); err != nil {
+				return err
+			}
+		}
+	}
+
+	if *interactive {
+		return w.AddInteractive(words, WizardOptions{Tags: parseTags(*tags), Note: *note, Source: *source})
+	}
+	return w.AddWords(words, parseTags(*tags), *note, *context, *source)
+}
+
+func cmdDel(w *WordDB, args []string) error {
+	fs := newFlagSet("del", "<word> ...")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	if len(args) > 1 {
+		if err := w.autoBackup(); err != nil {
+			return err
+		}
+	}
+	// delete the rest even if some words fail
+	var errs []error
+	for _, arg := range args {
+		errs = append(errs, w.DelWord(wordstore.NormalizeWord(arg)))
+	}
+	return errors.Join(errs...)
+}
+
+func cmdTrash(w *WordDB, args []string) error {
+	fs := newFlagSet("trash", "[list|empty]")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	action := "list"
+	if len(args) == 1 {
+		action = args[0]
+	}
+	switch action {
+	case "list":
+		return w.ShowTrash()
+	case "empty":
+		return w.EmptyTrash()
+	}
+	fs.Usage()
+	return errUsage
+}
+
+func cmdRestore(w *WordDB, args []string) error {
+	fs := newFlagSet("restore", "<word> ... | <backup.sqlite> | -remote <url>")
+	remote := fs.String("remote", "", "restore the database from a backup at s3://bucket/path/backup.sqlite or webdavs://host/path/backup.sqlite")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *remote != "" {
+		if len(args) > 0 {
+			fs.Usage()
+			return errUsage
+		}
+		return w.RestoreRemote(*remote)
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	words := make([]string, 0, len(args))
+	for _, arg := range args {
+		words = append(words, wordstore.NormalizeWord(arg))
+	}
+	// words have no dots or slashes, so anything else is a backup file
+	if len(words) == 1 && !wordstore.IsValidWord(words[0]) {
+		return w.RestoreBackup(args[0])
+	}
+
+	// restore the rest even if some words fail
+	var errs []error
+	for _, word := range words {
+		errs = append(errs, w.RestoreWord(word))
+	}
+	return errors.Join(errs...)
+}
+
+func cmdBackup(w *WordDB, args []string) error {
+	fs := newFlagSet("backup", "[path] | -remote <url>")
+	remote := fs.String("remote", "", "upload to s3://bucket/path or webdavs://host/path instead")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 || *remote != "" && len(args) > 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *remote != "" {
+		return w.BackupRemote(*remote)
+	}
+
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	}
+	return w.Backup(path)
+}
+
+func cmdDoctor(w *WordDB, args []string) error {
+	fs := newFlagSet("doctor", "")
+	fix := fs.Bool("fix", false, "delete orphaned rows")
+	vacuum := fs.Bool("vacuum", true, "vacuum the database file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return w.Doctor(*fix, *vacuum)
+}
+
+func cmdUndo(w *WordDB, args []string) error {
+	fs := newFlagSet("undo", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return w.Undo()
+}
+
+func cmdSetTrans(w *WordDB, args []string) error {
+	fs := newFlagSet("set-trans", "<word> <translation>")
+	fs.StringVar(&config.Translate.Lang, "lang", config.Translate.Lang, "language of translations like zh-CN or fr, default lang in [translate] of the config")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	return w.SetTranslation(wordstore.NormalizeWord(args[0]), strings.Join(args[1:], " "))
+}
+
+func cmdTranslate(w *WordDB, args []string) error {
+	fs := newFlagSet("translate", "-missing | <word> ...")
+	opts := BackfillOptions{}
+	fs.BoolVar(&opts.Missing, "missing", false, "translate all words without translation")
+	provider := fs.String("provider", config.Translate.Provider, "translation provider, "+translatorNames())
+	fs.StringVar(&config.Translate.Lang, "lang", config.Translate.Lang, "language of translations like zh-CN or fr, default lang in [translate] of the config")
+	fs.StringVar(&config.Translate.OfflineDict, "offline-dict", config.Translate.OfflineDict, "ECDICT sqlite or StarDict .ifo file, for the offline provider")
+	fs.Float64Var(&opts.Rate, "rate", 2, "max requests per second to the provider")
+	fs.IntVar(&opts.Retries, "retries", 3, "retries of a failed request")
+	fs.IntVar(&opts.Limit, "limit", 0, "translate at most N words with -missing, 0 for all")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if opts.Missing == (len(args) > 0) || opts.Rate <= 0 || opts.Retries < 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	t, err := getTranslator(*provider)
+	if err != nil {
+		return err
+	}
+	words := make([]string, 0, len(args))
+	for _, arg := range args {
+		words = append(words, wordstore.NormalizeWord(arg))
+	}
+	return w.Backfill(t, words, opts)
+}
+
+func cmdIPA(w *WordDB, args []string) error {
+	fs := newFlagSet("ipa", "-missing | <word> ...")
+	opts := BackfillOptions{Phonetic: true}
+	fs.BoolVar(&opts.Missing, "missing", false, "fetch ipa of all words without one")
+	source := fs.String("source", cmp.Or(config.Translate.Phonetic, "dictionaryapi"), "source of ipa, "+phoneticSourceNames())
+	fs.StringVar(&config.Translate.OfflineDict, "offline-dict", config.Translate.OfflineDict, "ECDICT sqlite or StarDict .ifo file, for the offline source")
+	fs.Float64Var(&opts.Rate, "rate", 2, "max requests per second to the source")
+	fs.IntVar(&opts.Retries, "retries", 3, "retries of a failed request")
+	fs.IntVar(&opts.Limit, "limit", 0, "fetch ipa of at most N words with -missing, 0 for all")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if opts.Missing == (len(args) > 0) || opts.Rate <= 0 || opts.Retries < 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	p, err := getPhoneticSource(*source)
+	if err != nil {
+		return err
+	}
+	words := make([]string, 0, len(args))
+	for _, arg := range args {
+		words = append(words, wordstore.NormalizeWord(arg))
+	}
+	return w.Backfill(p, words, opts)
+}
+
+func cmdList(w *WordDB, args []string) error {
+	fs := newFlagSet("list", "")
+	opts := wordstore.ListOptions{DeckID: w.DeckID}
+	fs.StringVar(&opts.Sort, "sort", "", "sort by "+strings.Join(wordstore.SortKeys, "|"))
+	fs.IntVar(&opts.Limit, "limit", 0, "show at most N words")
+	fs.StringVar(&opts.Filter, "filter", "", "only show words or translations containing substring")
+	fs.StringVar(&opts.Tag, "tag", "", "only show words with tag")
+	fs.StringVar(&opts.Level, "level", "", "only show words of cefr level "+strings.Join(wordstore.Levels, "|"))
+	fs.StringVar(&opts.Source, "source", "", "only show words from sources starting with this, like a book title")
+	fs.BoolVar(&opts.Archived, "archived", false, "only show archived words, which are left out otherwise")
+	fs.StringVar(&opts.Mastery, "mastery", "", "only show words of mastery state "+strings.Join(wordstore.MasteryStates, "|"))
+	fs.StringVar(&config.Translate.Lang, "lang", config.Translate.Lang, "show translations into this language")
+	alfred := fs.Bool("alfred", false, "print alfred script filter json, for workflows")
+	raycast := fs.Bool("raycast", false, "print json of list items, for raycast extensions")
+	dict := fs.String("dict", config.Serve.Dict, "dictionary of the links of -alfred and -raycast, "+dictionaryNames()+", or a URL template like 'https://example.com/{word}'")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *alfred && *raycast {
+		fs.Usage()
+		return errUsage
+	}
+
+	if opts.Sort != "" && !slices.Contains(wordstore.SortKeys, opts.Sort) {
+		return fmt.Errorf("unknown sort key %q, available: %s", opts.Sort, strings.Join(wordstore.SortKeys, "|"))
+	}
+	opts.Level = strings.ToUpper(opts.Level)
+	if opts.Level != "" && !slices.Contains(wordstore.Levels, opts.Level) {
+		return fmt.Errorf("unknown level %q, available: %s", opts.Level, strings.Join(wordstore.Levels, "|"))
+	}
+	if opts.Mastery != "" && !slices.Contains(wordstore.MasteryStates, opts.Mastery) {
+		return fmt.Errorf("unknown mastery state %q, available: %s", opts.Mastery, strings.Join(wordstore.MasteryStates, "|"))
+	}
+	if !*alfred && !*raycast {
+		return w.ShowSummary(opts)
+	}
+	if *dict != "" {
+		if err := setDefaultDictionary(*dict); err != nil {
+			return err
+		}
+	}
+	format := alfredFormat
+	if *raycast {
+		format = raycastFormat
+	}
+	return w.ShowLauncherItems(opts, format)
+}
+
+func cmdExport(w *WordDB, args []string) error {
+	fs := newFlagSet("export", "")
+	format := fs.String("format", "csv", "output format, csv|apkg")
+	output := fs.String("o", "", "output file, default stdout")
+	deck := fs.String("deck", cmp.Or(w.DeckName, "w2r"), "anki deck name, for apkg format")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var export func(out io.Writer) error
+	switch *format {
+	case "csv":
+		export = w.ExportCSV
+	case "apkg":
+		export = func(out io.Writer) error { return w.ExportApkg(out, *deck) }
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	return export(out)
+}
+
+func cmdImport(w *WordDB, args []string) error {
+	fs := newFlagSet("import", "<file.csv|file.tsv|vocab.db>")
+	tsv := fs.Bool("tsv", false, "tab separated input, default by file extension")
+	kindle := fs.Bool("kindle", false, "import from kindle vocabulary builder vocab.db")
+	source := fs.String("source", "", "where the words are from, default the file name, or the book titles of kindle lookups")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	if err := w.autoBackup(); err != nil {
+		return err
+	}
+	name := args[0]
+	var stats ImportStats
+	if *kindle {
+		stats, err = w.ImportKindle(name, *source)
+	} else {
+		stats, err = importFile(w, name, *tsv, cmp.Or(*source, filepath.Base(name)))
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("import '%s': %d added, %d merged, %d skipped", name, stats.Added, stats.Merged, stats.Skipped)
+	return nil
+}
+
+func cmdArchive(w *WordDB, args []string) error {
+	fs := newFlagSet("archive", "[<word>[,<word>...] ...]")
+	unarchive := fs.Bool("u", false, "unarchive the words")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	words := filterWords(strings.Join(args, ","))
+	switch {
+	case len(args) == 0 && !*unarchive:
+		return w.ShowSummary(wordstore.ListOptions{DeckID: w.DeckID, Archived: true})
+	case len(words) == 0:
+		fs.Usage()
+		return errUsage
+	case *unarchive:
+		return w.Unarchive(words)
+	default:
+		return w.Archive(words)
+	}
+}
+
+func cmdCleanup(w *WordDB, args []string) error {
+	fs := newFlagSet("cleanup", "")
+	opts := CleanupOptions{}
+	fs.BoolVar(&opts.DryRun, "n", false, "only list the duplicates")
+	fs.BoolVar(&opts.Yes, "yes", false, "merge all duplicates without asking")
+	reasons := fs.String("only", "", "comma separated kinds of duplicates, "+strings.Join(wordstore.DuplicateReasons, "|"))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	for _, reason := range strings.Split(*reasons, ",") {
+		if reason = strings.TrimSpace(reason); reason == "" {
+			continue
+		}
+		if !slices.Contains(wordstore.DuplicateReasons, reason) {
+			fs.Usage()
+			return errUsage
+		}
+		opts.Reasons = append(opts.Reasons, reason)
+	}
+	return w.Cleanup(opts)
+}
+
+func cmdMerge(w *WordDB, args []string) error {
+	fs := newFlagSet("merge", "<other.sqlite>")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	return w.Merge(args[0])
+}
+
+func cmdSync(w *WordDB, args []string) error {
+	fs := newFlagSet("sync", "")
+	remote := fs.String("remote", config.Sync.Remote, "url of the w2r server, like https://host")
+	token := fs.String("token", config.Sync.Token, "bearer token of the server")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *remote == "" {
+		fs.Usage()
+		return errUsage
+	}
+	return w.Sync(*remote, *token)
+}
+
+func importFile(w *WordDB, name string, tsv bool, source string) (ImportStats, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return ImportStats{}, err
+	}
+	defer f.Close()
+
+	comma := ','
+	if tsv || strings.EqualFold(filepath.Ext(name), ".tsv") {
+		comma = '\t'
+	}
+	return w.ImportCSV(f, comma, source)
+}
+
+func cmdReview(w *WordDB, args []string) error {
+	fs := newFlagSet("review", "")
+	limit := fs.Int("n", config.Review.Limit, "max number of words to review")
+	plain := fs.Bool("plain", false, "line based prompts instead of full-screen ui, default when stdin is not a terminal")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkReviewAlgorithm(); err != nil {
+		return err
+	}
+
+	if *plain || stdinIsPipe() {
+		return w.Review(os.Stdin, *limit)
+	}
+	return w.ReviewTUI(*limit)
+}
+
+func cmdQuiz(w *WordDB, args []string) error {
+	fs := newFlagSet("quiz", "")
+	count := fs.Int("n", config.Review.QuizCount, "number of questions")
+	choices := fs.Int("choices", config.Review.QuizChoices, "number of choices of each question")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *choices < 2 {
+		return errors.New("choices must be at least 2")
+	}
+	return w.Quiz(os.Stdin, *count, *choices)
+}
+
+func cmdSpell(w *WordDB, args []string) error {
+	fs := newFlagSet("spell", "")
+	count := fs.Int("n", config.Review.SpellCount, "number of words")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	return w.Spell(os.Stdin, *count)
+}
+
+func cmdSearch(w *WordDB, args []string) error {
+	fs := newFlagSet("search", "<query>")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	return w.Search(strings.Join(args, " "))
+}
+
+func cmdDict(w *WordDB, args []string) error {
+	fs := newFlagSet("dict", "<word> ...")
+	fs.StringVar(&config.Translate.OfflineDict, "offline-dict", config.Translate.OfflineDict, "ECDICT sqlite or StarDict .ifo file, default $W2R_OFFLINE_DICT")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || config.Translate.OfflineDict == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	return w.ShowOfflineEntries(args)
+}
+
+func cmdSay(w *WordDB, args []string) error {
+	fs := newFlagSet("say", "<word> ...")
+	fs.StringVar(&config.Audio.Accent, "accent", config.Audio.Accent, "us or uk")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 || (config.Audio.Accent != "us" && config.Audio.Accent != "uk") {
+		fs.Usage()
+		return errUsage
+	}
+
+	words := make([]string, 0, len(args))
+	for _, arg := range args {
+		words = append(words, wordstore.NormalizeWord(arg))
+	}
+	return w.Say(words)
+}
+
+func cmdSentence(w *WordDB, args []string) error {
+	fs := newFlagSet("sentence", "[list] <word> | add <word> <sentence> | fetch <word> ... | del <id>")
+	source := fs.String("source", "", "where the sentence is from, like a book title, for add")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "list"
+	if len(args) > 0 && slices.Contains([]string{"list", "add", "fetch", "del"}, args[0]) {
+		action, args = args[0], args[1:]
+	}
+	switch {
+	case action == "list" && len(args) == 1:
+		return w.ShowSentences(wordstore.NormalizeWord(args[0]))
+	case action == "add" && len(args) >= 2:
+		return w.AddSentence(wordstore.NormalizeWord(args[0]), strings.Join(args[1:], " "), *source)
+	case action == "fetch" && len(args) > 0:
+		words := make([]string, 0, len(args))
+		for _, arg := range args {
+			words = append(words, wordstore.NormalizeWord(arg))
+		}
+		return w.FetchSentences(words)
+	case action == "del" && len(args) == 1:
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			break
+		}
+		return w.DeleteSentence(id)
+	}
+	fs.Usage()
+	return errUsage
+}
+
+func cmdRelated(w *WordDB, args []string) error {
+	fs := newFlagSet("related", "<word>")
+	refresh := fs.Bool("refresh", false, "fetch again instead of showing the stored words")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	return w.ShowRelated(wordstore.NormalizeWord(args[0]), *refresh)
+}
+
+func cmdTags(w *WordDB, args []string) error {
+	fs := newFlagSet("tags", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return w.ShowTags()
+}
+
+func cmdSources(w *WordDB, args []string) error {
+	fs := newFlagSet("sources", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return w.ShowSources()
+}
+
+func cmdStats(w *WordDB, args []string) error {
+	fs := newFlagSet("stats", "")
+	days := fs.Int("days", 7, "number of days of words added per day")
+	weeks := fs.Int("weeks", 4, "number of weeks of words added per week")
+	months := fs.Int("months", 6, "number of months of words added per month")
+	top := fs.Int("top", 10, "number of most looked up words")
+	heatmap := fs.Bool("heatmap", false, "show a calendar of words added per day in the last year instead")
+	reviews := fs.Bool("reviews", false, "show reviews per day of the last -days and the most forgotten of -top words instead")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *heatmap {
+		return w.ShowHeatmap()
+	}
+	if *days < 0 || *weeks < 0 || *months < 0 || *top < 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *reviews {
+		return w.ShowReviewStats(*days, *top)
+	}
+	return w.ShowStats(*days, *weeks, *months, *top)
+}
+
+func cmdDigest(w *WordDB, args []string) error {
+	fs := newFlagSet("digest", "")
+	period := fs.String("period", config.Digest.Period, "words added in the last day or week, daily|weekly")
+	send := fs.Bool("send", false, "mail the digest with the smtp server of [digest] in the config file instead of printing it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	days, err := digestDays(*period)
+	if err != nil {
+		fs.Usage()
+		return errUsage
+	}
+	return w.Digest(days, *send)
+}
+
+func cmdEnrich(w *WordDB, args []string) error {
+	fs := newFlagSet("enrich", "<word> ...")
+	force := fs.Bool("force", false, "generate again instead of showing the cached result")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	return w.Enrich(args, *force)
+}
+
+func cmdWotd(w *WordDB, args []string) error {
+	fs := newFlagSet("wotd", "")
+	short := fs.Bool("short", false, "print only the word, for shell prompts")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return w.ShowWordOfTheDay(*short)
+}
+
+func cmdDeck(w *WordDB, args []string) error {
+	fs := newFlagSet("deck", "[list] | create <name> | rename <name> <new-name> | merge <name> <into>")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+	switch {
+	case action == "list" && len(args) == 0:
+		return w.ShowDecks()
+	case action == "create" && len(args) == 1:
+		return w.CreateDeck(args[0])
+	case action == "rename" && len(args) == 2:
+		return w.RenameDeck(args[0], args[1])
+	case action == "merge" && len(args) == 2:
+		return w.MergeDeck(args[0], args[1])
+	}
+	fs.Usage()
+	return errUsage
+}
+
+func cmdShare(w *WordDB, args []string) error {
+	fs := newFlagSet("share", "[<word>[,<word>...] ...] | -list | -del <token|link>")
+	var opts wordstore.ShareOptions
+	fs.StringVar(&opts.Title, "title", "", "title of the shared list, default the deck and tag")
+	fs.StringVar(&opts.Tag, "tag", "", "share the words with this tag")
+	expires := fs.Duration("expires", 0, "the link stops working after this long, like 720h, 0 for never")
+	base := fs.String("url", serverURL(), "url of the web server in the link, default url in [serve] of the config file")
+	list := fs.Bool("list", false, "list the shares and their links")
+	del := fs.String("del", "", "delete the share of a token or link")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *list && *del == "" && len(args) == 0:
+		return w.ShowShares(*base)
+	case *del != "" && !*list && len(args) == 0:
+		return w.DelShare(*del)
+	case *list || *del != "" || *expires < 0:
+		fs.Usage()
+		return errUsage
+	}
+	opts.Words = filterWords(strings.Join(args, ","))
+	if len(args) > 0 && len(opts.Words) == 0 {
+		return errors.New("no valid word to share")
+	}
+	if *expires > 0 {
+		opts.Expires = time.Now().Add(*expires)
+	}
+	return w.CreateShare(opts, *base)
+}
+
+func cmdUser(w *WordDB, args []string) error {
+	fs := newFlagSet("user", "[list] | add [-db path] <name> | passwd <name> | token <name> | revoke <name> | del <name>")
+	dbPath := fs.String("db", "", "database of the words of the added user, default users/<name>.sqlite next to the database")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+	switch {
+	case action == "list" && len(args) == 0:
+		return w.ShowUsers()
+	case action == "add" && len(args) == 1:
+		return w.AddUser(args[0], *dbPath)
+	case action == "passwd" && len(args) == 1:
+		return w.SetUserPassword(args[0])
+	case action == "token" && len(args) == 1:
+		return w.NewUserToken(args[0])
+	case action == "revoke" && len(args) == 1:
+		return w.RevokeUserTokens(args[0])
+	case action == "del" && len(args) == 1:
+		return w.DelUser(args[0])
+	}
+	fs.Usage()
+	return errUsage
+}
+
+func cmdMCP(w *WordDB, args []string) error {
+	fs := newFlagSet("mcp", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return w.ServeMCP(os.Stdin, os.Stdout)
+}
+
+func cmdDiscord(w *WordDB, args []string) error {
+	fs := newFlagSet("discord", "register")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 || args[0] != "register" {
+		fs.Usage()
+		return errUsage
+	}
+	if err := registerDiscordCommands(w.Ctx, config.Discord); err != nil {
+		return err
+	}
+	log.Printf("registered %d slash commands, they may take a while to show up in discord", len(discordCommands))
+	return nil
+}
+
+func cmdServe(w *WordDB, args []string) error {
+	fs := newFlagSet("serve", "")
+	port := fs.Int("p", config.Serve.Port, "webserver port on 127.0.0.1")
+	addr := fs.String("listen", config.Serve.Listen, "listen address like 0.0.0.0:8080 or a unix socket path, overrides -p")
+	dict := fs.String("dict", config.Serve.Dict, "default dictionary to lookup words, "+dictionaryNames()+", or a URL template like 'https://example.com/{word}'")
+	auth := AuthConfig{BasicAuth: config.Serve.BasicAuth, Token: config.Serve.Token}
+	fs.StringVar(&auth.BasicAuth, "basic-auth", auth.BasicAuth, "require http basic auth with user:password, default $W2R_BASIC_AUTH")
+	fs.StringVar(&auth.Token, "token", auth.Token, "require bearer token, default $W2R_TOKEN")
+	var backups BackupSchedule
+	fs.DurationVar(&backups.Interval, "backup-interval", config.Serve.BackupInterval, "back up the database periodically while serving, like 24h, 0 to disable")
+	fs.IntVar(&backups.Keep, "backup-keep", config.Serve.BackupKeep, "number of scheduled backups to keep")
+	digest := fs.String("digest-at", config.Digest.At, "comma separated local times like 08:00 to mail the digest of [digest] in the config file, weekly digests on mondays")
+	notify := fs.String("notify", strings.Join(config.Notify.Times, ","), "comma separated local times like 09:00,21:00 to show a desktop notification if words are due or the daily goal isn't reached")
+	opts := ServeOptions{TrustProxy: config.Serve.TrustProxy}
+	fs.BoolVar(&opts.AccessLog, "access-log", config.Serve.AccessLog, "log method, path, status and duration of each request")
+	fs.Float64Var(&opts.RateLimit, "rate-limit", config.Serve.RateLimit, "requests per second of each client to the api and quick add, 0 for no limit")
+	fs.IntVar(&opts.RateBurst, "rate-burst", config.Serve.RateBurst, "most requests of a client at once before -rate-limit applies")
+	fs.StringVar(&opts.BasePath, "base-path", config.Serve.BasePath, "serve under a path like /w2r behind a reverse proxy, which passes the path on as is")
+	fs.BoolVar(&opts.Users, "users", config.Serve.Users, "serve each user of the user command the words of their own database, logging in with their password or tokens, instead of -basic-auth and -token")
+	fs.StringVar(&opts.TLS.CertFile, "tls-cert", config.Serve.TLSCert, "serve https with this certificate file, with -tls-key")
+	fs.StringVar(&opts.TLS.KeyFile, "tls-key", config.Serve.TLSKey, "private key file of -tls-cert")
+	autocertDomains := fs.String("autocert", strings.Join(config.Serve.Autocert, ","), "comma separated domains to serve https with certificates of Let's Encrypt, the server must be reachable at port 443 of them")
+	fs.StringVar(&opts.TLS.AutocertDir, "autocert-dir", config.Serve.AutocertDir, "directory to keep the certificates of -autocert, default next to the database")
+	fs.StringVar(&opts.TLS.AutocertEmail, "autocert-email", config.Serve.AutocertEmail, "email of the Let's Encrypt account for expiry notices")
+	fs.StringVar(&opts.TLS.AutocertHTTP, "autocert-http", config.Serve.AutocertHTTP, "address like :80 to answer ACME challenges over http and redirect http to https")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *dict != "" {
+		if err := setDefaultDictionary(*dict); err != nil {
+			return err
+		}
+	}
+	// port must be between 0~65535
+	if *port <= 0 || *port > 65535 {
+		return errors.New("port must be between 0~65535")
+	}
+	if auth.BasicAuth != "" && !strings.Contains(auth.BasicAuth, ":") {
+		return errors.New("basic auth must be user:password")
+	}
+	if opts.Users && auth.Enabled() {
+		return errors.New("-users logs in users with their own passwords and tokens, it can't be used with -basic-auth or -token")
+	}
+	opts.BasePath = cleanBasePath(opts.BasePath)
+	for _, domain := range strings.Split(*autocertDomains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			opts.TLS.Autocert = append(opts.TLS.Autocert, domain)
+		}
+	}
+	if err := opts.TLS.check(); err != nil {
+		return err
+	}
+	if opts.RateLimit < 0 || opts.RateLimit > 0 && opts.RateBurst < 1 {
+		return errors.New("rate limit must not be negative, and the burst must be at least 1")
+	}
+	if backups.Interval < 0 || backups.Keep < 1 {
+		return errors.New("backup interval must not be negative, and at least 1 backup must be kept")
+	}
+	notifyAt, err := parseNotifyTimes(strings.Split(*notify, ","))
+	if err != nil {
+		return err
+	}
+	reminders := ReminderSchedule{Notify: notifyAt}
+	if reminders.Digest, err = parseNotifyTimes(strings.Split(*digest, ",")); err != nil {
+		return err
+	}
+	if reminders.DigestDays, err = digestDays(config.Digest.Period); err != nil {
+		return err
+	}
+	if len(reminders.Digest) > 0 && config.Digest.SMTPHost == "" {
+		return errors.New("-digest-at needs smtp_host in [digest] of the config file")
+	}
+	// reminders, digests and bots work on a single database, they'd show the
+	// words of the database of the users rather than anyone's own
+	if opts.Users {
+		switch {
+		case len(reminders.Notify) > 0:
+			return errors.New("-notify reminds of the words of one database, it can't be used with -users")
+		case len(reminders.Digest) > 0:
+			return errors.New("-digest-at mails the digest of one database, it can't be used with -users")
+		case config.Telegram.Token != "":
+			return errors.New("the telegram bot adds words to one database, remove [telegram] from the config file to use -users")
+		case config.Discord.PublicKey != "":
+			return errors.New("the discord bot adds words to one database, remove [discord] from the config file to use -users")
+		}
+	}
+	if err := checkReviewAlgorithm(); err != nil {
+		return err
+	}
+	if *addr == "" {
+		*addr = fmt.Sprintf("127.0.0.1:%d", *port)
+	}
+	return w.RunWebServer(*addr, auth, backups, reminders, opts)
+}
+
+func cmdVersion(w *WordDB, args []string) error {
+	fmt.Printf("word version: %s\n", Version)
+	return nil
+}
+
+func cmdScan(w *WordDB, args []string) error {
+	fs := newFlagSet("scan", "<file.txt|.html|.epub|.srt|.vtt> | -list - | -url <url>")
+	opts := ScanOptions{}
+	pageURL := fs.String("url", "", "scan the readable text of a web page instead of a file")
+	fs.BoolVar(&opts.List, "list", false, "print the unknown words, the most frequent first, instead of asking which to add")
+	fs.IntVar(&opts.Limit, "limit", 0, "at most N words, the most frequent ones, 0 for all")
+	tags := fs.String("tag", "", "comma separated tags of the added words")
+	fetch := fs.Bool("t", false, "fetch translation of the added words from online dictionary")
+	provider := fs.String("provider", config.Translate.Provider, "translation provider, "+translatorNames())
+	fs.StringVar(&config.Scan.KnownWords, "known", config.Scan.KnownWords, "file of known words to skip, one per line")
+	fs.StringVar(&config.Scan.CommonLevel, "common", config.Scan.CommonLevel, "skip words of this cefr level and the easier ones, "+strings.Join(wordstore.Levels, "|")+", empty for none")
+	fs.BoolVar(&config.Lemmatize, "lemma", config.Lemmatize, "count inflected forms like running or ran as their lemma run")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 && *pageURL == "" || len(args) > 0 && *pageURL != "" {
+		fs.Usage()
+		return errUsage
+	}
+	if len(args) == 1 && args[0] == "-" && !opts.List {
+		return errors.New("answers are read from stdin, so the text can only be read from stdin with -list")
+	}
+
+	if *fetch {
+		if w.Translator, err = getTranslator(*provider); err != nil {
+			return err
+		}
+		if config.Translate.Phonetic != "" {
+			if w.Phonetics, err = getPhoneticSource(config.Translate.Phonetic); err != nil {
+				return err
+			}
+		}
+	}
+	opts.Tags = parseTags(*tags)
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	}
+	parts, err := readScanInput(w.Ctx, path, *pageURL)
+	if err != nil {
+		return err
+	}
+	return w.Scan(parts, opts)
+}
+
+func cmdCoverage(w *WordDB, args []string) error {
+	fs := newFlagSet("coverage", "<file.txt|.html|.epub|.srt|.vtt> | - | -url <url>")
+	pageURL := fs.String("url", "", "the readable text of a web page instead of a file")
+	top := fs.Int("top", 20, "show the N most frequent unknown words")
+	fs.StringVar(&config.Scan.KnownWords, "known", config.Scan.KnownWords, "file of known words, one per line")
+	fs.StringVar(&config.Scan.CommonLevel, "common", config.Scan.CommonLevel, "words of this cefr level and the easier ones are known, "+strings.Join(wordstore.Levels, "|")+", empty for none")
+	fs.BoolVar(&config.Lemmatize, "lemma", config.Lemmatize, "count inflected forms like running or ran as their lemma run")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 && *pageURL == "" || len(args) > 0 && *pageURL != "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	}
+	parts, err := readScanInput(w.Ctx, path, *pageURL)
+	if err != nil {
+		return err
+	}
+	return w.Coverage(parts, *top)
+}
+
+func cmdKnown(w *WordDB, args []string) error {
+	return runWordList(w, wordstore.KnownList, args)
+}
+
+func cmdIgnore(w *WordDB, args []string) error {
+	return runWordList(w, wordstore.IgnoreList, args)
+}
+
+func cmdWatchClipboard(w *WordDB, args []string) error {
+	fs := newFlagSet("watch-clipboard", "")
+	opts := WatchOptions{}
+	fs.DurationVar(&opts.Interval, "interval", 500*time.Millisecond, "how often to read the clipboard")
+	fs.BoolVar(&opts.Confirm, "confirm", false, "ask before adding each word")
+	fs.BoolVar(&opts.Notify, "notify", false, "show a desktop notification of each added word")
+	tags := fs.String("tag", "", "comma separated tags of the words")
+	fetch := fs.Bool("t", false, "fetch translation of new words from online dictionary")
+	provider := fs.String("provider", config.Translate.Provider, "translation provider, "+translatorNames())
+	fs.BoolVar(&config.Lemmatize, "lemma", config.Lemmatize, "add inflected forms like running or ran as their lemma run")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if opts.Interval <= 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	opts.Tags = parseTags(*tags)
+	if *fetch {
+		t, err := getTranslator(*provider)
+		if err != nil {
+			return err
+		}
+		w.Translator = t
+	}
+	return w.WatchClipboard(opts)
+}
+
+func cmdQuick(w *WordDB, args []string) error {
+	fs := newFlagSet("quick", "[<text> | -]")
+	provider := fs.String("provider", config.Translate.Provider, "translation provider, "+translatorNames())
+	fs.BoolVar(&config.Lemmatize, "lemma", config.Lemmatize, "add inflected forms like running or ran as their lemma run")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// text services pass the selection as arguments or on stdin
+	text := strings.Join(args, " ")
+	if len(args) == 0 || text == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	t, err := getTranslator(*provider)
+	if err != nil {
+		return err
+	}
+	w.Translator = t
+	return w.QuickAdd(text)
+}
+
+// list, add or remove the words of a word list
+func runWordList(w *WordDB, list string, args []string) error {
+	fs := newFlagSet(list, "[list] | add <word>[,<word>...] ... | add - | del <word>[,<word>...] ...")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+	switch {
+	case action == "list" && len(args) == 0:
+		return w.ShowListedWords(list)
+	case action == "add" && len(args) == 1 && args[0] == "-":
+		words, err := readWords(os.Stdin)
+		if err != nil {
+			return err
+		}
+		return w.AddListedWords(list, words)
+	case action == "add" && len(args) > 0:
+		return w.AddListedWords(list, filterWords(strings.Join(args, ",")))
+	case action == "del" && len(args) > 0:
+		return w.RemoveListedWords(list, filterWords(strings.Join(args, ",")))
+	}
+	fs.Usage()
+	return errUsage
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// show all decks and the number of words
+func (w *WordDB) ShowDecks() error {
+	decks, err := w.Store.Decks(w.Ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%15s %10s\n", "Deck", "Words")
+	for _, deck := range decks {
+		fmt.Printf("%15s %10d\n", deck.Name, deck.WordCount)
+	}
+	return nil
+}
+
+func (w *WordDB) CreateDeck(name string) error {
+	if _, err := w.Store.CreateDeck(w.Ctx, name); err != nil {
+		return err
+	}
+	log.Printf("create deck '%s'", name)
+	return nil
+}
+
+func (w *WordDB) RenameDeck(name, newName string) error {
+	if err := w.Store.RenameDeck(w.Ctx, name, newName); err != nil {
+		return err
+	}
+	log.Printf("rename deck '%s' to '%s'", name, newName)
+	return nil
+}
+
+// move the words of deck name into deck into, and delete deck name
+func (w *WordDB) MergeDeck(name, into string) error {
+	n, err := w.Store.MergeDeck(w.Ctx, name, into)
+	if err != nil {
+		return err
+	}
+	log.Printf("merge deck '%s' into '%s', %d word(s) moved", name, into, n)
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// days of a digest period
+func digestDays(period string) (int, error) {
+	switch period {
+	case "daily":
+		return 1, nil
+	case "weekly":
+		return 7, nil
+	}
+	return 0, fmt.Errorf("invalid digest period %q, use daily or weekly", period)
+}
+
+// subject and text of a digest of the words added in the last days and the
+// words due for review
+func (w *WordDB) digest(ctx context.Context, days int) (string, string, error) {
+	now := time.Now()
+	since := now.AddDate(0, 0, -days)
+	queries := w.Store.Queries()
+	words, err := queries.Listword(ctx, w.DeckID)
+	if err != nil {
+		return "", "", err
+	}
+	var added []worddb.Word
+	for _, word := range words {
+		if word.CreatedAt.Valid && word.CreatedAt.Time.After(since) {
+			added = append(added, word)
+		}
+	}
+	slices.SortFunc(added, func(a, b worddb.Word) int { return a.CreatedAt.Time.Compare(b.CreatedAt.Time) })
+	limit := config.Digest.Limit
+	due, err := queries.ListDueWords(ctx, worddb.ListDueWordsParams{DueAt: now.UTC(), DeckID: w.DeckID, Limit: -1})
+	if err != nil {
+		return "", "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "w2r digest of %s ~ %s\n", since.Format(time.DateOnly), now.Format(time.DateOnly))
+	fmt.Fprintf(&b, "\n%d new word(s):\n", len(added))
+	for i, word := range added {
+		if i == limit {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(added)-limit)
+			break
+		}
+		digestLine(&b, word.Word, word.ZhTrans.String)
+	}
+	fmt.Fprintf(&b, "\n%d word(s) due for review:\n", len(due))
+	for i, word := range due {
+		if i == limit {
+			fmt.Fprintf(&b, "  ... and %d more\n", len(due)-limit)
+			break
+		}
+		digestLine(&b, word.Word, word.ZhTrans.String)
+	}
+	if config.Goal.Words > 0 || config.Goal.Reviews > 0 {
+		streak, err := w.streak(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		fmt.Fprintf(&b, "\nstreak: %d day(s), longest %d\n", streak.Current, streak.Longest)
+	}
+
+	subject := fmt.Sprintf("w2r: %d new word(s), %d due for review", len(added), len(due))
+	return subject, b.String(), nil
+}
+
+// a word and its translation, without trailing spaces if it's not translated
+func digestLine(b *strings.Builder, word, zhTrans string) {
+	fmt.Fprintln(b, strings.TrimRight(fmt.Sprintf("  %-20s %s", word, zhTrans), " "))
+}
+
+// print the digest of the last days, or mail it if send
+func (w *WordDB) Digest(days int, send bool) error {
+	subject, body, err := w.digest(w.Ctx, days)
+	if err != nil {
+		return err
+	}
+	if !send {
+		fmt.Printf("%s\n\n%s", subject, body)
+		return nil
+	}
+	if err := sendMail(w.Ctx, config.Digest, subject, body); err != nil {
+		return err
+	}
+	log.Printf("sent digest to %s", strings.Join(config.Digest.To, ", "))
+	return nil
+}
+
+// mail a text message with the smtp server of c, port 465 is tls from the
+// start, other ports use starttls if the server supports it
+func sendMail(ctx context.Context, c DigestConfig, subject, body string) error {
+	if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+		return errors.New("smtp_host, from and to must be set in [digest] of the config file")
+	}
+	addr := net.JoinHostPort(c.SMTPHost, strconv.Itoa(c.SMTPPort))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if c.SMTPPort == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: c.SMTPHost}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && c.SMTPPort != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: c.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	// plain auth refuses to send the password without tls, except to localhost
+	if c.SMTPUser != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.SMTPUser, c.SMTPPassword, c.SMTPHost)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, to := range c.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(mailMessage(c.From, c.To, subject, body)); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// utf-8 text message, the subject and body may be chinese
+func mailMessage(from string, to []string, subject, body string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(body))
+	qp.Close()
+	return b.Bytes()
+}
+
+// mail the digest of days at the times of day until ctx is done, weekly
+// digests are sent on mondays
+func (w *WordDB) runDigests(ctx context.Context, offsets []time.Duration, days int) {
+	for {
+		next := nextNotifyTime(time.Now(), offsets)
+		for days == 7 && next.Weekday() != time.Monday {
+			next = nextNotifyTime(next, offsets)
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		// keep the schedule after failures, the next one may succeed
+		subject, body, err := w.digest(ctx, days)
+		if err == nil {
+			err = sendMail(ctx, config.Digest, subject, body)
+		}
+		if err != nil {
+			log.Printf("digest: %v", err)
+		} else {
+			log.Printf("sent digest to %s", strings.Join(config.Digest.To, ", "))
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// discord rest api, the bot only uses it to register the slash commands
+const discordAPI = "https://discord.com/api/v10"
+
+// the slash commands of the bot, option type 3 is a string and 4 an integer,
+// see https://discord.com/developers/docs/interactions/application-commands
+var discordCommands = []map[string]any{
+	{"name": "add", "description": "add words, separated by spaces or commas", "options": []map[string]any{
+		{"type": 3, "name": "words", "description": "words to add", "required": true},
+	}},
+	{"name": "list", "description": "the most recently added words", "options": []map[string]any{
+		{"type": 4, "name": "n", "description": "number of words, 10 by default", "min_value": 1, "max_value": 50},
+	}},
+	{"name": "quiz", "description": "a flashcard of a word due for review"},
+	{"name": "help", "description": "commands of w2r"},
+}
+
+// markdown of discord messages
+type discordMarkup struct{}
+
+var discordEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`, "#", `\#`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`)
+
+func (discordMarkup) escape(s string) string  { return discordEscaper.Replace(s) }
+func (discordMarkup) bold(s string) string    { return "**" + discordEscaper.Replace(s) + "**" }
+func (discordMarkup) spoiler(s string) string { return "||" + discordEscaper.Replace(s) + "||" }
+
+// discord bot of the web server, discord posts slash commands to
+// /discord/interactions, which is set as the interactions endpoint url of
+// the application, so it needs no connection to the discord gateway
+type discordBot struct {
+	w         *WordDB
+	publicKey ed25519.PublicKey
+	// the shared deck, unless perUser
+	deckID  int64
+	perUser bool
+	// translates new words, may be nil
+	translator Translator
+}
+
+func newDiscordBot(w *WordDB, c DiscordConfig) (*discordBot, error) {
+	key, err := hex.DecodeString(c.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public_key in [discord], copy it from the general information of the discord application")
+	}
+	bot := &discordBot{w: w, publicKey: key, deckID: w.DeckID, perUser: c.PerUser}
+	if c.Deck != "" && !c.PerUser {
+		if bot.deckID, err = w.Store.DeckID(w.Ctx, c.Deck); err != nil {
+			return nil, err
+		}
+	}
+	if c.Translate {
+		if bot.translator, err = getTranslator(config.Translate.Provider); err != nil {
+			return nil, err
+		}
+	}
+	return bot, nil
+}
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type discordInteraction struct {
+	Type int `json:"type"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string `json:"name"`
+			Value any    `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	// member in servers, user in direct messages
+	Member *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+}
+
+func (i *discordInteraction) user() discordUser {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	if i.User != nil {
+		return *i.User
+	}
+	return discordUser{}
+}
+
+func (i *discordInteraction) option(name string) string {
+	for _, option := range i.Data.Options {
+		if option.Name == name {
+			return fmt.Sprint(option.Value)
+		}
+	}
+	return ""
+}
+
+// deck of the words of user, each user has a deck like discord-name if
+// perUser, created on first use
+func (b *discordBot) deck(ctx context.Context, user discordUser) (int64, error) {
+	if !b.perUser {
+		return b.deckID, nil
+	}
+	name := "discord-" + user.Username
+	id, err := b.w.Store.DeckID(ctx, name)
+	if errors.Is(err, wordstore.ErrDeckNotFound) {
+		return b.w.Store.CreateDeck(ctx, name)
+	}
+	return id, err
+}
+
+// reply of a slash command
+func (b *discordBot) reply(ctx context.Context, i *discordInteraction) (string, error) {
+	deckID, err := b.deck(ctx, i.user())
+	if err != nil {
+		return "", err
+	}
+	m := discordMarkup{}
+	switch i.Data.Name {
+	case "add":
+		// discord expects a response in 3 seconds, so slow dictionaries are
+		// cut short and the words are added without translation
+		var t Translator
+		if b.translator != nil {
+			t = deadlineTranslator{t: b.translator, deadline: time.Now().Add(2 * time.Second)}
+		}
+		return b.w.botAdd(ctx, t, deckID, i.option("words"), m)
+	case "list":
+		n, err := strconv.Atoi(i.option("n"))
+		if err != nil {
+			n = 10
+		}
+		return b.w.botList(ctx, deckID, min(max(n, 1), 50), m)
+	case "quiz":
+		return b.w.botQuiz(ctx, deckID, m)
+	}
+	return m.escape(botHelp("/", false)), nil
+}
+
+// ServeHTTP answers interactions of discord, which are signed with the
+// public key of the application
+func (b *discordBot) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sig, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || !ed25519.Verify(b.publicKey, append([]byte(r.Header.Get("X-Signature-Timestamp")), body...), sig) {
+		http.Error(rw, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+	var i discordInteraction
+	if err := json.Unmarshal(body, &i); err != nil {
+		http.Error(rw, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	// 1 is the ping of discord checking the endpoint, 2 a slash command
+	switch i.Type {
+	case 1:
+		writeJSON(rw, http.StatusOK, map[string]int{"type": 1})
+		return
+	case 2:
+	default:
+		http.Error(rw, "unsupported interaction", http.StatusBadRequest)
+		return
+	}
+	reply, err := b.reply(r.Context(), &i)
+	if err != nil {
+		log.Printf("discord: %v", err)
+		reply = "error: " + err.Error()
+	}
+	if reply == "" {
+		reply = "nothing to add"
+	}
+	// type 4 replies with a message, without pinging anyone mentioned
+	writeJSON(rw, http.StatusOK, map[string]any{
+		"type": 4,
+		"data": map[string]any{"content": reply, "allowed_mentions": map[string]any{"parse": []string{}}},
+	})
+}
+
+// translator which gives up at a deadline
+type deadlineTranslator struct {
+	t        Translator
+	deadline time.Time
+}
+
+func (d deadlineTranslator) Translate(ctx context.Context, word string) (string, error) {
+	ctx, cancel := context.WithDeadline(ctx, d.deadline)
+	defer cancel()
+	return d.t.Translate(ctx, word)
+}
+
+// register the slash commands of the application globally, they may take a
+// while to show up in discord
+func registerDiscordCommands(ctx context.Context, c DiscordConfig) error {
+	if c.ApplicationID == "" || c.Token == "" {
+		return errors.New("application_id and token must be set in [discord] of the config file")
+	}
+	body, err := json.Marshal(discordCommands)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, discordAPI+"/applications/"+c.ApplicationID+"/commands", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := doRemote(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// check the database, report orphaned rows and delete them if fix is set,
+// vacuum the file, and print statistics
+func (w *WordDB) Doctor(fix, vacuum bool) error {
+	problems, err := w.Store.CheckIntegrity(w.Ctx)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		fmt.Println("integrity check: failed")
+		for _, p := range problems {
+			fmt.Printf("  %s\n", p)
+		}
+		// vacuum may lose more data of a corrupted database, restore a backup
+		return errors.New("database is corrupted, restore a backup with 'w2r restore <backup.sqlite>'")
+	}
+	fmt.Println("integrity check: ok")
+
+	orphans, err := w.Store.Orphans(w.Ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(orphans) == 0:
+		fmt.Println("orphaned rows: none")
+	case fix:
+		n, err := w.Store.DeleteOrphans(w.Ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("orphaned rows: %d deleted\n", n)
+	default:
+		tables := make([]string, 0, len(orphans))
+		for table, n := range orphans {
+			tables = append(tables, fmt.Sprintf("%s %d", table, n))
+		}
+		sort.Strings(tables)
+		fmt.Printf("orphaned rows: %s, run with -fix to delete them\n", strings.Join(tables, ", "))
+	}
+
+	before, err := w.Store.FileInfo(w.Ctx)
+	if err != nil {
+		return err
+	}
+	after := before
+	if vacuum {
+		if err := w.Store.Vacuum(w.Ctx); err != nil {
+			return err
+		}
+		if after, err = w.Store.FileInfo(w.Ctx); err != nil {
+			return err
+		}
+		fmt.Printf("vacuum: %s -> %s\n", formatSize(before.Size()), formatSize(after.Size()))
+	}
+
+	stats, err := w.Store.Stats(w.Ctx, 0)
+	if err != nil {
+		return err
+	}
+	trash, err := w.Store.Trash(w.Ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("database: %s, %s, %d pages of %d bytes, %d free, schema version %d, journal mode %s\n",
+		w.Path, formatSize(after.Size()), after.PageCount, after.PageSize, after.FreePages, after.SchemaVersion, after.JournalMode)
+	fmt.Printf("words: %d, translated %d, in trash %d, due %d, tags %d\n",
+		stats.Words, stats.Translated, len(trash), stats.Due, stats.Tags)
+	return nil
+}
+
+// size in bytes for humans, like 1.5 MiB
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
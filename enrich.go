@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// definition, mnemonic and example sentences of a word, generated by an llm
+type Enrichment struct {
+	Word       string   `json:"word"`
+	Definition string   `json:"definition"`
+	Mnemonic   string   `json:"mnemonic"`
+	Examples   []string `json:"examples"`
+	Model      string   `json:"model"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+func newEnrichment(e worddb.Enrichment) Enrichment {
+	examples := []string{}
+	if e.Examples != "" {
+		examples = strings.Split(e.Examples, "\n")
+	}
+	return Enrichment{
+		Word:       e.Word,
+		Definition: e.Definition,
+		Mnemonic:   e.Mnemonic,
+		Examples:   examples,
+		Model:      e.Model,
+		CreatedAt:  formatTimestamp(sql.NullTime{Time: e.CreatedAt, Valid: true}),
+	}
+}
+
+const enrichPrompt = `You help a Chinese speaker learn English vocabulary. For the English word given by the user, reply a JSON object with:
+- "definition": a simple English definition in one sentence, using common words
+- "mnemonic": a short memorable mnemonic to remember the word, in Chinese or English
+- "examples": an array of 3 natural example sentences using the word`
+
+// ask the chat completions api of config.LLM to enrich word, zhTrans helps
+// to pick the meaning
+func llmEnrich(ctx context.Context, word, zhTrans string) (worddb.SetEnrichmentParams, error) {
+	c := config.LLM
+	params := worddb.SetEnrichmentParams{Word: word, Model: c.Model}
+	if c.APIKey == "" {
+		return params, errors.New("llm api key is missing, set api_key in [llm] of the config file, or W2R_LLM_API_KEY")
+	}
+	content := word
+	if zhTrans != "" {
+		content += " (" + zhTrans + ")"
+	}
+	body, err := json.Marshal(map[string]any{
+		"model": c.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": enrichPrompt},
+			{"role": "user", "content": content},
+		},
+		"response_format": map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return params, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.BaseURL, "/")+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return params, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := doRemote(req, http.StatusOK)
+	if err != nil {
+		return params, err
+	}
+	defer resp.Body.Close()
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return params, fmt.Errorf("invalid llm response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return params, errors.New("llm replied no choices")
+	}
+	var result struct {
+		Definition string   `json:"definition"`
+		Mnemonic   string   `json:"mnemonic"`
+		Examples   []string `json:"examples"`
+	}
+	// some models wrap json in a markdown code block despite response_format
+	reply := strings.TrimSpace(completion.Choices[0].Message.Content)
+	reply = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(reply, "```json"), "```"), "```")
+	if err := json.Unmarshal([]byte(reply), &result); err != nil {
+		return params, fmt.Errorf("invalid llm reply %q: %w", reply, err)
+	}
+
+	params.Definition = strings.TrimSpace(result.Definition)
+	params.Mnemonic = strings.TrimSpace(result.Mnemonic)
+	var examples []string
+	for _, example := range result.Examples {
+		// one example per line in the database
+		if example = strings.Join(strings.Fields(example), " "); example != "" {
+			examples = append(examples, example)
+		}
+	}
+	params.Examples = strings.Join(examples, "\n")
+	return params, nil
+}
+
+// the cached enrichment of word, generated first if there is none or force
+// is set, or wordstore.ErrNotFound
+func (w *WordDB) enrich(ctx context.Context, word string, force bool) (worddb.Enrichment, error) {
+	queries := w.Store.Queries()
+	if !force {
+		cached, err := queries.GetEnrichment(ctx, word)
+		if err == nil || !errors.Is(err, sql.ErrNoRows) {
+			return cached, err
+		}
+	}
+	result, err := w.Store.Get(ctx, word)
+	if err != nil {
+		return worddb.Enrichment{}, err
+	}
+	params, err := llmEnrich(ctx, word, result.ZhTrans.String)
+	if err != nil {
+		return worddb.Enrichment{}, fmt.Errorf("enrich '%s': %w", word, err)
+	}
+	if err := queries.SetEnrichment(ctx, params); err != nil {
+		return worddb.Enrichment{}, err
+	}
+	return queries.GetEnrichment(ctx, word)
+}
+
+// print the enrichment of words, a failed word doesn't stop the rest
+func (w *WordDB) Enrich(words []string, force bool) error {
+	failed := 0
+	for _, word := range words {
+		e, err := w.enrich(w.Ctx, word, force)
+		if errors.Is(err, wordstore.ErrNotFound) {
+			err = fmt.Errorf("enrich '%s': %w%s", word, err, w.didYouMean(word))
+		}
+		if err != nil {
+			failed++
+			log.Printf("%v", err)
+			continue
+		}
+		fmt.Printf("%s\n", e.Word)
+		fmt.Printf("  definition: %s\n", e.Definition)
+		fmt.Printf("  mnemonic:   %s\n", e.Mnemonic)
+		for _, example := range newEnrichment(e).Examples {
+			fmt.Printf("  - %s\n", example)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to enrich %d of %d word(s)", failed, len(words))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// META-INF/container.xml of an epub, which tells where its package is
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// the package of an epub, the .opf file with the metadata, files and reading
+// order of the book
+type epubPackage struct {
+	Title    string `xml:"metadata>title"`
+	Manifest []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// the first heading of a chapter
+var headingElement = regexp.MustCompile(`(?is)<h[1-3]\b[^>]*>(.*?)</h[1-3]\s*>`)
+
+// decode the xml file name of an epub into v
+func readZipXML(r *zip.Reader, name string, v any) error {
+	f, err := r.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return xml.NewDecoder(f).Decode(v)
+}
+
+// the chapters of the epub book at path in reading order, with the title of
+// the book and of each chapter as their source, like "Dune, Chapter 3", or
+// the number of the chapter if it has no heading
+func readEPUB(filePath string) ([]textPart, error) {
+	r, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var container epubContainer
+	if err := readZipXML(&r.Reader, "META-INF/container.xml", &container); err != nil {
+		return nil, fmt.Errorf("%s: not an epub book: %w", filePath, err)
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("%s: no package in META-INF/container.xml", filePath)
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readZipXML(&r.Reader, opfPath, &pkg); err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", filePath, opfPath, err)
+	}
+	title := strings.TrimSpace(pkg.Title)
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+
+	// hrefs are relative to the package, and url escaped
+	hrefs := make(map[string]string)
+	for _, item := range pkg.Manifest {
+		href, err := url.PathUnescape(item.Href)
+		if err != nil {
+			href = item.Href
+		}
+		hrefs[item.ID] = path.Join(path.Dir(opfPath), href)
+	}
+	var parts []textPart
+	for i, itemRef := range pkg.Spine {
+		name, ok := hrefs[itemRef.IDRef]
+		if !ok {
+			continue
+		}
+		f, err := r.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		page, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", filePath, name, err)
+		}
+		text := strings.Join(htmlBlocks(string(page), nil), "\n\n")
+		if text == "" {
+			continue
+		}
+		chapter := fmt.Sprintf("ch.%d", i+1)
+		if m := headingElement.FindStringSubmatch(string(page)); m != nil && htmlText(m[1]) != "" {
+			chapter = htmlText(m[1])
+		}
+		parts = append(parts, textPart{Text: text, Source: title + ", " + chapter})
+	}
+	return parts, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// how often the data revision is checked for changes, by the command line
+// or other processes too
+const eventPollInterval = time.Second
+
+// comments sent to keep idle connections of /api/events open through
+// proxies
+const eventKeepAlive = 30 * time.Second
+
+// an event of /api/events: word-added, word-updated and word-deleted with
+// the word, or changed for changes of other data like reviews and tags
+type event struct {
+	Type     string `json:"-"`
+	Word     string `json:"word,omitempty"`
+	Deck     string `json:"deck,omitempty"`
+	Revision int64  `json:"revision"`
+}
+
+// sends the changes of the data to the clients of /api/events, as
+// server-sent events
+type eventHub struct {
+	w           *WordDB
+	mu          sync.Mutex
+	subscribers map[chan event]struct{}
+	// closed when the server shuts down, so streams end
+	done chan struct{}
+}
+
+func newEventHub(w *WordDB) *eventHub {
+	return &eventHub{w: w, subscribers: make(map[chan event]struct{}), done: make(chan struct{})}
+}
+
+func (h *eventHub) subscribe() chan event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// a few events may pile up while a client is slow
+	c := make(chan event, 64)
+	h.subscribers[c] = struct{}{}
+	return c
+}
+
+func (h *eventHub) unsubscribe(c chan event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, c)
+}
+
+func (h *eventHub) subscribed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers) > 0
+}
+
+// send e to all clients, clients too slow to take it miss it
+func (h *eventHub) broadcast(e event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.subscribers {
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+// events of the changes after revision, at since. Words are added, updated
+// or deleted if their timestamps are at or after since, which has a
+// precision of seconds, so a word may be sent twice. Other changes are
+// sent as one changed event.
+func (h *eventHub) changes(ctx context.Context, revision int64, since time.Time) ([]event, error) {
+	words, err := h.w.Store.Changes(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	since = since.Truncate(time.Second)
+	var events []event
+	for _, word := range words {
+		e := event{Type: "word-updated", Word: word.Word, Deck: word.Deck, Revision: revision}
+		switch {
+		case word.DeletedAt != nil:
+			e.Type = "word-deleted"
+		case word.CreatedAt != nil && !word.CreatedAt.Before(since):
+			e.Type = "word-added"
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		events = append(events, event{Type: "changed", Revision: revision})
+	}
+	return events, nil
+}
+
+// check the data revision until ctx is done, and send the changes to the
+// clients. The revision is checked without clients too, so changes right
+// after a client connects aren't missed, the changed words are only listed
+// for clients.
+func (h *eventHub) run(ctx context.Context) {
+	defer close(h.done)
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+	var last int64 = -1
+	var since time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		revision, _, err := h.w.Store.Revision(ctx)
+		if err != nil {
+			log.Printf("check data revision: %v", err)
+			continue
+		}
+		if last >= 0 && revision != last && h.subscribed() {
+			events, err := h.changes(ctx, revision, since)
+			if err != nil {
+				log.Printf("list changed words: %v", err)
+				continue
+			}
+			for _, e := range events {
+				h.broadcast(e)
+			}
+		}
+		last, since = revision, now
+	}
+}
+
+// stream the events to a client until it disconnects or the server shuts
+// down, for GET /api/events
+func (h *eventHub) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(rw)
+	events := h.subscribe()
+	defer h.unsubscribe(events)
+
+	header := rw.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// nginx buffers replies by default
+	header.Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	// browsers reconnect after 3 seconds if the connection is lost
+	fmt.Fprint(rw, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(rw, ": keep-alive\n\n")
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
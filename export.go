@@ -0,0 +1,50 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+var csvHeader = []string{"word", "zh_trans", "added_count", "lookup_count", "created_at", "updated_at"}
+
+// format a nullable timestamp in RFC 3339, empty when unknown
+func formatTimestamp(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// dump the whole word table as csv
+func (w *WordDB) ExportCSV(out io.Writer) error {
+	queries := worddb.New(w.Db)
+	words, err := queries.Listword(w.Ctx, w.DeckID)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(out)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, word := range words {
+		record := []string{
+			word.Word,
+			word.ZhTrans.String,
+			strconv.FormatInt(word.AddedCount.Int64, 10),
+			strconv.FormatInt(word.LookupCount.Int64, 10),
+			formatTimestamp(word.CreatedAt),
+			formatTimestamp(word.UpdatedAt),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
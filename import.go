@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// result of an import
+type ImportStats struct {
+	Added   int
+	Merged  int
+	Skipped int
+}
+
+// bulk load words from csv, the first column is word and the optional second
+// column is translation. A header row like the one written by export is
+// recognized. Duplicates increase added_count, all in one transaction. The
+// words are recorded to be from source if it's not empty.
+func (w *WordDB) ImportCSV(in io.Reader, comma rune, source string) (ImportStats, error) {
+	var stats ImportStats
+
+	r := csv.NewReader(in)
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	err := w.Store.Tx(w.Ctx, func(queries *worddb.Queries) error {
+		journal, err := wordstore.StartJournal(w.Ctx, queries, "import")
+		if err != nil {
+			return err
+		}
+		wordCol, transCol := 0, 1
+		first := true
+		for {
+			record, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if first {
+				first = false
+				if strings.EqualFold(strings.TrimSpace(record[0]), "word") {
+					transCol = -1
+					for i, name := range record {
+						switch strings.ToLower(strings.TrimSpace(name)) {
+						case "word":
+							wordCol = i
+						case "zh_trans", "translation":
+							transCol = i
+						}
+					}
+					continue
+				}
+			}
+
+			if wordCol >= len(record) {
+				stats.Skipped++
+				continue
+			}
+			word := wordstore.NormalizeWord(record[wordCol])
+			if !wordstore.IsValidWord(word) {
+				log.Printf("skip invalid word '%s'", record[wordCol])
+				stats.Skipped++
+				continue
+			}
+			zhTrans := sql.NullString{}
+			if transCol >= 0 && transCol < len(record) {
+				zhTrans = nullString(strings.TrimSpace(record[transCol]))
+			}
+
+			if err := stats.merge(w.Ctx, queries, journal, w.DeckID, word, zhTrans); err != nil {
+				return err
+			}
+			if source != "" {
+				if err := queries.AddWordSource(w.Ctx, worddb.AddWordSourceParams{Word: word, Source: source}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// add word to deck, or increase added_count if it already exists
+func (s *ImportStats) merge(ctx context.Context, queries *worddb.Queries, journal *wordstore.Journal, deckID int64, word string, zhTrans sql.NullString) error {
+	if err := journal.Record(ctx, word); err != nil {
+		return err
+	}
+	count, err := queries.CountWord(ctx, word)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		s.Added++
+		_, err = queries.CreateWord(ctx, worddb.CreateWordParams{
+			Word:    word,
+			ZhTrans: zhTrans,
+			DeckID:  max(deckID, wordstore.DefaultDeckID),
+			Level:   nullString(wordstore.Level(word)),
+		})
+		return err
+	}
+	s.Merged++
+	return queries.AddWordCount(ctx, word)
+}
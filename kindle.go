@@ -0,0 +1,87 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// lookups of kindle vocabulary builder, joined with the book title
+const kindleLookups = `
+SELECT w.word, IFNULL(w.stem, ''), IFNULL(w.lang, ''), IFNULL(l.usage, ''), IFNULL(b.title, '')
+FROM LOOKUPS l
+JOIN WORDS w ON w.id = l.word_key
+LEFT JOIN BOOK_INFO b ON b.id = l.book_key
+ORDER BY l.timestamp
+`
+
+// merge words and their usage sentences from kindle's vocab.db, each looked up
+// word is merged once, and every lookup adds its usage sentence and the title
+// of its book as source of the word, or source if it's not empty
+func (w *WordDB) ImportKindle(path, source string) (ImportStats, error) {
+	var stats ImportStats
+
+	kindle, err := sql.Open("sqlite3", wordstore.FileURI(path, "mode=ro"))
+	if err != nil {
+		return stats, err
+	}
+	defer kindle.Close()
+
+	rows, err := kindle.QueryContext(w.Ctx, kindleLookups)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	err = w.Store.Tx(w.Ctx, func(queries *worddb.Queries) error {
+		journal, err := wordstore.StartJournal(w.Ctx, queries, "import")
+		if err != nil {
+			return err
+		}
+		merged := make(map[string]bool)
+		for rows.Next() {
+			var surface, stem, lang, usage, title string
+			if err := rows.Scan(&surface, &stem, &lang, &usage, &title); err != nil {
+				return err
+			}
+			// lookups in books of other languages
+			if lang != "" && !strings.HasPrefix(strings.ToLower(lang), config.Language) {
+				stats.Skipped++
+				continue
+			}
+
+			// prefer the stem, kindle records the inflected form as word
+			word := wordstore.NormalizeWord(stem)
+			if !wordstore.IsValidWord(word) {
+				word = wordstore.NormalizeWord(surface)
+			}
+			if !wordstore.IsValidWord(word) {
+				stats.Skipped++
+				continue
+			}
+
+			if !merged[word] {
+				merged[word] = true
+				if err := stats.merge(w.Ctx, queries, journal, w.DeckID, word, sql.NullString{}); err != nil {
+					return err
+				}
+			}
+			if source := cmp.Or(source, strings.TrimSpace(title)); source != "" {
+				if err := queries.AddWordSource(w.Ctx, worddb.AddWordSourceParams{Word: word, Source: source}); err != nil {
+					return err
+				}
+			}
+			if usage = strings.TrimSpace(usage); usage != "" {
+				_, err := queries.AddSentence(w.Ctx, worddb.AddSentenceParams{Word: word, Sentence: usage, Source: nullString(title)})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return rows.Err()
+	})
+	return stats, err
+}
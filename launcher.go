@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// launchers which show the word list, with the json their extensions read
+const (
+	alfredFormat  = "alfred"
+	raycastFormat = "raycast"
+)
+
+// an item of an alfred script filter, see
+// https://www.alfredapp.com/help/workflows/inputs/script-filter/json/
+type alfredItem struct {
+	UID          string `json:"uid"`
+	Title        string `json:"title"`
+	Subtitle     string `json:"subtitle"`
+	Arg          string `json:"arg"`
+	Autocomplete string `json:"autocomplete"`
+	// words alfred matches the query against
+	Match        string `json:"match"`
+	QuicklookURL string `json:"quicklookurl"`
+	Text         struct {
+		Copy      string `json:"copy"`
+		LargeType string `json:"largetype"`
+	} `json:"text"`
+}
+
+// props of a List.Item of a raycast extension
+type raycastItem struct {
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Subtitle    string             `json:"subtitle"`
+	Keywords    []string           `json:"keywords"`
+	Accessories []raycastAccessory `json:"accessories"`
+	// link to the default dictionary
+	URL string `json:"url"`
+}
+
+type raycastAccessory struct {
+	Text string `json:"text"`
+}
+
+// the ipa and translation of a word for the subtitle of items
+func launcherSubtitle(phonetic, trans string) string {
+	var parts []string
+	for _, s := range []string{phonetic, trans} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// print the words selected by opts as json for the extensions of a launcher,
+// format is alfred or raycast. Items link to the default dictionary of the
+// web server.
+func (w *WordDB) ShowLauncherItems(opts wordstore.ListOptions, format string) error {
+	words, translations, err := w.listTranslations(opts)
+	if err != nil {
+		return err
+	}
+
+	var result any
+	switch format {
+	case alfredFormat:
+		items := make([]alfredItem, 0, len(words))
+		for _, word := range words {
+			trans := translations[word.Word]
+			item := alfredItem{
+				UID:          word.Word,
+				Title:        word.Word,
+				Subtitle:     launcherSubtitle(word.Phonetic.String, trans),
+				Arg:          word.Word,
+				Autocomplete: word.Word,
+				Match:        strings.TrimSpace(word.Word + " " + trans),
+				QuicklookURL: dictionaries[0].Link(word.Word),
+			}
+			item.Text.Copy = word.Word
+			item.Text.LargeType = strings.TrimSpace(word.Word + "\n" + trans)
+			items = append(items, item)
+		}
+		result = struct {
+			Items []alfredItem `json:"items"`
+		}{items}
+	case raycastFormat:
+		items := make([]raycastItem, 0, len(words))
+		for _, word := range words {
+			trans := translations[word.Word]
+			item := raycastItem{
+				ID:          word.Word,
+				Title:       word.Word,
+				Subtitle:    launcherSubtitle(word.Phonetic.String, trans),
+				Keywords:    append([]string{}, strings.Fields(trans)...),
+				Accessories: []raycastAccessory{},
+				URL:         dictionaries[0].Link(word.Word),
+			}
+			if word.Level.String != "" {
+				item.Accessories = append(item.Accessories, raycastAccessory{Text: word.Level.String})
+			}
+			items = append(items, item)
+		}
+		result = items
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(result)
+}
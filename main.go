@@ -1,57 +1,98 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
-	"text/template"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
+	"github.com/notsobad/w2r/pkg/w2rclient"
+	"github.com/notsobad/w2r/pkg/wordstore"
 	"github.com/notsobad/w2r/worddb"
 )
 
 var (
-	DbName  = ".word.sqlite" // in $HOME directory
+	DbName  = "word.sqlite" // in $XDG_DATA_HOME/w2r directory
 	Version = "0.1"
-	//go:embed words.html
+	//go:embed words.html word.html review.html stats.html add.html apidocs.html share.html
 	WordsHTML embed.FS
 )
 
 // struct to store word database
 type WordDB struct {
 	// database connection
-	Db  *sql.DB
-	Ctx context.Context
+	Db    *sql.DB
+	Store *wordstore.Store
+	Ctx   context.Context
+	// path of the database file
+	Path string
+	// back up the database before destructive operations
+	AutoBackup bool
+	// deck selected by --deck, 0 for all decks when reading, and the default
+	// deck when adding
+	DeckID   int64
+	DeckName string
+	// fetch translation of new words when set
+	Translator Translator
+	// fetch ipa of new words when set
+	Phonetics Translator
+	// language of the zh_trans translations, translate.lang of the config
+	// before -lang flags select another one
+	TransLang string
+	// database of the users of serve -users and the user whose database
+	// this is, when run with --user
+	Users  *wordstore.Store
+	UserID int64
 }
 
-func isValidWord(s string) bool {
-	match, _ := regexp.MatchString("^[a-z]+$", s)
-	return match
+// whether translations are into another language than zh_trans, selected by
+// a -lang flag, they are kept by language code then
+func (w *WordDB) otherLang() bool {
+	return !strings.EqualFold(config.Translate.Lang, w.TransLang)
 }
 
-func getDb() *sql.DB {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Println(err)
-		return nil
+// path of database, the --db flag takes precedence over W2R_DB environment
+// variable and the config file, and falls back to DbName in $XDG_DATA_HOME/w2r
+func dbPath(flagPath string) (string, error) {
+	if flagPath != "" {
+		return flagPath, nil
+	}
+	if config.DB != "" {
+		return config.DB, nil
 	}
 
-	// 拼接文件路径
-	DbPath := filepath.Join(homeDir, DbName)
+	path, err := defaultDbPath()
+	if err != nil {
+		return "", fmt.Errorf("locate database: %w, use --db or W2R_DB to set database path", err)
+	}
+	return path, nil
+}
 
-	db, err := sql.Open("sqlite3", DbPath)
+// path to open the database, the auth token of the config is added to the
+// url of a remote database, unless the url has one
+func dbSource(path string) (string, error) {
+	if !wordstore.IsRemote(path) || config.DBAuthToken == "" {
+		return path, nil
+	}
+	u, err := url.Parse(path)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
+	}
+	q := u.Query()
+	if q.Get("authToken") == "" {
+		q.Set("authToken", config.DBAuthToken)
+		u.RawQuery = q.Encode()
 	}
-	return db
+	return u.String(), nil
 }
 
 // get multiple words from arguments, split
@@ -61,182 +102,408 @@ func filterWords(s string) []string {
 	results := make([]string, 0) // Initialize results as an empty string slice
 
 	for _, word := range words {
-		word = strings.TrimSpace(word)
-		word = strings.ToLower(word)
-		if isValidWord(word) {
+		word = wordstore.NormalizeWord(word)
+		if wordstore.IsValidWord(word) {
 			results = append(results, word)
 		}
 	}
 	return results
 }
 
-// init database
-func (w *WordDB) Init() {
-	db := w.Db
+// read words from r, one word per line, comma separated lines are allowed too
+func readWords(r io.Reader) ([]string, error) {
+	results := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		results = append(results, filterWords(scanner.Text())...)
+	}
+	return results, scanner.Err()
+}
 
-	sqlStmt := `
-    CREATE TABLE word (
-        word TEXT PRIMARY KEY,
-        zh_trans TEXT,
-        added_count INTEGER,
-        lookup_count INTEGER
-    );
-    `
-	_, err := db.Exec(sqlStmt)
+// whether stdin is piped or redirected rather than a terminal
+func stdinIsPipe() bool {
+	stat, err := os.Stdin.Stat()
 	if err != nil {
-		log.Printf("%q: %s\n", err, sqlStmt)
-		return
+		return false
 	}
-	log.Printf("init database")
+	return stat.Mode()&os.ModeCharDevice == 0
 }
 
-// add word to database
-func (w *WordDB) AddWord(word string) {
+// init database, it's safe to run on an initialized database
+func (w *WordDB) Init() error {
+	applied, err := w.Store.Migrate(w.Ctx)
+	if err != nil {
+		return err
+	}
+	version, _ := w.Store.SchemaVersion(w.Ctx)
+	log.Printf("init database, %d migration(s) applied, schema version %d", applied, version)
+	return nil
+}
 
-	queries := worddb.New(w.Db)
+// translations of the words not in the database yet by t, if not nil, words
+// failed to translate are left out
+func (w *WordDB) translateNew(ctx context.Context, t Translator, words []string) map[string]string {
+	translations := make(map[string]string)
+	if t == nil {
+		return translations
+	}
+	for _, word := range words {
+		if exists, _ := w.Store.Exists(ctx, word); exists {
+			continue
+		}
+		trans, err := t.Translate(ctx, word)
+		if err != nil {
+			log.Printf("translate '%s': %v", word, err)
+			continue
+		}
+		translations[word] = trans
+	}
+	return translations
+}
 
-	count, _ := queries.CountWord(w.Ctx, word)
-	if count == 0 {
-		_, err := queries.CreateWord(w.Ctx, worddb.CreateWordParams{Word: word, ZhTrans: sql.NullString{}})
+// lemmas of words like run of running if lemmatize is set in the config,
+// with the inflected forms by lemma, a word failed to lemmatize is kept as
+// it is
+func (w *WordDB) lemmatize(ctx context.Context, words []string) ([]string, map[string][]string) {
+	variants := make(map[string][]string)
+	if !config.Lemmatize {
+		return words, variants
+	}
+	lemmas := make([]string, 0, len(words))
+	for _, word := range words {
+		lemma, err := w.Store.Lemma(ctx, word)
 		if err != nil {
-			log.Fatal(err)
+			log.Printf("lemma of '%s': %v", word, err)
+			lemma = word
 		}
-		log.Printf("add word '%s'", word)
-	} else {
-		err := queries.AddWordCount(w.Ctx, word)
+		if lemma != word {
+			log.Printf("'%s' is a form of '%s'", word, lemma)
+			variants[lemma] = append(variants[lemma], word)
+		}
+		lemmas = append(lemmas, lemma)
+	}
+	return lemmas, variants
+}
+
+// add words to database with tags, note and context in one transaction,
+// words already in database get added_count++. A failed word is reported and
+// doesn't stop the rest.
+func (w *WordDB) AddWords(words []string, tags []string, note, context, source string) error {
+	words, variants := w.lemmatize(w.Ctx, words)
+	// fetch translations before the transaction, so slow dictionaries don't
+	// hold the database lock
+	translations := w.translateNew(w.Ctx, w.Translator, words)
+	opts := wordstore.AddOptions{Tags: tags, DeckID: w.DeckID, Note: note, Context: context, Source: source}
+	opts.Phonetics = w.phoneticsNew(w.Ctx, w.Phonetics, words)
+	opts.Variants = variants
+	return w.addWords(words, opts, translations)
+}
+
+// add words with opts and log the results, translations of new words are
+// saved as translations into the language of the translate config
+func (w *WordDB) addWords(words []string, opts wordstore.AddOptions, translations map[string]string) error {
+	opts.Translations = translations
+	if w.otherLang() {
+		opts.Translations = nil
+	}
+	results, err := w.Store.Add(w.Ctx, words, opts)
+	if err != nil {
+		return err
+	}
+	if w.otherLang() && len(translations) > 0 {
+		if _, err := w.Store.SetLangTranslations(w.Ctx, config.Translate.Lang, translations); err != nil {
+			return err
+		}
+	}
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			log.Printf("add word '%s': %v", result.Word, result.Err)
+		case result.Created && wordstore.Level(result.Word) != "":
+			log.Printf("add word '%s', level %s", result.Word, wordstore.Level(result.Word))
+		case result.Created:
+			log.Printf("add word '%s'", result.Word)
+		default:
+			log.Printf("word '%s' already in database, added_count++", result.Word)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to add %d of %d word(s)", failed, len(results))
+	}
+	return nil
+}
+
+// set translation of an existing word, empty translation clears it
+func (w *WordDB) SetTranslation(word, zhTrans string) error {
+	if w.otherLang() {
+		if err := w.Store.SetLangTranslation(w.Ctx, config.Translate.Lang, word, zhTrans); err != nil {
+			return fmt.Errorf("set %s translation of '%s': %w", config.Translate.Lang, word, err)
+		}
+		log.Printf("set %s translation of '%s'", config.Translate.Lang, word)
+		return nil
+	}
+	if err := w.Store.SetTranslation(w.Ctx, word, zhTrans); err != nil {
+		return fmt.Errorf("set translation of '%s': %w", word, err)
+	}
+	log.Printf("set translation of '%s'", word)
+	return nil
+}
+
+// format a nullable timestamp as local date, "-" when unknown
+func formatDate(t sql.NullTime) string {
+	if !t.Valid {
+		return "-"
+	}
+	return t.Time.Local().Format("2006-01-02")
+}
+
+// words selected by opts, and their translations into the language of the
+// translate config by word, their zh_trans or translations into another
+// language
+func (w *WordDB) listTranslations(opts wordstore.ListOptions) ([]worddb.Word, map[string]string, error) {
+	words, err := w.Store.List(w.Ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	translations := make(map[string]string, len(words))
+	if w.otherLang() {
+		other, err := w.Store.LangTranslations(w.Ctx, config.Translate.Lang)
 		if err != nil {
-			log.Fatal(err)
+			return nil, nil, err
+		}
+		for _, word := range words {
+			translations[word.Word] = other[word.Word]
 		}
-		log.Printf("word '%s' already in database, added_count++", word)
+		return words, translations, nil
 	}
+	for _, word := range words {
+		translations[word.Word] = word.ZhTrans.String
+	}
+	return words, translations, nil
 }
 
 // show summary
-func (w *WordDB) ShowSummary() {
-
-	queries := worddb.New(w.Db)
-	words, _ := queries.Listword(w.Ctx)
+func (w *WordDB) ShowSummary(opts wordstore.ListOptions) error {
+	words, translations, err := w.listTranslations(opts)
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("%15s %10s %12s %-12s\n", "Word", "Added Count", "Lookup Count", "Translation")
+	fmt.Printf("%15s %-18s %-5s %10s %12s %10s %10s %-12s\n", "Word", "IPA", "Level", "Added Count", "Lookup Count", "Created", "Updated", "Translation")
 	for _, word := range words {
 		lookupCount := word.LookupCount.Int64
 		if !word.LookupCount.Valid {
 			lookupCount = 0
 		}
-		zhTrans := ""
-		if word.ZhTrans.Valid {
-			zhTrans = word.ZhTrans.String
-		}
-		fmt.Printf("%15s %10d %12d %-12s\n",
-			word.Word, word.AddedCount.Int64, lookupCount, zhTrans)
+		fmt.Printf("%15s %-18s %-5s %10d %12d %10s %10s %-12s\n",
+			word.Word, word.Phonetic.String, word.Level.String, word.AddedCount.Int64, lookupCount, formatDate(word.CreatedAt), formatDate(word.UpdatedAt), translations[word.Word])
 	}
+	return nil
 }
 
-// delete word from database
-func (w *WordDB) DelWord(word string) {
-	db := w.Db
+// hint of words close to a misspelled word, empty if there's none
+func (w *WordDB) didYouMean(word string) string {
+	suggestions, err := w.Store.Suggest(w.Ctx, word, 3)
+	if err != nil || len(suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, ", "))
+}
 
-	queries := worddb.New(db)
-	err := queries.DeleteWord(w.Ctx, word)
+// delete word from database
+func (w *WordDB) DelWord(word string) error {
+	err := w.Store.Delete(w.Ctx, word)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		return fmt.Errorf("del word '%s': %w%s", word, err, w.didYouMean(word))
+	}
+	if err != nil {
+		return fmt.Errorf("del word '%s': %w", word, err)
+	}
+	log.Printf("del word '%s', moved to trash", word)
+	return nil
+}
 
+// show deleted words, the most recently deleted first
+func (w *WordDB) ShowTrash() error {
+	words, err := w.Store.Trash(w.Ctx)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	log.Printf("del word '%s'", word)
+
+	fmt.Printf("%15s %10s %10s %-12s\n", "Word", "Added Count", "Deleted", "Translation")
+	for _, word := range words {
+		fmt.Printf("%15s %10d %10s %-12s\n", word.Word, word.AddedCount.Int64, formatDate(word.DeletedAt), word.ZhTrans.String)
+	}
+	return nil
 }
 
-// create a http service to show all words, and generate links to online dictionary
-func (w *WordDB) RunWebServer(port int) {
-	tmpl, err := template.ParseFS(WordsHTML, "words.html")
+func (w *WordDB) RestoreWord(word string) error {
+	if err := w.Store.Restore(w.Ctx, word); err != nil {
+		return fmt.Errorf("restore word '%s': %w", word, err)
+	}
+	log.Printf("restore word '%s'", word)
+	return nil
+}
+
+// revert the most recent add, del, restore, set-trans or import
+func (w *WordDB) Undo() error {
+	op, words, err := w.Store.Undo(w.Ctx)
 	if err != nil {
-		// handle error
-		log.Fatal(err)
+		return err
 	}
+	log.Printf("undo %s of %d word(s): %s", op, len(words), strings.Join(words, ", "))
+	return nil
+}
 
-	http.HandleFunc("/", func(rw http.ResponseWriter, r *http.Request) {
+// permanently delete the words in the trash
+func (w *WordDB) EmptyTrash() error {
+	if err := w.autoBackup(); err != nil {
+		return err
+	}
+	n, err := w.Store.EmptyTrash(w.Ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("deleted %d word(s) in trash", n)
+	return nil
+}
 
-		queries := worddb.New(w.Db)
-		words, _ := queries.Listword(w.Ctx)
+// combine the words of another w2r database into this one
+func (w *WordDB) Merge(path string) error {
+	if err := w.autoBackup(); err != nil {
+		return err
+	}
+	result, err := w.Store.Merge(w.Ctx, path, w.DeckID)
+	if err != nil {
+		return err
+	}
+	log.Printf("merge '%s': %d added, %d merged, %d skipped", path, result.Added, result.Merged, result.Skipped)
+	return nil
+}
 
-		err := tmpl.Execute(rw, words)
-		if err != nil {
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	})
-	// add /word to show single word
-	http.HandleFunc("/word/", func(rw http.ResponseWriter, r *http.Request) {
-		word := strings.TrimPrefix(r.URL.Path, "/word/")
-		word = strings.TrimSuffix(word, "/")
-		if word == "" {
-			http.Error(rw, "word not found", http.StatusNotFound)
-			return
-		}
-		// redirect to online dictionary
-		http.Redirect(rw, r, "https://dictionary.cambridge.org/dictionary/english-chinese-simplified/"+word, http.StatusMovedPermanently)
+// exit codes of w2r, for scripts and editors calling it
+const (
+	exitOK       = 0
+	exitUsage    = 1
+	exitNotFound = 2
+	exitDB       = 3
+	exitError    = 4
+)
 
-	})
-	log.Printf("Start web server at http://127.0.0.1:%d", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf("127.0.0.1:%d", port), nil))
+// map the error of a command to exit code
+func exitCode(err error) int {
+	var sqliteErr sqlite3.Error
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, wordstore.ErrNotFound), errors.Is(err, wordstore.ErrDeckNotFound), errors.Is(err, wordstore.ErrSentenceNotFound),
+		errors.Is(err, wordstore.ErrUserNotFound), errors.Is(err, wordstore.ErrShareNotFound), errors.Is(err, w2rclient.ErrNotFound):
+		return exitNotFound
+	case errors.As(err, &sqliteErr), errors.Is(err, sql.ErrConnDone), errors.Is(err, sql.ErrTxDone):
+		return exitDB
+	}
+	return exitError
 }
 
 func main() {
-	init := flag.Bool("init", false, "init database")
-	add := flag.String("a", "", "add new word")
-	show := flag.Bool("s", false, "show summary")
-	del := flag.String("d", "", "del word")
-	daemon := flag.Bool("D", false, "run webserver")
-	port := flag.Int("p", 8080, "webserver port")
+	flag.CommandLine.Init(progName(), flag.ContinueOnError)
+	// load config first, it provides the defaults of flags
+	cfgPath, err := configPath()
+	if err == nil {
+		config, err = loadConfig(cfgPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: load config: %v\n", progName(), err)
+		os.Exit(exitError)
+	}
 	showVersion := flag.Bool("v", false, "show version")
-	flag.Parse()
-	// show help when run with no argument
-	if flag.NFlag() == 0 {
-		flag.Usage()
-		return
+	dbFlag := flag.String("db", "", "database path, default $W2R_DB, db in config file or $XDG_DATA_HOME/w2r/"+DbName)
+	deck := flag.String("deck", config.Deck, "only use words in this deck, and add new words to it, default $W2R_DECK")
+	language := flag.String("language", config.Language, "code of the language of words like en, de or ja, default $W2R_LANGUAGE")
+	autoBackup := flag.Bool("auto-backup", config.AutoBackup, "back up the database before import, deleting several words and emptying trash, default true if $W2R_AUTO_BACKUP is set")
+	remote := flag.String("remote", config.Remote, "url of a w2r server like https://host to run commands with its api instead of the database, default $W2R_REMOTE")
+	remoteToken := flag.String("remote-token", config.RemoteToken, "bearer token of the -remote server, default $W2R_REMOTE_TOKEN")
+	user := flag.String("user", "", "run the command on the database of this user of serve -users, like share for them")
+	flag.Usage = usage
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(exitOK)
+		}
+		os.Exit(exitUsage)
 	}
 
-	if showVersion != nil && *showVersion {
+	if *showVersion {
 		fmt.Printf("word version: %s\n", Version)
 		return
 	}
-
-	db := getDb()
-	defer db.Close()
-
-	w := WordDB{Db: db}
-	w.Ctx = context.Background()
-
-	if daemon != nil && *daemon {
-		// port must be between 0~65535
-		if *port <= 0 || *port > 65535 {
-			log.Fatal("port must be between 0~65535")
-		}
-
-		w.RunWebServer(*port)
+	// show help when run with no argument
+	if flag.NArg() == 0 {
+		flag.Usage()
 		return
 	}
 
-	if init != nil && *init {
-		w.Init()
-		return
+	cmd := findCommand(flag.Arg(0))
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(exitUsage)
 	}
 
-	if add != nil && *add != "" {
-		words := filterWords(*add)
-		for _, word := range words {
-			w.AddWord(word)
-		}
-		return
+	// EN is en too
+	config.Language = strings.ToLower(strings.TrimSpace(*language))
+	wordstore.Language = config.Language
+	// thin clients without the database
+	if *remote != "" {
+		exitCommand(cmd, runRemote(cmd, flag.Args()[1:], *remote, *remoteToken, *deck))
 	}
 
-	if del != nil && *del != "" {
-		w.DelWord(*del)
-		return
+	path, err := dbPath(*dbFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", progName(), err)
+		os.Exit(exitDB)
+	}
+	source, err := dbSource(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", progName(), err)
+		os.Exit(exitDB)
+	}
+	ctx := context.Background()
+	// create or upgrade schema on first run, so init is optional
+	store, err := wordstore.Open(ctx, source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: open database %s: %v\n", progName(), path, err)
+		os.Exit(exitDB)
 	}
 
-	if show != nil && *show {
-		w.ShowSummary()
-		return
+	w := WordDB{Db: store.DB(), Store: store, Ctx: ctx, Path: path, AutoBackup: *autoBackup, DeckName: *deck, TransLang: config.Translate.Lang}
+	if *user != "" {
+		err = w.useUser(*user)
+	}
+	if err == nil && w.DeckName != "" {
+		w.DeckID, err = w.Store.DeckID(ctx, w.DeckName)
+	}
+	if err == nil {
+		err = cmd.run(&w, flag.Args()[1:])
+	}
+	if w.Store != store {
+		w.Store.Close()
 	}
+	store.Close()
+	exitCommand(cmd, err)
+}
 
+// print the error of cmd and exit with its exit code
+func exitCommand(cmd *command, err error) {
+	// usage and help are already printed
+	if err != nil && !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+		// one line for each of joined errors
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", progName(), cmd.name, line)
+		}
+	}
+	os.Exit(exitCode(err))
 }
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// versions of the model context protocol, the latest first, see
+// https://modelcontextprotocol.io/specification
+var mcpVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// a json-rpc 2.0 request, or a notification without id
+type mcpRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+// a tool of the server, run gets the arguments and returns the text result
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+	run         func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// json schema of the arguments of a tool
+func mcpSchema(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (w *WordDB) mcpTools() []mcpTool {
+	str := func(desc string) map[string]any { return map[string]any{"type": "string", "description": desc} }
+	num := func(desc string) map[string]any { return map[string]any{"type": "integer", "description": desc} }
+	return []mcpTool{
+		{
+			Name:        "add_word",
+			Description: "Add an English word to the vocabulary, or count it again if it's already there. Returns the word.",
+			InputSchema: mcpSchema(map[string]any{
+				"word":        str("English word or phrase like well-being or give up"),
+				"translation": str("Chinese translation, kept if the word already has one"),
+				"tags":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "tags like GRE"},
+				"note":        str("personal note"),
+				"context":     str("sentence where the word was seen"),
+			}, "word"),
+			run: w.mcpAddWord,
+		},
+		{
+			Name:        "list_words",
+			Description: "List words of the vocabulary with their translations and counts.",
+			InputSchema: mcpSchema(map[string]any{
+				"query": str("only words or translations containing this"),
+				"tag":   str("only words with this tag"),
+				"sort":  map[string]any{"type": "string", "enum": []string{"date", "added", "lookup", "alpha"}, "description": "order, the most recently added first by default"},
+				"limit": num("max number of words, 50 by default"),
+			}),
+			run: w.mcpListWords,
+		},
+		{
+			Name:        "get_due_reviews",
+			Description: "Words due for spaced repetition review, the most overdue first.",
+			InputSchema: mcpSchema(map[string]any{
+				"limit": num("max number of words, 20 by default"),
+			}),
+			run: w.mcpDueReviews,
+		},
+		{
+			Name:        "set_translation",
+			Description: "Set the Chinese translation of a word in the vocabulary, an empty translation clears it.",
+			InputSchema: mcpSchema(map[string]any{
+				"word":        str("the word"),
+				"translation": str("Chinese translation"),
+			}, "word", "translation"),
+			run: w.mcpSetTranslation,
+		},
+	}
+}
+
+func (w *WordDB) mcpAddWord(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Word        string   `json:"word"`
+		Translation string   `json:"translation"`
+		Tags        []string `json:"tags"`
+		Note        string   `json:"note"`
+		Context     string   `json:"context"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	word := wordstore.NormalizeWord(args.Word)
+	if !wordstore.IsValidWord(word) {
+		return nil, fmt.Errorf("%q is not a valid word or phrase", args.Word)
+	}
+	results, err := w.Store.Add(ctx, []string{word}, wordstore.AddOptions{
+		Translations: map[string]string{word: args.Translation},
+		Tags:         args.Tags,
+		DeckID:       w.DeckID,
+		Note:         args.Note,
+		Context:      args.Context,
+	})
+	if err == nil {
+		err = results[0].Err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.mcpWord(ctx, word)
+}
+
+// word with its tags
+func (w *WordDB) mcpWord(ctx context.Context, word string) (apiWord, error) {
+	result, err := w.Store.Get(ctx, word)
+	if err != nil {
+		return apiWord{}, err
+	}
+	tags, err := w.Store.WordTags(ctx)
+	if err != nil {
+		return apiWord{}, err
+	}
+	return newAPIWord(result, tags[word]), nil
+}
+
+func (w *WordDB) mcpListWords(ctx context.Context, raw json.RawMessage) (any, error) {
+	args := struct {
+		Query string `json:"query"`
+		Tag   string `json:"tag"`
+		Sort  string `json:"sort"`
+		Limit int    `json:"limit"`
+	}{Sort: "date", Limit: 50}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	words, err := w.Store.List(ctx, wordstore.ListOptions{Sort: args.Sort, Limit: max(args.Limit, 1), Filter: args.Query, Tag: args.Tag, DeckID: w.DeckID})
+	if err != nil {
+		return nil, err
+	}
+	tags, err := w.Store.WordTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]apiWord, 0, len(words))
+	for _, word := range words {
+		results = append(results, newAPIWord(word, tags[word.Word]))
+	}
+	return results, nil
+}
+
+func (w *WordDB) mcpDueReviews(ctx context.Context, raw json.RawMessage) (any, error) {
+	args := struct {
+		Limit int `json:"limit"`
+	}{Limit: 20}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	words, err := w.Store.Queries().ListDueWords(ctx, worddb.ListDueWordsParams{DueAt: time.Now().UTC(), DeckID: w.DeckID, Limit: int64(max(args.Limit, 1))})
+	if err != nil {
+		return nil, err
+	}
+	type dueWord struct {
+		Word    string `json:"word"`
+		ZhTrans string `json:"zh_trans"`
+		// reviews in a row, 0 for new words
+		Repetitions  int64 `json:"repetitions"`
+		IntervalDays int64 `json:"interval_days"`
+	}
+	results := make([]dueWord, 0, len(words))
+	for _, word := range words {
+		results = append(results, dueWord{word.Word, word.ZhTrans.String, word.Repetitions.Int64, word.IntervalDays.Int64})
+	}
+	return results, nil
+}
+
+func (w *WordDB) mcpSetTranslation(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Word        string `json:"word"`
+		Translation string `json:"translation"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, err
+	}
+	if err := w.Store.SetTranslation(ctx, args.Word, args.Translation); err != nil {
+		return nil, err
+	}
+	return w.mcpWord(ctx, args.Word)
+}
+
+// result of a request, or a json-rpc error
+func (w *WordDB) mcpHandle(ctx context.Context, tools []mcpTool, req mcpRequest) (any, *mcpError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		// the version of the client if supported, otherwise the latest
+		version := mcpVersions[0]
+		if slices.Contains(mcpVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "w2r", "version": Version},
+			"instructions":    "Tools to manage the English vocabulary of the user in w2r, translations are Chinese.",
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &mcpError{Code: -32602, Message: "invalid params"}
+		}
+		i := slices.IndexFunc(tools, func(t mcpTool) bool { return t.Name == params.Name })
+		if i < 0 {
+			return nil, &mcpError{Code: -32602, Message: "unknown tool " + params.Name}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		// errors of tools are results, so the model can see them
+		result, err := tools[i].run(ctx, params.Arguments)
+		if err != nil {
+			return map[string]any{"content": []map[string]string{{"type": "text", "text": err.Error()}}, "isError": true}, nil
+		}
+		text, err := json.Marshal(result)
+		if err != nil {
+			return nil, &mcpError{Code: -32603, Message: err.Error()}
+		}
+		return map[string]any{"content": []map[string]string{{"type": "text", "text": string(text)}}}, nil
+	}
+	return nil, &mcpError{Code: -32601, Message: "method not found: " + req.Method}
+}
+
+// serve the model context protocol over in and out, a json-rpc message per
+// line, until in is closed. Logs go to stderr, out is only for messages.
+func (w *WordDB) ServeMCP(in io.Reader, out io.Writer) error {
+	tools := w.mcpTools()
+	enc := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var req mcpRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := enc.Encode(mcpResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &mcpError{Code: -32700, Message: "parse error"}}); err != nil {
+				return err
+			}
+			continue
+		}
+		// notifications like notifications/initialized get no response
+		if len(req.ID) == 0 {
+			continue
+		}
+		result, rpcErr := w.mcpHandle(w.Ctx, tools, req)
+		if err := enc.Encode(mcpResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
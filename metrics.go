@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// upper bounds in seconds of the buckets of the request duration histogram,
+// the defaults of the prometheus client libraries
+var durationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// labels of a series of the request metrics
+type requestSeries struct {
+	method string
+	code   int
+}
+
+// histogram of request durations, counts are by bucket of durationBuckets,
+// not cumulative
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// counts and durations of requests of the web server by method and status
+type requestMetrics struct {
+	mu     sync.Mutex
+	series map[requestSeries]*histogram
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{series: make(map[requestSeries]*histogram)}
+}
+
+// http methods kept as labels, others are counted as "other" so clients
+// can't add series at will
+var metricMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+func (m *requestMetrics) observe(method string, code int, d time.Duration) {
+	if !slices.Contains(metricMethods, method) {
+		method = "other"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := requestSeries{method: method, code: code}
+	h := m.series[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		m.series[key] = h
+	}
+	seconds := d.Seconds()
+	if i, _ := slices.BinarySearch(durationBuckets, seconds); i < len(durationBuckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// wrap h to count requests and their durations
+func (m *requestMetrics) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: rw}
+		h.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		m.observe(r.Method, rec.status, time.Since(start))
+	})
+}
+
+// write the histograms in the prometheus text format, sorted by labels so
+// scrapes are stable
+func (m *requestMetrics) write(out io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]requestSeries, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b requestSeries) int {
+		return cmp.Or(cmp.Compare(a.method, b.method), cmp.Compare(a.code, b.code))
+	})
+
+	const name = "w2r_http_request_duration_seconds"
+	fmt.Fprintf(out, "# HELP %s Duration of HTTP requests by method and status code.\n", name)
+	fmt.Fprintf(out, "# TYPE %s histogram\n", name)
+	for _, key := range keys {
+		h := m.series[key]
+		labels := fmt.Sprintf(`method="%s",code="%d"`, key.method, key.code)
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(out, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(out, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(out, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+// write a metric without labels in the prometheus text format
+func writeMetric(out io.Writer, name, kind, help string, value int64) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
+
+// serve the metrics of all decks and of requests in the prometheus text
+// format, the word counts are read from the database, so words added or
+// reviewed with the command line are counted too
+func (w *WordDB) metricsHandler(requests *requestMetrics) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		stats, err := w.Store.Stats(r.Context(), 0)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reviews, err := w.Store.Queries().CountReviews(r.Context())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		out := bufio.NewWriter(rw)
+		defer out.Flush()
+		writeMetric(out, "w2r_words", "gauge", "Words in the database, not counting the trash.", stats.Words)
+		writeMetric(out, "w2r_words_translated", "gauge", "Words with a translation.", stats.Translated)
+		writeMetric(out, "w2r_words_due", "gauge", "Words due for review now.", stats.Due)
+		writeMetric(out, "w2r_words_archived", "gauge", "Archived words.", stats.Archived)
+		fmt.Fprintf(out, "# HELP w2r_words_by_mastery Words by mastery state.\n# TYPE w2r_words_by_mastery gauge\n")
+		for _, state := range wordstore.MasteryStates {
+			fmt.Fprintf(out, "w2r_words_by_mastery{state=\"%s\"} %d\n", state, stats.Mastery[state])
+		}
+		writeMetric(out, "w2r_adds_total", "counter", "Times words in the database were added, including adding them again.", stats.Words+stats.AddedCount)
+		writeMetric(out, "w2r_lookups_total", "counter", "Lookups of words in the database.", stats.LookupCount)
+		writeMetric(out, "w2r_reviews_total", "counter", "Reviews of words.", reviews)
+		requests.write(out)
+	}
+}
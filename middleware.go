@@ -0,0 +1,166 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+)
+
+// a response writer keeping the status code, for the access log and metrics
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// for http.ResponseController to reach the flusher of the connection
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// wrap h to log each request with its method, path, status and duration,
+// without the query, which may carry the token of quick add
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: rw}
+		h.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		log.Printf("%s %s %s %d %s", r.RemoteAddr, r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Microsecond))
+	})
+}
+
+// wrap h to reply 500 instead of crashing the server when a handler panics,
+// the panic is logged with its stack. Nothing is replied if the handler has
+// started replying already, and http.ErrAbortHandler is passed on to abort
+// the response as it's meant to.
+func recoverPanics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: rw}
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if e, ok := err.(error); ok && errors.Is(e, http.ErrAbortHandler) {
+				panic(err)
+			}
+			log.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+			if rec.status != 0 {
+				return
+			}
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				writeJSONError(rec, http.StatusInternalServerError, "internal server error")
+			} else {
+				http.Error(rec, "internal server error", http.StatusInternalServerError)
+			}
+		}()
+		h.ServeHTTP(rec, r)
+	})
+}
+
+// content types compressed with gzip, others like audio and event streams
+// are sent as is
+var gzipTypes = []string{"text/html", "text/plain", "text/css", "text/javascript", "application/javascript", "application/json", "image/svg+xml"}
+
+// a response writer compressing the body with gzip if its content type is
+// one of gzipTypes, decided when the header is written
+type gzipWriter struct {
+	http.ResponseWriter
+	gz      *gzip.Writer
+	decided bool
+}
+
+func (g *gzipWriter) WriteHeader(code int) {
+	if !g.decided {
+		g.decided = true
+		header := g.Header()
+		mediaType, _, _ := strings.Cut(header.Get("Content-Type"), ";")
+		if code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified &&
+			header.Get("Content-Encoding") == "" && slices.Contains(gzipTypes, strings.TrimSpace(mediaType)) {
+			header.Del("Content-Length")
+			header.Set("Content-Encoding", "gzip")
+			g.gz = gzip.NewWriter(g.ResponseWriter)
+		}
+	}
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if !g.decided {
+		// like net/http does for handlers not setting it
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+// send what's compressed so far, for streamed replies
+func (g *gzipWriter) FlushError() error {
+	if g.gz != nil {
+		if err := g.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+func (g *gzipWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// whether the client accepts gzip, without q=0
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(encoding, ";")
+		if strings.TrimSpace(name) == "gzip" {
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
+
+// wrap h to compress html, json and other text replies with gzip for
+// clients accepting it
+func compress(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			h.ServeHTTP(rw, r)
+			return
+		}
+		g := &gzipWriter{ResponseWriter: rw}
+		defer func() {
+			if g.gz != nil {
+				g.gz.Close()
+			}
+		}()
+		h.ServeHTTP(g, r)
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"gzip, deflate, br", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.000", false},
+		{"br", false},
+		{"x-gzip", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(r); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	const body = `{"word":"abate"}`
+	tests := []struct {
+		name        string
+		method      string
+		accept      string
+		contentType string
+		status      int
+		want        bool
+	}{
+		{"json", "GET", "gzip", "application/json", http.StatusOK, true},
+		{"html with charset", "GET", "gzip", "text/html; charset=utf-8", http.StatusOK, true},
+		{"detected type", "GET", "gzip", "", http.StatusOK, true},
+		{"error", "GET", "gzip", "text/plain; charset=utf-8", http.StatusNotFound, true},
+		{"not accepted", "GET", "", "application/json", http.StatusOK, false},
+		{"head", "HEAD", "gzip", "application/json", http.StatusOK, false},
+		{"audio", "GET", "gzip", "audio/mpeg", http.StatusOK, false},
+		{"event stream", "GET", "gzip", "text/event-stream", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		h := compress(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if tt.contentType != "" {
+				rw.Header().Set("Content-Type", tt.contentType)
+			}
+			// the content type is detected by the first write without it
+			if tt.status != http.StatusOK {
+				rw.WriteHeader(tt.status)
+			}
+			io.WriteString(rw, body)
+		}))
+		r := httptest.NewRequest(tt.method, "/api/words", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept-Encoding", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		if got := rec.Header().Get("Content-Encoding") == "gzip"; got != tt.want {
+			t.Errorf("%s: gzipped = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s: Vary = %q, want Accept-Encoding", tt.name, rec.Header().Get("Vary"))
+		}
+		reader := io.Reader(rec.Body)
+		if tt.want {
+			gz, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			reader = gz
+		}
+		got, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != body {
+			t.Errorf("%s: body = %q, want %q", tt.name, got, body)
+		}
+	}
+}
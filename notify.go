@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// show a desktop notification, with notify-send on linux and the bsds, which
+// talks to the notification daemon over d-bus, osascript on macos and a
+// powershell toast on windows
+func notifyDesktop(ctx context.Context, title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, body)
+	case "windows":
+		// the text is passed in the environment to avoid quoting
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToast)
+		cmd.Env = append(os.Environ(), "W2R_TITLE="+title, "W2R_BODY="+body)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=w2r", title, body)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+const windowsToast = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:W2R_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:W2R_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('w2r').Show([Windows.UI.Notifications.ToastNotification]::new($xml))`
+
+// ReminderSchedule is when the web server shows desktop notifications and
+// mails digests, as offsets from midnight.
+type ReminderSchedule struct {
+	Notify []time.Duration
+	Digest []time.Duration
+	// days of each digest
+	DigestDays int
+}
+
+// parse local times of day like 09:00,21:30 into offsets from midnight,
+// sorted
+func parseNotifyTimes(times []string) ([]time.Duration, error) {
+	var offsets []time.Duration
+	for _, s := range times {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notify time %q, use hh:mm like 09:00", s)
+		}
+		offsets = append(offsets, time.Duration(t.Hour())*time.Hour+time.Duration(t.Minute())*time.Minute)
+	}
+	slices.Sort(offsets)
+	return slices.Compact(offsets), nil
+}
+
+// the first of the times of day after now
+func nextNotifyTime(now time.Time, offsets []time.Duration) time.Time {
+	for day := startOfDay(now); ; day = day.AddDate(0, 0, 1) {
+		for _, offset := range offsets {
+			// by the clock, days with daylight saving changes aren't 24h
+			h, m := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+			t := time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+			if t.After(now) {
+				return t
+			}
+		}
+	}
+}
+
+// the reminder of due reviews and the unmet daily goal, empty if there is
+// nothing to remind
+func (w *WordDB) reminder(ctx context.Context) (string, error) {
+	var lines []string
+	stats, err := w.Store.Stats(ctx, w.DeckID)
+	if err != nil {
+		return "", err
+	}
+	if stats.Due > 0 {
+		lines = append(lines, fmt.Sprintf("%d word(s) due for review", stats.Due))
+	}
+	if config.Goal.Words > 0 || config.Goal.Reviews > 0 {
+		streak, err := w.streak(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !goalReached(streak.Words, streak.Reviews) {
+			lines = append(lines, fmt.Sprintf("daily goal: %d/%d new words, %d/%d reviews, streak %d day(s)",
+				streak.Words, streak.GoalWords, streak.Reviews, streak.GoalReviews, streak.Current))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// notify due reviews and the unmet daily goal at the times of day until ctx
+// is done
+func (w *WordDB) runReminders(ctx context.Context, offsets []time.Duration) {
+	for {
+		timer := time.NewTimer(time.Until(nextNotifyTime(time.Now(), offsets)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		// keep the schedule after failures, the next one may succeed
+		body, err := w.reminder(ctx)
+		if err == nil && body != "" {
+			err = notifyDesktop(ctx, "w2r", body)
+		}
+		if err != nil {
+			log.Printf("reminder: %v", err)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+var errNotInDict = errors.New("word not found in offline dictionary")
+
+// entry of an offline dictionary
+type DictEntry struct {
+	Word        string
+	Phonetic    string
+	Definition  string
+	Translation string
+}
+
+// local dictionary, which works without network
+type OfflineDict interface {
+	Lookup(ctx context.Context, word string) (DictEntry, error)
+	Close() error
+}
+
+// open an offline dictionary, path is either the .ifo file of a StarDict
+// dictionary or an ECDICT sqlite database
+func openOfflineDict(path string) (OfflineDict, error) {
+	if strings.EqualFold(filepath.Ext(path), ".ifo") {
+		return openStarDict(path)
+	}
+	return openECDict(path)
+}
+
+// ECDICT sqlite database, see https://github.com/skywind3000/ECDICT
+type ecDict struct {
+	db *sql.DB
+}
+
+func openECDict(path string) (*ecDict, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", wordstore.FileURI(path, "mode=ro"))
+	if err != nil {
+		return nil, err
+	}
+	return &ecDict{db: db}, nil
+}
+
+func (d *ecDict) Lookup(ctx context.Context, word string) (DictEntry, error) {
+	var e DictEntry
+	err := d.db.QueryRowContext(ctx, `
+    SELECT word, IFNULL(phonetic, ''), IFNULL(definition, ''), IFNULL(translation, '')
+    FROM stardict WHERE word = ? COLLATE NOCASE LIMIT 1
+    `, word).Scan(&e.Word, &e.Phonetic, &e.Definition, &e.Translation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, errNotInDict
+	}
+	// the ECDICT csv escapes new lines as \n, which some converted databases keep
+	e.Definition = strings.ReplaceAll(e.Definition, `\n`, "\n")
+	e.Translation = strings.ReplaceAll(e.Translation, `\n`, "\n")
+	return e, err
+}
+
+func (d *ecDict) Close() error {
+	return d.db.Close()
+}
+
+// StarDict dictionary, the index is loaded into memory, and the .dict.dz
+// data is decompressed into memory too
+type starDict struct {
+	sameTypeSequence string
+	index            map[string][2]uint64
+	data             io.ReaderAt
+	closer           io.Closer
+}
+
+// find the first existing file among base+exts
+func findDictFile(base string, exts ...string) (string, error) {
+	for _, ext := range exts {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext, nil
+		}
+	}
+	return "", fmt.Errorf("%s%s not found", base, exts[0])
+}
+
+// read a file, decompressing it if it's gzipped
+func readDictFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".dz") {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	}
+	return data, nil
+}
+
+func openStarDict(ifoPath string) (*starDict, error) {
+	f, err := os.Open(ifoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			info[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	offsetSize := 4
+	if info["idxoffsetbits"] == "64" {
+		offsetSize = 8
+	}
+
+	base := strings.TrimSuffix(ifoPath, filepath.Ext(ifoPath))
+	idxPath, err := findDictFile(base, ".idx", ".idx.gz")
+	if err != nil {
+		return nil, err
+	}
+	idx, err := readDictFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// each index entry is word\0, offset and size in big endian
+	d := &starDict{sameTypeSequence: info["sametypesequence"], index: make(map[string][2]uint64)}
+	for len(idx) > 0 {
+		end := bytes.IndexByte(idx, 0)
+		if end < 0 || len(idx) < end+1+offsetSize+4 {
+			return nil, fmt.Errorf("%s: corrupted index", idxPath)
+		}
+		word := strings.ToLower(string(idx[:end]))
+		idx = idx[end+1:]
+		var offset uint64
+		if offsetSize == 8 {
+			offset = binary.BigEndian.Uint64(idx)
+		} else {
+			offset = uint64(binary.BigEndian.Uint32(idx))
+		}
+		size := uint64(binary.BigEndian.Uint32(idx[offsetSize:]))
+		idx = idx[offsetSize+4:]
+		if _, ok := d.index[word]; !ok {
+			d.index[word] = [2]uint64{offset, size}
+		}
+	}
+
+	dictPath, err := findDictFile(base, ".dict", ".dict.dz")
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(dictPath, ".dz") {
+		data, err := readDictFile(dictPath)
+		if err != nil {
+			return nil, err
+		}
+		d.data = bytes.NewReader(data)
+	} else {
+		df, err := os.Open(dictPath)
+		if err != nil {
+			return nil, err
+		}
+		d.data, d.closer = df, df
+	}
+	return d, nil
+}
+
+func (d *starDict) Lookup(ctx context.Context, word string) (DictEntry, error) {
+	loc, ok := d.index[strings.ToLower(word)]
+	if !ok {
+		return DictEntry{}, errNotInDict
+	}
+	buf := make([]byte, loc[1])
+	if _, err := d.data.ReadAt(buf, int64(loc[0])); err != nil {
+		return DictEntry{}, err
+	}
+
+	e := DictEntry{Word: word}
+	for _, field := range d.fields(buf) {
+		switch field.typ {
+		case 't':
+			e.Phonetic = field.text
+		case 'm', 'x', 'h', 'g', 'l':
+			if e.Translation != "" {
+				e.Translation += "\n"
+			}
+			e.Translation += field.text
+		}
+	}
+	return e, nil
+}
+
+type starDictField struct {
+	typ  byte
+	text string
+}
+
+// split entry data into typed fields, lower case types are null terminated
+// text, upper case types are prefixed with size
+func (d *starDict) fields(data []byte) []starDictField {
+	fields := make([]starDictField, 0)
+	types := d.sameTypeSequence
+	for i := 0; len(data) > 0; i++ {
+		var typ byte
+		if types != "" {
+			if i >= len(types) {
+				break
+			}
+			typ = types[i]
+		} else {
+			typ, data = data[0], data[1:]
+		}
+		// the last field of sametypesequence has no terminator or size
+		last := types != "" && i == len(types)-1
+
+		var value []byte
+		switch {
+		case last:
+			value, data = data, nil
+		case typ >= 'a' && typ <= 'z':
+			end := bytes.IndexByte(data, 0)
+			if end < 0 {
+				end = len(data)
+			}
+			value = data[:end]
+			data = data[min(end+1, len(data)):]
+		default:
+			if len(data) < 4 {
+				return fields
+			}
+			size := int(binary.BigEndian.Uint32(data))
+			data = data[4:]
+			size = min(size, len(data))
+			value, data = data[:size], data[size:]
+		}
+		if typ >= 'a' && typ <= 'z' {
+			fields = append(fields, starDictField{typ: typ, text: strings.TrimSpace(string(value))})
+		}
+	}
+	return fields
+}
+
+func (d *starDict) Close() error {
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
+
+// translate with the offline dictionary in config, which is opened on first use
+type offlineTranslator struct {
+	once sync.Once
+	dict OfflineDict
+	err  error
+}
+
+func (t *offlineTranslator) open() (OfflineDict, error) {
+	t.once.Do(func() {
+		if config.Translate.OfflineDict == "" {
+			t.err = errors.New("offline dictionary not set, use -offline-dict or W2R_OFFLINE_DICT")
+			return
+		}
+		t.dict, t.err = openOfflineDict(config.Translate.OfflineDict)
+	})
+	return t.dict, t.err
+}
+
+func (t *offlineTranslator) Translate(ctx context.Context, word string) (string, error) {
+	dict, err := t.open()
+	if err != nil {
+		return "", err
+	}
+	e, err := dict.Lookup(ctx, word)
+	if err != nil {
+		return "", err
+	}
+	if e.Translation == "" {
+		return "", errNotInDict
+	}
+	// ECDICT separates meanings with new lines
+	return strings.Join(strings.Fields(strings.ReplaceAll(e.Translation, "\n", "; ")), " "), nil
+}
+
+// look up words in the offline dictionary and print the entries
+func (w *WordDB) ShowOfflineEntries(words []string) error {
+	dict, err := openOfflineDict(config.Translate.OfflineDict)
+	if err != nil {
+		return err
+	}
+	defer dict.Close()
+
+	for _, word := range words {
+		e, err := dict.Lookup(w.Ctx, word)
+		if errors.Is(err, errNotInDict) {
+			fmt.Printf("%s: not found\n\n", word)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s", e.Word)
+		if e.Phonetic != "" {
+			fmt.Printf(" [%s]", e.Phonetic)
+		}
+		fmt.Println()
+		if e.Definition != "" {
+			fmt.Printf("%s\n", e.Definition)
+		}
+		if e.Translation != "" {
+			fmt.Printf("%s\n", e.Translation)
+		}
+		fmt.Println()
+	}
+	return nil
+}
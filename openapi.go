@@ -0,0 +1,26 @@
+package main
+
+import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
+)
+
+// openapi 3 document of the json api, keep it in sync with registerAPI
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// the openapi document with the base path and version of the server, served
+// at /api/openapi.json
+func openAPIDocument(base string) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		return nil, err
+	}
+	doc["servers"] = []map[string]string{{"url": cmp.Or(base, "/")}}
+	if info, ok := doc["info"].(map[string]any); ok {
+		info["version"] = Version
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
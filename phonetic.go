@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+var (
+	errNoPhonetic    = errors.New("no ipa found")
+	errNotInFreeDict = errors.New("word not found in the free dictionary")
+)
+
+// sources of ipa pronunciations, selected by name, they return the ipa of a
+// word like /ˈæp.əl/
+var phoneticSources = map[string]Translator{
+	"dictionaryapi": dictionaryAPIPhonetic{},
+	"offline":       &offlinePhonetic{},
+}
+
+func phoneticSourceNames() string {
+	names := make([]string, 0, len(phoneticSources))
+	for name := range phoneticSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+func getPhoneticSource(name string) (Translator, error) {
+	p, ok := phoneticSources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ipa source %q, available: %s", name, phoneticSourceNames())
+	}
+	return p, nil
+}
+
+// ipa between slashes, as ECDICT has it without them
+func formatPhonetic(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "/") || strings.HasPrefix(s, "[") {
+		return s
+	}
+	return "/" + s + "/"
+}
+
+// an entry of the free dictionary api
+type dictionaryAPIEntry struct {
+	Phonetic  string `json:"phonetic"`
+	Phonetics []struct {
+		Text  string `json:"text"`
+		Audio string `json:"audio"`
+	} `json:"phonetics"`
+	Meanings []struct {
+		Definitions []struct {
+			Example string `json:"example"`
+		} `json:"definitions"`
+	} `json:"meanings"`
+}
+
+// look up word in the free dictionary api, see https://dictionaryapi.dev,
+// which only has english words
+func dictionaryAPILookup(ctx context.Context, word string) ([]dictionaryAPIEntry, error) {
+	if !wordstore.IsEnglish() {
+		return nil, errNotInFreeDict
+	}
+	var entries []dictionaryAPIEntry
+	err := getJSON(ctx, "https://api.dictionaryapi.dev/api/v2/entries/en/"+url.PathEscape(word), &entries)
+	// unknown words are 404 Not Found
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		return nil, errNotInFreeDict
+	}
+	return entries, err
+}
+
+// free dictionary api, no api key needed
+type dictionaryAPIPhonetic struct{}
+
+func (dictionaryAPIPhonetic) Translate(ctx context.Context, word string) (string, error) {
+	entries, err := dictionaryAPILookup(ctx, word)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.Phonetic != "" {
+			return formatPhonetic(entry.Phonetic), nil
+		}
+		for _, phonetic := range entry.Phonetics {
+			if phonetic.Text != "" {
+				return formatPhonetic(phonetic.Text), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w for '%s'", errNoPhonetic, word)
+}
+
+// the offline dictionary of the offline translator
+type offlinePhonetic struct {
+	offlineTranslator
+}
+
+func (p *offlinePhonetic) Translate(ctx context.Context, word string) (string, error) {
+	dict, err := p.open()
+	if err != nil {
+		return "", err
+	}
+	e, err := dict.Lookup(ctx, word)
+	if err != nil {
+		return "", err
+	}
+	if e.Phonetic == "" {
+		return "", fmt.Errorf("%w for '%s'", errNoPhonetic, word)
+	}
+	return formatPhonetic(e.Phonetic), nil
+}
+
+// ipa of the words not in the database yet by p, if not nil, words failed
+// to look up are left out
+func (w *WordDB) phoneticsNew(ctx context.Context, p Translator, words []string) map[string]string {
+	phonetics := make(map[string]string)
+	if p == nil {
+		return phonetics
+	}
+	for _, word := range words {
+		if exists, _ := w.Store.Exists(ctx, word); exists {
+			continue
+		}
+		phonetic, err := p.Translate(ctx, word)
+		if err != nil {
+			log.Printf("ipa of '%s': %v", word, err)
+			continue
+		}
+		phonetics[word] = phonetic
+	}
+	return phonetics
+}
@@ -0,0 +1,200 @@
+package w2rclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DueWord is the next word to review.
+type DueWord struct {
+	Word    string `json:"word"`
+	ZhTrans string `json:"zh_trans"`
+	// number of due words
+	Due int `json:"due"`
+}
+
+// NextReview returns the most overdue word of the deck, or nil if nothing is
+// due.
+func (c *Client) NextReview(ctx context.Context) (*DueWord, error) {
+	var result DueWord
+	status, err := c.do(ctx, http.MethodGet, "/api/review/next", nil, nil, &result)
+	if err != nil || status == http.StatusNoContent {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ReviewResult is when a word is reviewed next.
+type ReviewResult struct {
+	Word         string `json:"word"`
+	IntervalDays int64  `json:"interval_days"`
+	// new, learning, known or mastered, mastered words are archived
+	Mastery string `json:"mastery"`
+	// box of the Leitner system, if it's the algorithm of the server
+	Box int `json:"box,omitempty"`
+}
+
+// AnswerReview grades the review of a word, grade is again, hard, good or
+// easy, or 1 to 4. latency is the time from showing the word to the answer,
+// 0 if unknown.
+func (c *Client) AnswerReview(ctx context.Context, word, grade string, latency time.Duration) (ReviewResult, error) {
+	body := struct {
+		Word      string `json:"word"`
+		Grade     string `json:"grade"`
+		LatencyMs int64  `json:"latency_ms,omitempty"`
+	}{word, grade, latency.Milliseconds()}
+	var result ReviewResult
+	_, err := c.do(ctx, http.MethodPost, "/api/review/answer", nil, body, &result)
+	return result, err
+}
+
+// Review is a review of the review log.
+type Review struct {
+	Word       string    `json:"word"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+	// again, hard, good or easy, correct if hard or better
+	Grade   string `json:"grade"`
+	Correct bool   `json:"correct"`
+	// milliseconds taken to answer, nil if unknown
+	LatencyMs *int64 `json:"latency_ms,omitempty"`
+	// interval scheduled before the review, 0 for the first review, and
+	// after the review
+	IntervalDays     int64 `json:"interval_days"`
+	NextIntervalDays int64 `json:"next_interval_days"`
+}
+
+// HistoryOptions filters the reviews of ReviewHistory.
+type HistoryOptions struct {
+	// reviews of a word only
+	Word string
+	// reviews since then only
+	Since time.Time
+	// at most this many reviews, 1000 if 0, the server returns 10000 at most
+	Limit int
+}
+
+// ReviewHistory returns the review log of the deck, the latest first.
+func (c *Client) ReviewHistory(ctx context.Context, opts HistoryOptions) ([]Review, error) {
+	query := url.Values{}
+	if opts.Word != "" {
+		query.Set("word", opts.Word)
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var reviews []Review
+	err := c.get(ctx, "/api/review/history", query, &reviews)
+	return reviews, err
+}
+
+// RetentionBucket is the share of correct reviews after an interval since
+// the previous review.
+type RetentionBucket struct {
+	Label string `json:"label"`
+	// shortest interval of the bucket in days
+	MinDays int64 `json:"min_days"`
+	Reviews int64 `json:"reviews"`
+	Correct int64 `json:"correct"`
+	// percentage of correct reviews, 0 without reviews
+	Accuracy float64 `json:"accuracy"`
+}
+
+// WordRetention is the retention of a word.
+type WordRetention struct {
+	Word     string            `json:"word"`
+	Reviews  int64             `json:"reviews"`
+	Correct  int64             `json:"correct"`
+	Accuracy float64           `json:"accuracy"`
+	Buckets  []RetentionBucket `json:"buckets"`
+}
+
+// Retention is the retention of all words by interval, and of the words with
+// the lowest retention.
+type Retention struct {
+	Buckets []RetentionBucket `json:"buckets"`
+	Words   []WordRetention   `json:"words"`
+}
+
+// Retention returns the retention of the deck, or of a word if it isn't
+// empty, with top words of the lowest retention.
+func (c *Client) Retention(ctx context.Context, word string, top int) (Retention, error) {
+	query := url.Values{"top": {strconv.Itoa(top)}}
+	if word != "" {
+		query.Set("word", word)
+	}
+	var result Retention
+	err := c.get(ctx, "/api/review/retention", query, &result)
+	return result, err
+}
+
+// Streak is the number of days in a row the daily goal was reached.
+type Streak struct {
+	// days until today, or yesterday while the goal of today isn't reached
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+	// words added and reviews today
+	Words   int64 `json:"words"`
+	Reviews int64 `json:"reviews"`
+	// the goal
+	GoalWords   int64 `json:"goal_words"`
+	GoalReviews int64 `json:"goal_reviews"`
+}
+
+// Lookup is a word and the number of its lookups.
+type Lookup struct {
+	Word        string `json:"word"`
+	ZhTrans     string `json:"zh_trans"`
+	LookupCount int64  `json:"lookup_count"`
+}
+
+// Stats are the statistics of a deck.
+type Stats struct {
+	Words       int64 `json:"words"`
+	Translated  int64 `json:"translated"`
+	Due         int64 `json:"due"`
+	Tags        int64 `json:"tags"`
+	AddedCount  int64 `json:"added_count"`
+	LookupCount int64 `json:"lookup_count"`
+	// words by CEFR level, "" for words without one
+	Levels map[string]int64 `json:"levels"`
+	// words by mastery state, new, learning, known or mastered
+	Mastery  map[string]int64 `json:"mastery"`
+	Archived int64            `json:"archived"`
+	// words added by local date of the server, like 2006-01-02
+	AddedPerDay map[string]int64 `json:"added_per_day"`
+	TopLookups  []Lookup         `json:"top_lookups"`
+	// of all decks
+	Streak Streak `json:"streak"`
+}
+
+// Stats returns the statistics of the deck, with top most looked up words.
+func (c *Client) Stats(ctx context.Context, top int) (Stats, error) {
+	var result Stats
+	err := c.get(ctx, "/api/stats", url.Values{"top": {strconv.Itoa(top)}}, &result)
+	return result, err
+}
+
+// WordOfTheDay is the word of the day of a deck.
+type WordOfTheDay struct {
+	Word    string `json:"word"`
+	ZhTrans string `json:"zh_trans"`
+	// local date of the server like 2006-01-02
+	Day string `json:"day"`
+	// whether the word is due for review today, otherwise it's one of the
+	// least practiced words
+	Due bool `json:"due"`
+}
+
+// WordOfTheDay returns the word of the day, ErrNotFound if the deck has no
+// words.
+func (c *Client) WordOfTheDay(ctx context.Context) (WordOfTheDay, error) {
+	var result WordOfTheDay
+	err := c.get(ctx, "/api/wotd", nil, &result)
+	return result, err
+}
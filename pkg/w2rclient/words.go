@@ -0,0 +1,220 @@
+package w2rclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Word is a word of the server.
+type Word struct {
+	Word        string    `json:"word"`
+	ZhTrans     string    `json:"zh_trans"`
+	Phonetic    string    `json:"phonetic,omitempty"`
+	Level       string    `json:"level,omitempty"`
+	AddedCount  int64     `json:"added_count"`
+	LookupCount int64     `json:"lookup_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Tags        []string  `json:"tags"`
+	Note        string    `json:"note,omitempty"`
+	Context     string    `json:"context,omitempty"`
+	// Translations into other languages by code, Sources, Mastery and
+	// Archived are only set for single words, not for lists.
+	Translations map[string]string `json:"translations,omitempty"`
+	Sources      []string          `json:"sources,omitempty"`
+	// new, learning, known or mastered
+	Mastery  string `json:"mastery,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
+}
+
+// WordRequest is a word to add. Only Word is required.
+type WordRequest struct {
+	Word    string   `json:"word"`
+	ZhTrans string   `json:"zh_trans,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Note    string   `json:"note,omitempty"`
+	// the sentence the word was met in
+	Context string `json:"context,omitempty"`
+	// where the word is from, like a book title
+	Source string `json:"source,omitempty"`
+}
+
+// ListOptions filters the words of ListWords, all words which aren't
+// archived by default.
+type ListOptions struct {
+	Tag string
+	// CEFR level like B1
+	Level string
+	// prefix of the source
+	Source string
+	// new, learning, known or mastered
+	Mastery string
+	// list only archived words
+	Archived bool
+}
+
+// ListWords returns the words of the deck.
+func (c *Client) ListWords(ctx context.Context, opts ListOptions) ([]Word, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{"tag": opts.Tag, "level": opts.Level, "source": opts.Source, "mastery": opts.Mastery} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if opts.Archived {
+		query.Set("archived", "1")
+	}
+	var words []Word
+	err := c.get(ctx, "/api/words", query, &words)
+	return words, err
+}
+
+// Search returns the words matching q by full-text search, the most relevant
+// first.
+func (c *Client) Search(ctx context.Context, q string) ([]Word, error) {
+	var words []Word
+	err := c.get(ctx, "/api/search", url.Values{"q": {q}}, &words)
+	return words, err
+}
+
+// GetWord returns a word, with its translations, sources and mastery.
+func (c *Client) GetWord(ctx context.Context, word string) (Word, error) {
+	var result Word
+	err := c.get(ctx, wordPath(word), nil, &result)
+	return result, err
+}
+
+// AddWord adds a word to the deck, or counts it added once more if it exists,
+// and reports whether it's new.
+func (c *Client) AddWord(ctx context.Context, req WordRequest) (Word, bool, error) {
+	var result Word
+	status, err := c.do(ctx, http.MethodPost, "/api/words", nil, req, &result)
+	return result, status == http.StatusCreated, err
+}
+
+// SetTranslation sets the translation of a word, or its translation into lang
+// if it isn't empty or the default language of the server.
+func (c *Client) SetTranslation(ctx context.Context, word, lang, translation string) (Word, error) {
+	body := struct {
+		ZhTrans string `json:"zh_trans"`
+		Lang    string `json:"lang,omitempty"`
+	}{translation, lang}
+	var result Word
+	_, err := c.do(ctx, http.MethodPut, wordPath(word), nil, body, &result)
+	return result, err
+}
+
+// DeleteWord moves a word to the trash.
+func (c *Client) DeleteWord(ctx context.Context, word string) error {
+	_, err := c.do(ctx, http.MethodDelete, wordPath(word), nil, nil, nil)
+	return err
+}
+
+// Enrichment is the definition, mnemonic and example sentences of a word
+// generated by the LLM of the server.
+type Enrichment struct {
+	Word       string    `json:"word"`
+	Definition string    `json:"definition"`
+	Mnemonic   string    `json:"mnemonic"`
+	Examples   []string  `json:"examples"`
+	Model      string    `json:"model"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Enrich returns the enrichment of a word, generated once and cached by the
+// server unless force is set.
+func (c *Client) Enrich(ctx context.Context, word string, force bool) (Enrichment, error) {
+	var query url.Values
+	if force {
+		query = url.Values{"force": {"true"}}
+	}
+	var result Enrichment
+	_, err := c.do(ctx, http.MethodPost, wordPath(word, "/enrich"), query, nil, &result)
+	return result, err
+}
+
+// RelatedWord is a synonym or antonym, and whether it's in the database.
+type RelatedWord struct {
+	Word  string `json:"word"`
+	Added bool   `json:"added"`
+}
+
+// RelatedWords are the synonyms and antonyms of a word.
+type RelatedWords struct {
+	Synonyms []RelatedWord `json:"synonyms"`
+	Antonyms []RelatedWord `json:"antonyms"`
+}
+
+// Related returns the synonyms and antonyms of a word, fetched by the server
+// from Datamuse the first time, or again if refresh is set.
+func (c *Client) Related(ctx context.Context, word string, refresh bool) (RelatedWords, error) {
+	var query url.Values
+	if refresh {
+		query = url.Values{"refresh": {"true"}}
+	}
+	var result RelatedWords
+	err := c.get(ctx, wordPath(word, "/related"), query, &result)
+	return result, err
+}
+
+// Sentence is an example sentence of a word.
+type Sentence struct {
+	ID       int64  `json:"id"`
+	Sentence string `json:"sentence"`
+	Source   string `json:"source,omitempty"`
+}
+
+// Sentences returns the example sentences of a word.
+func (c *Client) Sentences(ctx context.Context, word string) ([]Sentence, error) {
+	var sentences []Sentence
+	err := c.get(ctx, wordPath(word, "/sentences"), nil, &sentences)
+	return sentences, err
+}
+
+// AddSentence adds an example sentence to a word, source may be empty, and
+// returns all sentences of the word.
+func (c *Client) AddSentence(ctx context.Context, word, sentence, source string) ([]Sentence, error) {
+	body := struct {
+		Sentence string `json:"sentence"`
+		Source   string `json:"source,omitempty"`
+	}{sentence, source}
+	var sentences []Sentence
+	_, err := c.do(ctx, http.MethodPost, wordPath(word, "/sentences"), nil, body, &sentences)
+	return sentences, err
+}
+
+// DeleteSentence deletes an example sentence by its id.
+func (c *Client) DeleteSentence(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodDelete, "/api/sentences/"+strconv.FormatInt(id, 10), nil, nil, nil)
+	return err
+}
+
+// Tag is a tag and the number of its words.
+type Tag struct {
+	Name      string `json:"name"`
+	WordCount int64  `json:"word_count"`
+}
+
+// Tags returns all tags.
+func (c *Client) Tags(ctx context.Context) ([]Tag, error) {
+	var tags []Tag
+	err := c.get(ctx, "/api/tags", nil, &tags)
+	return tags, err
+}
+
+// Source is where words are from, like a book title, and the number of its
+// words.
+type Source struct {
+	Source    string `json:"source"`
+	WordCount int64  `json:"word_count"`
+}
+
+// Sources returns all sources.
+func (c *Client) Sources(ctx context.Context) ([]Source, error) {
+	var sources []Source
+	err := c.get(ctx, "/api/sources", nil, &sources)
+	return sources, err
+}
@@ -0,0 +1,103 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backup copies the database to a new sqlite file at path with the online
+// backup API of sqlite, so it's consistent even if the store is being written.
+func (s *Store) Backup(ctx context.Context, path string) error {
+	if s.remote {
+		return ErrRemote
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup %s: %w", path, os.ErrExist)
+	}
+	dest, err := sql.Open("sqlite3", FileURI(path, ""))
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	if err := copyDatabase(ctx, dest, s.db); err != nil {
+		return err
+	}
+	// the copy is in WAL mode like the store, a single file is easier to move
+	_, err = dest.ExecContext(ctx, "PRAGMA journal_mode = DELETE")
+	return err
+}
+
+// RestoreBackup replaces the content of the database with the backup at
+// path, and upgrades its schema if the backup is from an older version.
+func (s *Store) RestoreBackup(ctx context.Context, path string) error {
+	if s.remote {
+		return ErrRemote
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	src, err := sql.Open("sqlite3", FileURI(path, "mode=ro"))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// refuse to replace the words with some other sqlite database
+	var tables int
+	err = src.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'word'").Scan(&tables)
+	if err != nil {
+		return fmt.Errorf("read backup %s: %w", path, err)
+	}
+	if tables == 0 {
+		return fmt.Errorf("%s is not a w2r database", path)
+	}
+
+	if err := copyDatabase(ctx, s.db, src); err != nil {
+		return err
+	}
+	_, err = s.Migrate(ctx)
+	return err
+}
+
+// copy the main database of src to dest, page by page
+func copyDatabase(ctx context.Context, dest, src *sql.DB) error {
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("backup needs a sqlite3 connection")
+			}
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("backup needs a sqlite3 connection")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			// -1 copies all pages in one step
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,258 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// Reasons of duplicates.
+const (
+	// an inflected form of another word, like cats of cat
+	DuplicateForm = "form"
+	// another word in different case, like Paris of paris
+	DuplicateCase = "case"
+	// a misspelling of another word, a letter or two apart or with two
+	// letters swapped
+	DuplicateTypo = "typo"
+)
+
+// DuplicateReasons are the reasons of duplicates.
+var DuplicateReasons = []string{DuplicateForm, DuplicateCase, DuplicateTypo}
+
+// Duplicate is a word which seems to be a duplicate of another word.
+type Duplicate struct {
+	Word string
+	// the word Word would be merged into
+	Into   string
+	Reason string
+}
+
+// shortest words compared for typos, shorter words are too often different
+// words one letter apart
+const minTypoLength = 5
+
+// FindDuplicates returns the words which seem to duplicate other words in
+// the store, in alphabetical order: inflected forms like plurals, words
+// differing in case, and pairs of words a typo apart. A word is a duplicate
+// at most once. Typos are only guessed between words not both in the list of
+// levels, and the word added or looked up less, or added later, is the typo.
+func (s *Store) FindDuplicates(ctx context.Context) ([]Duplicate, error) {
+	words, err := s.Queries().Listword(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(words, func(i, j int) bool { return words[i].Word < words[j].Word })
+	exists := make(map[string]bool, len(words))
+	for _, word := range words {
+		exists[word.Word] = true
+	}
+
+	var duplicates []Duplicate
+	// words found to be duplicates, and the words they are merged into,
+	// which aren't duplicates themselves so merges don't chain
+	found := make(map[string]bool)
+	targets := make(map[string]bool)
+	add := func(word, into, reason string) {
+		if !found[word] && !found[into] && !targets[word] {
+			duplicates = append(duplicates, Duplicate{Word: word, Into: into, Reason: reason})
+			found[word] = true
+			targets[into] = true
+		}
+	}
+	for _, word := range words {
+		if lower := strings.ToLower(word.Word); lower != word.Word && exists[lower] {
+			add(word.Word, lower, DuplicateCase)
+			continue
+		}
+		if !IsEnglish() || Level(word.Word) != "" || irregular()[word.Word] == word.Word {
+			continue
+		}
+		for _, c := range lemmaCandidates(word.Word) {
+			if c != word.Word && exists[c] {
+				add(word.Word, c, DuplicateForm)
+				break
+			}
+		}
+	}
+
+	for i, a := range words {
+		if found[a.Word] || len(a.Word) < minTypoLength {
+			continue
+		}
+		for _, b := range words[i+1:] {
+			if found[a.Word] {
+				break
+			}
+			if found[b.Word] || len(b.Word) < minTypoLength || (Level(a.Word) != "" && Level(b.Word) != "") {
+				continue
+			}
+			maxDistance := min(len(a.Word), len(b.Word)) / 5
+			if abs(len(a.Word)-len(b.Word)) > maxDistance {
+				continue
+			}
+			if !transposed(a.Word, b.Word) && editDistance(a.Word, b.Word) > maxDistance {
+				continue
+			}
+			typo, into := b, a
+			if typoOf(a, b) {
+				typo, into = a, b
+			}
+			add(typo.Word, into.Word, DuplicateTypo)
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool { return duplicates[i].Word < duplicates[j].Word })
+	return duplicates, nil
+}
+
+// reports whether a rather than b is the misspelling of a pair of words
+func typoOf(a, b worddb.Word) bool {
+	if (Level(a.Word) != "") != (Level(b.Word) != "") {
+		return Level(a.Word) == ""
+	}
+	countA := a.AddedCount.Int64 + a.LookupCount.Int64
+	countB := b.AddedCount.Int64 + b.LookupCount.Int64
+	if countA != countB {
+		return countA < countB
+	}
+	return a.CreatedAt.Time.After(b.CreatedAt.Time)
+}
+
+// reports whether a and b differ by two adjacent letters swapped, like
+// recieve and receive
+func transposed(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	return i+1 < len(a) && a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// MergeWords folds the word of each duplicate into the word Into, and moves
+// it to the trash, in one transaction. The counts are summed, the
+// translation, note and context of Into are kept unless they are empty, and
+// the tags, sources, sentences and variants of the word are added to Into,
+// with the word as a variant of Into. Reviews and quiz results stay with the
+// word. A word merged into a word merged before goes where that word went.
+// Returns ErrNotFound if a word isn't in the store. It's journaled as
+// "cleanup", undo reverts the counts and texts of the words and restores
+// the merged ones from the trash.
+func (s *Store) MergeWords(ctx context.Context, merges []Duplicate) error {
+	return s.Tx(ctx, func(queries *worddb.Queries) error {
+		journal, err := StartJournal(ctx, queries, "cleanup")
+		if err != nil {
+			return err
+		}
+		// words merged already by the word they are merged into, a word
+		// merged into one of them is merged into that word instead
+		merged := make(map[string]string)
+		for _, merge := range merges {
+			into := merge.Into
+			for merged[into] != "" {
+				into = merged[into]
+			}
+			if into == merge.Word || merged[merge.Word] != "" {
+				continue
+			}
+			if err := mergeWord(ctx, queries, journal, merge.Word, into); err != nil {
+				return fmt.Errorf("merge '%s' into '%s': %w", merge.Word, into, err)
+			}
+			merged[merge.Word] = into
+		}
+		return nil
+	})
+}
+
+func mergeWord(ctx context.Context, queries *worddb.Queries, journal *Journal, word, into string) error {
+	from, err := queries.GetWord(ctx, word)
+	if err == nil {
+		_, err = queries.GetWord(ctx, into)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	for _, w := range []string{word, into} {
+		if err := journal.Record(ctx, w); err != nil {
+			return err
+		}
+	}
+
+	err = queries.MergeWord(ctx, worddb.MergeWordParams{
+		Word:        into,
+		ZhTrans:     from.ZhTrans,
+		AddedCount:  from.AddedCount,
+		LookupCount: from.LookupCount,
+		CreatedAt:   from.CreatedAt,
+		UpdatedAt:   from.UpdatedAt,
+		Note:        from.Note,
+		Context:     from.Context,
+		DeckID:      from.DeckID,
+		Level:       from.Level,
+	})
+	if err != nil {
+		return err
+	}
+	tags, err := queries.ListWordTags(ctx)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, tag := range tags {
+		if tag.Word == word {
+			names = append(names, tag.Name)
+		}
+	}
+	if err := TagWord(ctx, queries, into, names); err != nil {
+		return err
+	}
+	sources, err := queries.ListWordSources(ctx, word)
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if err := queries.AddWordSource(ctx, worddb.AddWordSourceParams{Word: into, Source: source}); err != nil {
+			return err
+		}
+	}
+	sentences, err := queries.ListSentences(ctx, word)
+	if err != nil {
+		return err
+	}
+	for _, sentence := range sentences {
+		if _, err := queries.AddSentence(ctx, worddb.AddSentenceParams{Word: into, Sentence: sentence.Sentence, Source: sentence.Source}); err != nil {
+			return err
+		}
+	}
+	variants, err := queries.ListVariants(ctx, word)
+	if err != nil {
+		return err
+	}
+	for _, variant := range append(variants, word) {
+		if variant == into {
+			continue
+		}
+		if err := queries.AddVariant(ctx, worddb.AddVariantParams{Variant: variant, Word: into}); err != nil {
+			return err
+		}
+	}
+	_, err = queries.TrashWord(ctx, word)
+	return err
+}
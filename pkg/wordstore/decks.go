@@ -0,0 +1,100 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// ErrDeckNotFound is returned when a deck doesn't exist.
+var ErrDeckNotFound = errors.New("deck not found")
+
+// DefaultDeckID is the deck of words added without a deck, it always exists
+// and can be renamed but not merged away.
+const DefaultDeckID = 1
+
+// deck id of new words, 0 for the default deck
+func deckOrDefault(deckID int64) int64 {
+	if deckID == 0 {
+		return DefaultDeckID
+	}
+	return deckID
+}
+
+// Decks returns all decks with the number of their words, the default deck
+// first.
+func (s *Store) Decks(ctx context.Context) ([]worddb.ListDecksRow, error) {
+	return s.Queries().ListDecks(ctx)
+}
+
+// DeckID returns the id of the deck named name, or ErrDeckNotFound.
+func (s *Store) DeckID(ctx context.Context, name string) (int64, error) {
+	id, err := s.Queries().GetDeckID(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", ErrDeckNotFound, name)
+	}
+	return id, err
+}
+
+// CreateDeck creates an empty deck, and returns its id.
+func (s *Store) CreateDeck(ctx context.Context, name string) (int64, error) {
+	if name == "" {
+		return 0, errors.New("deck name is empty")
+	}
+	if _, err := s.DeckID(ctx, name); err == nil {
+		return 0, fmt.Errorf("deck %s already exists", name)
+	}
+	return s.Queries().CreateDeck(ctx, name)
+}
+
+// RenameDeck renames deck name to newName, or returns ErrDeckNotFound.
+func (s *Store) RenameDeck(ctx context.Context, name, newName string) error {
+	if newName == "" {
+		return errors.New("deck name is empty")
+	}
+	n, err := s.Queries().RenameDeck(ctx, worddb.RenameDeckParams{NewName: newName, Name: name})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrDeckNotFound, name)
+	}
+	return nil
+}
+
+// MergeDeck moves all words of deck from into deck into, deletes deck from,
+// and returns the number of words moved.
+func (s *Store) MergeDeck(ctx context.Context, from, into string) (int64, error) {
+	var moved int64
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		fromID, err := queries.GetDeckID(ctx, from)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrDeckNotFound, from)
+		}
+		if err != nil {
+			return err
+		}
+		intoID, err := queries.GetDeckID(ctx, into)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrDeckNotFound, into)
+		}
+		if err != nil {
+			return err
+		}
+		if fromID == intoID {
+			return errors.New("can't merge a deck into itself")
+		}
+		if fromID == DefaultDeckID {
+			return errors.New("can't merge the default deck away, merge other decks into it or rename it")
+		}
+
+		if moved, err = queries.MoveDeckWords(ctx, worddb.MoveDeckWordsParams{ToDeckID: intoID, FromDeckID: fromID}); err != nil {
+			return err
+		}
+		return queries.DeleteDeck(ctx, fromID)
+	})
+	return moved, err
+}
@@ -0,0 +1,141 @@
+package wordstore
+
+import (
+	"context"
+	"fmt"
+)
+
+// rows left behind by deleting words or tags outside of the store, or by
+// syncing a half written database file, by table name
+var orphanConditions = []struct {
+	table     string
+	condition string
+}{
+	{"review", "word NOT IN (SELECT word FROM word)"},
+	{"sentence", "word NOT IN (SELECT word FROM word)"},
+	{"quiz_stat", "word NOT IN (SELECT word FROM word)"},
+	{"enrichment", "word NOT IN (SELECT word FROM word)"},
+	{"related_word", "word NOT IN (SELECT word FROM word)"},
+	{"word_variant", "word NOT IN (SELECT word FROM word)"},
+	{"translation", "word NOT IN (SELECT word FROM word)"},
+	{"word_source", "word NOT IN (SELECT word FROM word)"},
+	{"word_mastery", "word NOT IN (SELECT word FROM word)"},
+	{"review_log", "word NOT IN (SELECT word FROM word)"},
+	{"word_tag", "word NOT IN (SELECT word FROM word) OR tag_id NOT IN (SELECT id FROM tag)"},
+	{"journal_word", "journal_id NOT IN (SELECT id FROM journal)"},
+}
+
+// Orphans are the numbers of orphaned rows by table.
+type Orphans map[string]int64
+
+// Total returns the number of orphaned rows in all tables.
+func (o Orphans) Total() int64 {
+	var total int64
+	for _, n := range o {
+		total += n
+	}
+	return total
+}
+
+// CheckIntegrity runs PRAGMA integrity_check, and returns the problems found,
+// none if the database is fine.
+func (s *Store) CheckIntegrity(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	return problems, rows.Err()
+}
+
+// Orphans counts rows attached to words or tags which don't exist.
+func (s *Store) Orphans(ctx context.Context) (Orphans, error) {
+	orphans := make(Orphans)
+	for _, o := range orphanConditions {
+		var n int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", o.table, o.condition)
+		if err := s.db.QueryRowContext(ctx, query).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			orphans[o.table] = n
+		}
+	}
+	return orphans, nil
+}
+
+// DeleteOrphans deletes the rows counted by Orphans, and returns how many
+// are deleted.
+func (s *Store) DeleteOrphans(ctx context.Context) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var deleted int64
+	for _, o := range orphanConditions {
+		result, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", o.table, o.condition))
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		deleted += n
+	}
+	return deleted, tx.Commit()
+}
+
+// Vacuum rebuilds the database file, reclaiming the space of deleted rows.
+func (s *Store) Vacuum(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+// FileInfo describes the database file.
+type FileInfo struct {
+	PageSize      int64
+	PageCount     int64
+	FreePages     int64
+	JournalMode   string
+	SchemaVersion int
+}
+
+// Size returns the size of the database in bytes, without the WAL file.
+func (f FileInfo) Size() int64 {
+	return f.PageSize * f.PageCount
+}
+
+// FileInfo returns the page usage and settings of the database file.
+func (s *Store) FileInfo(ctx context.Context) (FileInfo, error) {
+	var info FileInfo
+	pragmas := []struct {
+		name string
+		dest any
+	}{
+		{"page_size", &info.PageSize},
+		{"page_count", &info.PageCount},
+		{"freelist_count", &info.FreePages},
+		{"journal_mode", &info.JournalMode},
+		{"user_version", &info.SchemaVersion},
+	}
+	for _, p := range pragmas {
+		if err := s.db.QueryRowContext(ctx, "PRAGMA "+p.name).Scan(p.dest); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,122 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// ErrNothingToUndo is returned by Undo when the journal is empty.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrEmptyTrashUndo is returned by Undo when the most recent operation
+// emptied the trash, whose words are deleted for good with their reviews,
+// tags and sentences. The next Undo reverts the operation before it.
+var ErrEmptyTrashUndo = errors.New("can't undo emptying the trash, the words are deleted for good")
+
+// journal op of EmptyTrash, only kept so Undo refuses it
+const emptyTrashOp = "empty-trash"
+
+// number of recent operations kept in the journal
+const journalSize = 50
+
+// Journal records the previous state of the words changed by an operation,
+// so the operation can be reverted by Undo.
+type Journal struct {
+	queries *worddb.Queries
+	id      int64
+}
+
+// StartJournal starts journaling operation op, like "add" or "del", in the
+// transaction of queries. Call Record before changing each word.
+func StartJournal(ctx context.Context, queries *worddb.Queries, op string) (*Journal, error) {
+	id, err := queries.CreateJournal(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	if err := queries.PruneJournal(ctx, journalSize); err != nil {
+		return nil, err
+	}
+	if err := queries.PruneJournalWords(ctx); err != nil {
+		return nil, err
+	}
+	return &Journal{queries: queries, id: id}, nil
+}
+
+// Record saves the current state of word, including whether it exists.
+func (j *Journal) Record(ctx context.Context, word string) error {
+	return j.queries.JournalWord(ctx, worddb.JournalWordParams{JournalID: j.id, Word: word})
+}
+
+// Undo reverts the most recent operation in the journal, and returns its
+// name and the words changed, or ErrNothingToUndo. Words created by the
+// operation are deleted permanently, other words get their previous
+// translation, counts, note, context, deletion state and deck back, unless
+// the deck has been deleted since. Emptying the trash isn't reverted, Undo
+// drops it from the journal and returns ErrEmptyTrashUndo.
+func (s *Store) Undo(ctx context.Context) (string, []string, error) {
+	var op string
+	var words []string
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		journal, err := queries.GetLastJournal(ctx)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNothingToUndo
+		}
+		if err != nil {
+			return err
+		}
+		op = journal.Op
+		if op == emptyTrashOp {
+			return queries.DeleteJournal(ctx, journal.ID)
+		}
+
+		// the latest change first, in case a word changed more than once
+		rows, err := queries.ListJournalWords(ctx, journal.ID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := revert(ctx, queries, row); err != nil {
+				return err
+			}
+			words = append(words, row.Word)
+		}
+
+		if err := queries.DeleteJournalWords(ctx, journal.ID); err != nil {
+			return err
+		}
+		return queries.DeleteJournal(ctx, journal.ID)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if op == emptyTrashOp {
+		return "", nil, ErrEmptyTrashUndo
+	}
+	return op, words, nil
+}
+
+// put a word back to the state saved in the journal
+func revert(ctx context.Context, queries *worddb.Queries, row worddb.JournalWord) error {
+	if !row.Existed {
+		if _, err := queries.DeleteWord(ctx, row.Word); err != nil {
+			return err
+		}
+		return deleteAttached(ctx, queries, row.Word)
+	}
+	return queries.RevertWord(ctx, worddb.RevertWordParams{
+		Word:        row.Word,
+		ZhTrans:     row.ZhTrans,
+		AddedCount:  row.AddedCount,
+		LookupCount: row.LookupCount,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+		Note:        row.Note,
+		Context:     row.Context,
+		DeletedAt:   row.DeletedAt,
+		Level:       nullString(Level(row.Word)),
+		DeckID:      row.DeckID,
+	})
+}
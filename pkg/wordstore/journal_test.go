@@ -0,0 +1,103 @@
+package wordstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndoRestoresDeck(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "words.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	gre, err := s.CreateDeck(ctx, "GRE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateDeck(ctx, "TOEFL"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(ctx, []string{"abate"}, AddOptions{DeckID: gre}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a deleted word comes back in its deck
+	if err := s.Delete(ctx, "abate"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Undo(ctx); err != nil {
+		t.Fatal(err)
+	}
+	word, err := s.Get(ctx, "abate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word.DeckID != gre {
+		t.Errorf("deck after undo = %d, want %d", word.DeckID, gre)
+	}
+
+	// a word whose deck was merged away stays in the deck it was merged into
+	if err := s.SetTranslation(ctx, "abate", "减轻"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.MergeDeck(ctx, "GRE", "TOEFL"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Undo(ctx); err != nil {
+		t.Fatal(err)
+	}
+	toefl, err := s.DeckID(ctx, "TOEFL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if word, err = s.Get(ctx, "abate"); err != nil {
+		t.Fatal(err)
+	}
+	if word.DeckID != toefl {
+		t.Errorf("deck after undo = %d, want %d", word.DeckID, toefl)
+	}
+}
+
+func TestUndoEmptyTrash(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "words.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.Add(ctx, []string{"abate"}, AddOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "abate"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.EmptyTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := s.Undo(ctx); !errors.Is(err, ErrEmptyTrashUndo) {
+		t.Fatalf("undo empty-trash: err = %v, want %v", err, ErrEmptyTrashUndo)
+	}
+	// the del and add before it no longer bring the word back
+	for _, op := range []string{"del", "add"} {
+		got, words, err := s.Undo(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != op || len(words) != 0 {
+			t.Errorf("undo = %s %v, want %s of no words", got, words, op)
+		}
+	}
+	if _, err := s.Get(ctx, "abate"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get after undo: err = %v, want %v", err, ErrNotFound)
+	}
+	if _, _, err := s.Undo(ctx); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("undo: err = %v, want %v", err, ErrNothingToUndo)
+	}
+}
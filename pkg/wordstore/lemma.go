@@ -0,0 +1,88 @@
+package wordstore
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed lemmas.txt
+var lemmasData string
+
+// lemma by irregular form like go of went, and the lemmas themselves, parsed
+// from lemmas.txt on first use
+var irregular = sync.OnceValue(func() map[string]string {
+	m := make(map[string]string)
+	for _, line := range strings.Split(lemmasData, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, form := range fields {
+			m[form] = fields[0]
+		}
+	}
+	return m
+})
+
+// suffixes of regular inflections and what replaces them, tried in order, so
+// used is use rather than us
+var suffixRules = []struct{ suffix, replace string }{
+	{"ies", "y"},
+	{"s", ""},
+	{"es", ""},
+	{"ied", "y"},
+	{"ed", "e"},
+	{"ed", ""},
+	{"ing", "e"},
+	{"ing", ""},
+}
+
+// Lemma returns the dictionary form of word, like run of running, ran or
+// runs. Words in the store or the list of levels are already lemmas, and
+// inflected forms only count if their lemma is such a word, so news stays
+// news and unknown words stay as they are, like all words if Language isn't
+// English.
+func (s *Store) Lemma(ctx context.Context, word string) (string, error) {
+	if !IsEnglish() {
+		return word, nil
+	}
+	known := func(w string) (bool, error) {
+		if Level(w) != "" || irregular()[w] == w {
+			return true, nil
+		}
+		return s.Exists(ctx, w)
+	}
+
+	if ok, err := known(word); ok || err != nil {
+		return word, err
+	}
+	for _, c := range lemmaCandidates(word) {
+		if ok, err := known(c); ok || err != nil {
+			return c, err
+		}
+	}
+	return word, nil
+}
+
+// possible lemmas of word in the order they're tried, the lemma of an
+// irregular form, or the stems of regular inflections
+func lemmaCandidates(word string) []string {
+	if lemma, ok := irregular()[word]; ok {
+		return []string{lemma}
+	}
+	var candidates []string
+	for _, rule := range suffixRules {
+		stem, ok := strings.CutSuffix(word, rule.suffix)
+		if !ok || len(stem) < 2 {
+			continue
+		}
+		candidates = append(candidates, stem+rule.replace)
+		// stopped and running double the last consonant
+		if n := len(stem); rule.replace == "" && n >= 3 && stem[n-1] == stem[n-2] {
+			candidates = append(candidates, stem[:n-1])
+		}
+	}
+	return candidates
+}
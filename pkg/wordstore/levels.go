@@ -0,0 +1,74 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed levels.txt
+var levelsData string
+
+// Levels are the CEFR levels of words from the easiest, words not in the
+// list of levels.txt have none.
+var Levels = []string{"A1", "A2", "B1", "B2", "C1"}
+
+// level by word, parsed from levels.txt on first use
+var levels = sync.OnceValue(func() map[string]string {
+	m := make(map[string]string)
+	level := ""
+	for _, line := range strings.Split(levelsData, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			level = line[1 : len(line)-1]
+		default:
+			for _, word := range strings.Fields(line) {
+				m[word] = level
+			}
+		}
+	}
+	return m
+})
+
+// Level returns the CEFR level of word like B1, or "" if it's not in the
+// list or Language isn't English.
+func Level(word string) string {
+	if !IsEnglish() {
+		return ""
+	}
+	return levels()[word]
+}
+
+// set the level of the words added before levels
+func fillLevels(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT word FROM word WHERE level IS NULL")
+	if err != nil {
+		return err
+	}
+	var words []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			rows.Close()
+			return err
+		}
+		words = append(words, word)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, word := range words {
+		if level := Level(word); level != "" {
+			if _, err := tx.ExecContext(ctx, "UPDATE word SET level = ? WHERE word = ?", level, word); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
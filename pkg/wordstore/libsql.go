@@ -0,0 +1,11 @@
+//go:build libsql
+
+package wordstore
+
+// remote libSQL databases over http or websockets, not in the default build,
+// since most users keep the database in a local file
+import _ "github.com/tursodatabase/libsql-client-go/libsql"
+
+func init() {
+	remoteDriver = "libsql"
+}
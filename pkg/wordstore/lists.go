@@ -0,0 +1,107 @@
+package wordstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// Word lists of words which aren't tracked: words known already, like the
+// most common words, and words to ignore, like names. A word is on one list
+// at most. Listed words are skipped when scanning texts and left out of
+// quizzes, they needn't be in the store. The lists aren't journaled, merged
+// or synced.
+const (
+	KnownList  = "known"
+	IgnoreList = "ignore"
+)
+
+// WordLists are the names of the word lists.
+var WordLists = []string{KnownList, IgnoreList}
+
+func checkWordList(list string) error {
+	if !slices.Contains(WordLists, list) {
+		return fmt.Errorf("unknown word list %q, available: %s", list, strings.Join(WordLists, "|"))
+	}
+	return nil
+}
+
+// ListedWords returns the words on all word lists, with the list of each.
+func (s *Store) ListedWords(ctx context.Context) (map[string]string, error) {
+	rows, err := s.Queries().ListListedWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]string, len(rows))
+	for _, row := range rows {
+		listed[row.Word] = row.List
+	}
+	return listed, nil
+}
+
+// ListWords returns the words on list in alphabetical order.
+func (s *Store) ListWords(ctx context.Context, list string) ([]string, error) {
+	if err := checkWordList(list); err != nil {
+		return nil, err
+	}
+	rows, err := s.Queries().ListListedWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var words []string
+	for _, row := range rows {
+		if row.List == list {
+			words = append(words, row.Word)
+		}
+	}
+	return words, nil
+}
+
+// AddListedWords adds words to list in one transaction, moving them from the
+// other list, and returns the number of words added. Invalid words and words
+// on list already are skipped.
+func (s *Store) AddListedWords(ctx context.Context, list string, words []string) (int, error) {
+	if err := checkWordList(list); err != nil {
+		return 0, err
+	}
+	added := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		added = 0
+		for _, word := range words {
+			if !IsValidWord(word) {
+				continue
+			}
+			n, err := queries.AddListedWord(ctx, worddb.AddListedWordParams{Word: word, List: list})
+			if err != nil {
+				return err
+			}
+			added += int(n)
+		}
+		return nil
+	})
+	return added, err
+}
+
+// RemoveListedWords removes words from list in one transaction, and returns
+// the number of words removed.
+func (s *Store) RemoveListedWords(ctx context.Context, list string, words []string) (int, error) {
+	if err := checkWordList(list); err != nil {
+		return 0, err
+	}
+	removed := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		removed = 0
+		for _, word := range words {
+			n, err := queries.DeleteListedWord(ctx, worddb.DeleteListedWordParams{Word: word, List: list})
+			if err != nil {
+				return err
+			}
+			removed += int(n)
+		}
+		return nil
+	})
+	return removed, err
+}
@@ -0,0 +1,109 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// Mastery states of words, updated by reviews: new words aren't reviewed
+// yet, learning words are reviewed at short intervals, known words at
+// intervals of weeks, and mastered words are reviewed successfully many
+// times in a row and archived.
+const (
+	MasteryNew      = "new"
+	MasteryLearning = "learning"
+	MasteryKnown    = "known"
+	MasteryMastered = "mastered"
+)
+
+// MasteryStates are the mastery states from new to mastered.
+var MasteryStates = []string{MasteryNew, MasteryLearning, MasteryKnown, MasteryMastered}
+
+// shortest review interval of known words
+const knownIntervalDays = 21
+
+// Mastery returns the mastery state of a word reviewed next in intervalDays,
+// after repetitions successful reviews in a row. A word is mastered after
+// archiveAfter successful reviews in a row, never if it's 0.
+func Mastery(intervalDays, repetitions int64, archiveAfter int) string {
+	switch {
+	case archiveAfter > 0 && repetitions >= int64(archiveAfter):
+		return MasteryMastered
+	case repetitions > 0 && intervalDays >= knownIntervalDays:
+		return MasteryKnown
+	default:
+		return MasteryLearning
+	}
+}
+
+// UpdateMastery sets the mastery state of word in the transaction of
+// queries, after a review. Mastered words are archived, archived words stay
+// archived even if they are reviewed and forgotten.
+func UpdateMastery(ctx context.Context, queries *worddb.Queries, word, state string) error {
+	var archivedAt sql.NullTime
+	if state == MasteryMastered {
+		archivedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+	return queries.UpsertMastery(ctx, worddb.UpsertMasteryParams{Word: word, State: state, ArchivedAt: archivedAt})
+}
+
+// WordMastery returns the mastery state of word and whether it's archived.
+func (s *Store) WordMastery(ctx context.Context, word string) (string, bool, error) {
+	mastery, err := s.Queries().GetMastery(ctx, word)
+	if errors.Is(err, sql.ErrNoRows) {
+		return MasteryNew, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return mastery.State, mastery.ArchivedAt.Valid, nil
+}
+
+// Archive archives words in one transaction, so they are left out of lists,
+// reviews and quizzes, and returns the number of words archived. Words
+// archived already are skipped, and ErrNotFound is returned if a word isn't
+// in the store or is in the trash.
+func (s *Store) Archive(ctx context.Context, words []string) (int, error) {
+	archived := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		archived = 0
+		for _, word := range words {
+			if _, err := queries.GetWord(ctx, word); errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrNotFound, word)
+			} else if err != nil {
+				return err
+			}
+			n, err := queries.ArchiveWord(ctx, word)
+			if err != nil {
+				return err
+			}
+			archived += int(n)
+		}
+		return nil
+	})
+	return archived, err
+}
+
+// Unarchive brings archived words back to lists, reviews and quizzes in one
+// transaction, and returns the number of words unarchived. Their mastery
+// state is kept.
+func (s *Store) Unarchive(ctx context.Context, words []string) (int, error) {
+	unarchived := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		unarchived = 0
+		for _, word := range words {
+			n, err := queries.UnarchiveWord(ctx, word)
+			if err != nil {
+				return err
+			}
+			unarchived += int(n)
+		}
+		return nil
+	})
+	return unarchived, err
+}
@@ -0,0 +1,157 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// MergeResult counts the words merged from another database.
+type MergeResult struct {
+	// words which were not in the store
+	Added int
+	// words in both databases
+	Merged int
+	// invalid words which are left out
+	Skipped int
+}
+
+// Merge combines the words of the w2r database at path into the store, for
+// words collected on two machines. Counts are summed and the translation,
+// note and context of the store are kept unless they are empty, tags,
+// sources and sentences are added. New words go to deck deckID, or the default deck if
+// it's 0. Words in the trash of the other database are left out.
+func (s *Store) Merge(ctx context.Context, path string, deckID int64) (MergeResult, error) {
+	var result MergeResult
+	info, err := os.Stat(path)
+	if err != nil {
+		return result, err
+	}
+	if same, err := s.isFile(ctx, info); err != nil {
+		return result, err
+	} else if same {
+		return result, errors.New("can't merge a database into itself")
+	}
+
+	db, err := sql.Open("sqlite3", FileURI(path, "mode=ro"))
+	if err != nil {
+		return result, err
+	}
+	defer db.Close()
+	other := New(db)
+
+	// the queries below need the columns of the current schema
+	version, err := other.SchemaVersion(ctx)
+	if err != nil {
+		return result, fmt.Errorf("read %s: %w", path, err)
+	}
+	switch {
+	case version == 0:
+		return result, fmt.Errorf("%s is not a w2r database", path)
+	case version < len(migrations):
+		return result, fmt.Errorf("%s is from an older version, upgrade it with 'w2r --db %s init' first", path, path)
+	case version > len(migrations):
+		return result, fmt.Errorf("%s is from a newer version of w2r", path)
+	}
+
+	words, err := other.Queries().Listword(ctx, 0)
+	if err != nil {
+		return result, err
+	}
+	tags, err := other.WordTags(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	err = s.Tx(ctx, func(queries *worddb.Queries) error {
+		journal, err := StartJournal(ctx, queries, "merge")
+		if err != nil {
+			return err
+		}
+		for _, word := range words {
+			if !IsValidWord(word.Word) {
+				result.Skipped++
+				continue
+			}
+			if err := journal.Record(ctx, word.Word); err != nil {
+				return err
+			}
+			count, err := queries.CountWord(ctx, word.Word)
+			if err != nil {
+				return err
+			}
+			if count == 0 {
+				result.Added++
+			} else {
+				result.Merged++
+			}
+
+			err = queries.MergeWord(ctx, worddb.MergeWordParams{
+				Word:        word.Word,
+				ZhTrans:     word.ZhTrans,
+				AddedCount:  word.AddedCount,
+				LookupCount: word.LookupCount,
+				CreatedAt:   word.CreatedAt,
+				UpdatedAt:   word.UpdatedAt,
+				Note:        word.Note,
+				Context:     word.Context,
+				DeckID:      deckOrDefault(deckID),
+				Level:       nullString(Level(word.Word)),
+			})
+			if err != nil {
+				return err
+			}
+			if err := TagWord(ctx, queries, word.Word, tags[word.Word]); err != nil {
+				return err
+			}
+			sources, err := other.WordSources(ctx, word.Word)
+			if err != nil {
+				return err
+			}
+			for _, source := range sources {
+				if err := queries.AddWordSource(ctx, worddb.AddWordSourceParams{Word: word.Word, Source: source}); err != nil {
+					return err
+				}
+			}
+
+			sentences, err := other.Queries().ListSentences(ctx, word.Word)
+			if err != nil {
+				return err
+			}
+			for _, sentence := range sentences {
+				_, err := queries.AddSentence(ctx, worddb.AddSentenceParams{Word: word.Word, Sentence: sentence.Sentence, Source: sentence.Source})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// reports whether the main database of the store is the file of info
+func (s *Store) isFile(ctx context.Context, info os.FileInfo) (bool, error) {
+	if s.remote {
+		return false, nil
+	}
+	var seq int
+	var name, file string
+	err := s.db.QueryRowContext(ctx, "SELECT seq, name, file FROM pragma_database_list WHERE name = 'main'").Scan(&seq, &name, &file)
+	if err != nil {
+		return false, err
+	}
+	// empty for in-memory databases
+	if file == "" {
+		return false, nil
+	}
+	mainInfo, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(info, mainInfo), nil
+}
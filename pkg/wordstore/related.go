@@ -0,0 +1,39 @@
+package wordstore
+
+import (
+	"context"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// Kinds of related words.
+const (
+	Synonym = "synonym"
+	Antonym = "antonym"
+)
+
+// Related returns the synonyms and antonyms of word, synonyms first and the
+// most related first, with whether each is in the store.
+func (s *Store) Related(ctx context.Context, word string) ([]worddb.ListRelatedWordsRow, error) {
+	return s.Queries().ListRelatedWords(ctx, word)
+}
+
+// SetRelated replaces the related words of word in one transaction, or
+// returns ErrNotFound if word isn't in the store or is in the trash.
+func (s *Store) SetRelated(ctx context.Context, word string, related []worddb.AddRelatedWordParams) error {
+	if _, err := s.Get(ctx, word); err != nil {
+		return err
+	}
+	return s.Tx(ctx, func(queries *worddb.Queries) error {
+		if err := queries.DeleteRelatedWords(ctx, word); err != nil {
+			return err
+		}
+		for _, r := range related {
+			r.Word = word
+			if err := queries.AddRelatedWord(ctx, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
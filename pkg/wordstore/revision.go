@@ -0,0 +1,18 @@
+package wordstore
+
+import (
+	"context"
+	"time"
+)
+
+// Revision returns the revision of the data and the time of its last change.
+// The revision is counted up by triggers on every change to words, their
+// reviews, tags, sentences and other data, by any process using the
+// database, so it tells whether anything changed since it was read.
+func (s *Store) Revision(ctx context.Context) (int64, time.Time, error) {
+	row, err := s.Queries().GetDataRevision(ctx)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return row.Revision, row.UpdatedAt, nil
+}
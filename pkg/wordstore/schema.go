@@ -0,0 +1,292 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// schema migrations, migrations[i] upgrades the database from version i to
+// i+1, the version is stored in PRAGMA user_version. Only append to this list,
+// and keep schema.sql in sync for sqlc.
+var migrations = []string{
+	// 1: word table, may already exist in databases created before versioning
+	`CREATE TABLE IF NOT EXISTS word (
+        word TEXT PRIMARY KEY,
+        zh_trans TEXT,
+        added_count INTEGER,
+        lookup_count INTEGER
+    );`,
+	// 2: spaced-repetition state
+	`CREATE TABLE IF NOT EXISTS review (
+        word TEXT PRIMARY KEY,
+        ease REAL NOT NULL DEFAULT 2.5,
+        interval_days INTEGER NOT NULL DEFAULT 0,
+        repetitions INTEGER NOT NULL DEFAULT 0,
+        due_at DATETIME NOT NULL
+    );`,
+	// 3: usage sentences
+	`CREATE TABLE IF NOT EXISTS sentence (
+        id INTEGER PRIMARY KEY,
+        word TEXT NOT NULL,
+        sentence TEXT NOT NULL,
+        source TEXT,
+        UNIQUE (word, sentence)
+    );`,
+	// 4: timestamps of word, unknown for words added before
+	`ALTER TABLE word ADD COLUMN created_at DATETIME;
+    ALTER TABLE word ADD COLUMN updated_at DATETIME;`,
+	// 5: tags, many-to-many with words
+	`CREATE TABLE IF NOT EXISTS tag (
+        id INTEGER PRIMARY KEY,
+        name TEXT NOT NULL UNIQUE COLLATE NOCASE
+    );
+    CREATE TABLE IF NOT EXISTS word_tag (
+        word TEXT NOT NULL,
+        tag_id INTEGER NOT NULL,
+        PRIMARY KEY (word, tag_id)
+    );`,
+	// 6: personal note and the sentence where the word was seen
+	`ALTER TABLE word ADD COLUMN note TEXT;
+    ALTER TABLE word ADD COLUMN context TEXT;`,
+	// 7: per-word quiz accuracy
+	`CREATE TABLE IF NOT EXISTS quiz_stat (
+        word TEXT PRIMARY KEY,
+        attempts INTEGER NOT NULL DEFAULT 0,
+        correct INTEGER NOT NULL DEFAULT 0
+    );`,
+	// 8: soft delete, deleted words stay in the trash until emptied
+	`ALTER TABLE word ADD COLUMN deleted_at DATETIME;`,
+	// 9: journal of recent operations with the previous state of words, for undo
+	`CREATE TABLE IF NOT EXISTS journal (
+        id INTEGER PRIMARY KEY,
+        op TEXT NOT NULL,
+        created_at DATETIME NOT NULL
+    );
+    CREATE TABLE IF NOT EXISTS journal_word (
+        id INTEGER PRIMARY KEY,
+        journal_id INTEGER NOT NULL,
+        word TEXT NOT NULL,
+        existed BOOLEAN NOT NULL,
+        zh_trans TEXT,
+        added_count INTEGER,
+        lookup_count INTEGER,
+        created_at DATETIME,
+        updated_at DATETIME,
+        note TEXT,
+        context TEXT,
+        deleted_at DATETIME
+    );`,
+	// 10: decks, the existing words are in the default deck, and the decks of
+	// journaled words, so undo puts words back in their deck
+	`CREATE TABLE IF NOT EXISTS deck (
+        id INTEGER PRIMARY KEY,
+        name TEXT NOT NULL UNIQUE COLLATE NOCASE
+    );
+    INSERT OR IGNORE INTO deck (id, name) VALUES (1, 'default');
+    ALTER TABLE word ADD COLUMN deck_id INTEGER NOT NULL DEFAULT 1;
+    ALTER TABLE journal_word ADD COLUMN deck_id INTEGER;`,
+	// 11: sync cursors of remote servers, in local and remote clock
+	`CREATE TABLE IF NOT EXISTS sync_state (
+        remote TEXT PRIMARY KEY,
+        local_synced_at DATETIME NOT NULL,
+        remote_synced_at DATETIME NOT NULL
+    );`,
+	// 12: reviews per local day, for streaks and daily goals
+	`CREATE TABLE IF NOT EXISTS daily_activity (
+        day TEXT PRIMARY KEY,
+        reviews INTEGER NOT NULL DEFAULT 0
+    );`,
+	// 13: definitions, mnemonics and example sentences generated by an llm,
+	// example sentences are one per line
+	`CREATE TABLE IF NOT EXISTS enrichment (
+        word TEXT PRIMARY KEY,
+        definition TEXT NOT NULL,
+        mnemonic TEXT NOT NULL,
+        examples TEXT NOT NULL,
+        model TEXT NOT NULL,
+        created_at DATETIME NOT NULL
+    );`,
+	// 14: ipa pronunciations
+	`ALTER TABLE word ADD COLUMN phonetic TEXT;`,
+	// 15: synonyms and antonyms, kind is synonym or antonym, a higher score
+	// is more related
+	`CREATE TABLE IF NOT EXISTS related_word (
+        word TEXT NOT NULL,
+        related TEXT NOT NULL,
+        kind TEXT NOT NULL,
+        score INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (word, kind, related)
+    );`,
+	// 16: cefr level like B1, filled by fillLevels
+	`ALTER TABLE word ADD COLUMN level TEXT;`,
+	// 17: inflected forms of words added as their lemma, like running of run
+	`CREATE TABLE IF NOT EXISTS word_variant (
+        variant TEXT PRIMARY KEY,
+        word TEXT NOT NULL
+    );`,
+	// 18: translations of words into other languages than zh_trans, by
+	// language code like en or ja
+	`CREATE TABLE IF NOT EXISTS translation (
+        word TEXT NOT NULL,
+        lang TEXT NOT NULL,
+        trans TEXT NOT NULL,
+        updated_at DATETIME NOT NULL,
+        PRIMARY KEY (word, lang)
+    );`,
+	// 19: words of the known and ignore lists, list is known or ignore,
+	// skipped by scan, coverage and quizzes
+	`CREATE TABLE IF NOT EXISTS word_list (
+        word TEXT PRIMARY KEY,
+        list TEXT NOT NULL,
+        added_at DATETIME NOT NULL
+    );`,
+	// 20: sources words come from, like a book or a url, a word may have
+	// several
+	`CREATE TABLE IF NOT EXISTS word_source (
+        word TEXT NOT NULL,
+        source TEXT NOT NULL,
+        added_at DATETIME NOT NULL,
+        PRIMARY KEY (word, source)
+    );`,
+	// 21: mastery state of words updated by reviews, and archived words, the
+	// state of reviewed words is guessed from their interval
+	`CREATE TABLE IF NOT EXISTS word_mastery (
+        word TEXT PRIMARY KEY,
+        state TEXT NOT NULL,
+        archived_at DATETIME,
+        updated_at DATETIME NOT NULL
+    );
+    INSERT OR IGNORE INTO word_mastery (word, state, updated_at)
+    SELECT word, CASE WHEN interval_days >= 21 THEN 'known' ELSE 'learning' END, CURRENT_TIMESTAMP
+    FROM review;`,
+	// 22: log of reviews, with the interval since the previous review, for
+	// retention analysis
+	`CREATE TABLE IF NOT EXISTS review_log (
+        id INTEGER PRIMARY KEY,
+        word TEXT NOT NULL,
+        grade INTEGER NOT NULL,
+        latency_ms INTEGER,
+        interval_days INTEGER NOT NULL,
+        next_interval_days INTEGER NOT NULL,
+        reviewed_at DATETIME NOT NULL
+    );`,
+	// 23: revision of the data, counted up on every change, for caching and
+	// live updates of web pages
+	`CREATE TABLE IF NOT EXISTS data_revision (
+        id INTEGER PRIMARY KEY CHECK (id = 1),
+        revision INTEGER NOT NULL,
+        updated_at DATETIME NOT NULL
+    );
+    INSERT OR IGNORE INTO data_revision (id, revision, updated_at) VALUES (1, 0, CURRENT_TIMESTAMP);` +
+		revisionTriggers("word", "review", "sentence", "tag", "word_tag", "quiz_stat", "deck", "daily_activity",
+			"enrichment", "related_word", "word_variant", "word_list", "word_source", "word_mastery", "review_log", "translation"),
+	// 24: users of a shared server, each with a database of their own, and
+	// their api tokens, of which only hashes are kept
+	`CREATE TABLE IF NOT EXISTS user (
+        id INTEGER PRIMARY KEY,
+        name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+        password_hash TEXT NOT NULL,
+        db_path TEXT NOT NULL,
+        created_at DATETIME NOT NULL
+    );
+    CREATE TABLE IF NOT EXISTS user_token (
+        token_hash TEXT PRIMARY KEY,
+        user_id INTEGER NOT NULL,
+        created_at DATETIME NOT NULL
+    );` + revisionTriggers("user", "user_token"),
+	// 25: read-only shares of words by a link, of a deck, a tag or chosen
+	// words
+	`CREATE TABLE IF NOT EXISTS share (
+        token TEXT PRIMARY KEY,
+        title TEXT NOT NULL,
+        deck_id INTEGER NOT NULL DEFAULT 0,
+        tag TEXT NOT NULL DEFAULT '',
+        words TEXT NOT NULL DEFAULT '',
+        created_at DATETIME NOT NULL,
+        expires_at DATETIME
+    );` + revisionTriggers("share"),
+	// 26: owners of shares of users of a shared server, so share links find
+	// the database of their user
+	`CREATE TABLE IF NOT EXISTS user_share (
+        token TEXT PRIMARY KEY,
+        user_id INTEGER NOT NULL,
+        created_at DATETIME NOT NULL
+    );` + revisionTriggers("user_share"),
+	// 27: data revision the full-text index of search was rebuilt at, it's
+	// only rebuilt when the words changed since
+	`CREATE TABLE IF NOT EXISTS search_index (
+        id INTEGER PRIMARY KEY CHECK (id = 1),
+        revision INTEGER NOT NULL,
+        rebuilt_at DATETIME NOT NULL
+    );`,
+}
+
+// triggers counting up the data revision on every insert, update and delete
+// of tables, tables added later need them too
+func revisionTriggers(tables ...string) string {
+	var b strings.Builder
+	for _, table := range tables {
+		for _, op := range []string{"INSERT", "UPDATE", "DELETE"} {
+			fmt.Fprintf(&b, `
+    CREATE TRIGGER IF NOT EXISTS %s_revision_%s AFTER %s ON %s BEGIN
+        UPDATE data_revision SET revision = revision + 1, updated_at = CURRENT_TIMESTAMP WHERE id = 1;
+    END;`, table, strings.ToLower(op), op, table)
+		}
+	}
+	return b.String()
+}
+
+// steps run in the transaction after the migration to the same version, for
+// data which can't be migrated in sql
+var dataMigrations = map[int]func(context.Context, *sql.Tx) error{
+	16: fillLevels,
+}
+
+// SchemaVersion returns the schema version of the database.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// Migrate upgrades the database schema to the latest version, and returns the
+// number of migrations applied.
+func (s *Store) Migrate(ctx context.Context) (int, error) {
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if version > len(migrations) {
+		return 0, fmt.Errorf("database schema version %d is newer than supported version %d, please upgrade w2r", version, len(migrations))
+	}
+
+	applied := 0
+	for ; version < len(migrations); version++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return applied, err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version]); err != nil {
+			tx.Rollback()
+			return applied, fmt.Errorf("migrate schema to version %d: %w", version+1, err)
+		}
+		if migrate := dataMigrations[version+1]; migrate != nil {
+			if err := migrate(ctx, tx); err != nil {
+				tx.Rollback()
+				return applied, fmt.Errorf("migrate data to version %d: %w", version+1, err)
+			}
+		}
+		// PRAGMA doesn't accept bound parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return applied, err
+		}
+		if err := tx.Commit(); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
@@ -0,0 +1,45 @@
+package wordstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// ErrSentenceNotFound is returned when a sentence doesn't exist.
+var ErrSentenceNotFound = errors.New("sentence not found")
+
+// Sentences returns the example sentences of word, in the order they were
+// added.
+func (s *Store) Sentences(ctx context.Context, word string) ([]worddb.Sentence, error) {
+	return s.Queries().ListSentences(ctx, word)
+}
+
+// AddSentence adds an example sentence of word, source tells where it's from
+// like a book title and may be empty. It reports false if word already has
+// the sentence, or ErrNotFound if word isn't in the store or is in the trash.
+func (s *Store) AddSentence(ctx context.Context, word, sentence, source string) (bool, error) {
+	sentence = strings.Join(strings.Fields(sentence), " ")
+	if sentence == "" {
+		return false, errors.New("empty sentence")
+	}
+	if _, err := s.Get(ctx, word); err != nil {
+		return false, err
+	}
+	n, err := s.Queries().AddSentence(ctx, worddb.AddSentenceParams{Word: word, Sentence: sentence, Source: nullString(source)})
+	return n > 0, err
+}
+
+// DeleteSentence deletes the sentence of id, or returns ErrSentenceNotFound.
+func (s *Store) DeleteSentence(ctx context.Context, id int64) error {
+	n, err := s.Queries().DeleteSentence(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSentenceNotFound
+	}
+	return nil
+}
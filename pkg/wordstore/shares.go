@@ -0,0 +1,100 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// ErrShareNotFound is returned when a share doesn't exist or has expired.
+var ErrShareNotFound = errors.New("share not found")
+
+// ShareOptions selects the words of a share, all words by default.
+type ShareOptions struct {
+	// shown above the words
+	Title string
+	// only words in this deck, 0 for all decks
+	DeckID int64
+	// only words with this tag
+	Tag string
+	// only these words, of the deck and tag if set
+	Words []string
+	// the share is gone after then, never if zero
+	Expires time.Time
+}
+
+// CreateShare creates a read-only share of the words selected by opts, and
+// returns its token, which is all it takes to see the words.
+func (s *Store) CreateShare(ctx context.Context, opts ShareOptions) (string, error) {
+	token, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	err = s.Queries().CreateShare(ctx, worddb.CreateShareParams{
+		Token:     token,
+		Title:     opts.Title,
+		DeckID:    opts.DeckID,
+		Tag:       opts.Tag,
+		Words:     strings.Join(opts.Words, ","),
+		ExpiresAt: sql.NullTime{Time: opts.Expires.UTC(), Valid: !opts.Expires.IsZero()},
+	})
+	return token, err
+}
+
+// Shares returns all shares, expired ones too, the latest first.
+func (s *Store) Shares(ctx context.Context) ([]worddb.Share, error) {
+	return s.Queries().ListShares(ctx)
+}
+
+// Share returns the share of token, or ErrShareNotFound if there's none or
+// it has expired.
+func (s *Store) Share(ctx context.Context, token string) (worddb.Share, error) {
+	share, err := s.Queries().GetShare(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && share.ExpiresAt.Valid && time.Now().After(share.ExpiresAt.Time) {
+		return share, ErrShareNotFound
+	}
+	return share, err
+}
+
+// DeleteShare deletes the share of token, or returns ErrShareNotFound.
+func (s *Store) DeleteShare(ctx context.Context, token string) error {
+	n, err := s.Queries().DeleteShare(ctx, token)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+// SharedWords returns the words of a share in alphabetical order, archived
+// ones too, words deleted since it was created are left out.
+func (s *Store) SharedWords(ctx context.Context, share worddb.Share) ([]worddb.Word, error) {
+	opts := ListOptions{Tag: share.Tag, DeckID: share.DeckID}
+	words, err := s.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.Archived = true
+	archived, err := s.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	words = append(words, archived...)
+	if share.Words != "" {
+		chosen := strings.Split(share.Words, ",")
+		words = slices.DeleteFunc(words, func(word worddb.Word) bool {
+			return !slices.Contains(chosen, word.Word)
+		})
+	}
+	slices.SortFunc(words, func(a, b worddb.Word) int {
+		return strings.Compare(a.Word, b.Word)
+	})
+	return words, nil
+}
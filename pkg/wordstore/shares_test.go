@@ -0,0 +1,99 @@
+package wordstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestShare(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "words.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	gre, err := s.CreateDeck(ctx, "GRE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adds := []struct {
+		words []string
+		opts  AddOptions
+	}{
+		{[]string{"abate", "dally"}, AddOptions{DeckID: gre, Tags: []string{"unit3"}}},
+		{[]string{"bask"}, AddOptions{DeckID: gre}},
+		{[]string{"cede"}, AddOptions{Tags: []string{"unit3"}}},
+	}
+	for _, add := range adds {
+		if _, err := s.Add(ctx, add.words, add.opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// words in the trash aren't shared
+	if err := s.Delete(ctx, "dally"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    ShareOptions
+		want    []string
+		wantErr error
+	}{
+		{"all", ShareOptions{}, []string{"abate", "bask", "cede"}, nil},
+		{"deck", ShareOptions{DeckID: gre}, []string{"abate", "bask"}, nil},
+		{"tag", ShareOptions{Tag: "unit3"}, []string{"abate", "cede"}, nil},
+		{"deck and tag", ShareOptions{DeckID: gre, Tag: "unit3"}, []string{"abate"}, nil},
+		{"chosen words", ShareOptions{Words: []string{"cede", "abate", "dally"}}, []string{"abate", "cede"}, nil},
+		{"chosen words of deck", ShareOptions{DeckID: gre, Words: []string{"cede", "abate"}}, []string{"abate"}, nil},
+		{"not expired", ShareOptions{Expires: time.Now().Add(time.Hour)}, []string{"abate", "bask", "cede"}, nil},
+		{"expired", ShareOptions{Expires: time.Now().Add(-time.Second)}, nil, ErrShareNotFound},
+	}
+	for _, tt := range tests {
+		token, err := s.CreateShare(ctx, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, err := s.Share(ctx, token)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		words, err := s.SharedWords(ctx, share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, word := range words {
+			got = append(got, word.Word)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: words = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// a deleted share is gone at once, like an unknown token
+	token, err := s.CreateShare(ctx, ShareOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteShare(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{token, "0123456789abcdef"} {
+		if _, err := s.Share(ctx, token); !errors.Is(err, ErrShareNotFound) {
+			t.Errorf("Share(%q) = %v, want %v", token, err, ErrShareNotFound)
+		}
+	}
+	if err := s.DeleteShare(ctx, token); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("DeleteShare of a deleted share = %v, want %v", err, ErrShareNotFound)
+	}
+}
@@ -0,0 +1,157 @@
+// Package wordstore is an embeddable store of words to learn, English by
+// default, backed by a sqlite database, which is shared with the w2r command.
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/notsobad/w2r/worddb"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrNotFound is returned when a word is not in the store.
+var ErrNotFound = errors.New("word not found")
+
+// ErrInvalidWord is returned when adding something other than a lower case
+// word or phrase of the language, see IsValidWord.
+var ErrInvalidWord = errors.New("invalid word")
+
+// ErrRemote is returned by operations which need a local database file, like
+// backups, when the store is a remote libSQL database.
+var ErrRemote = errors.New("not supported by a remote database")
+
+// Language is the code of the language of the words, like en, de or ja. Set
+// it before opening a store, English words are ascii letters, and levels and
+// lemmas are only known of English words.
+var Language = "en"
+
+// IsEnglish reports whether Language is English.
+func IsEnglish() bool {
+	return Language == "" || Language == "en"
+}
+
+// words of letters joined by hyphens or apostrophes, like well-being or
+// o'clock, and phrases of such words separated by single spaces
+var wordPattern = regexp.MustCompile(`^[a-z]+(?:['-][a-z]+)*(?: [a-z]+(?:['-][a-z]+)*)*$`)
+
+// the same of letters of any script with their accents, like über, café or 猫
+var unicodeWordPattern = regexp.MustCompile(`^[\p{L}\p{M}]+(?:['-][\p{L}\p{M}]+)*(?: [\p{L}\p{M}]+(?:['-][\p{L}\p{M}]+)*)*$`)
+
+// IsValidWord reports whether s is a lower case word or phrase of Language,
+// as normalized by NormalizeWord, like apple, well-being, o'clock or give up
+// in English, or straße and à la carte in other languages.
+func IsValidWord(s string) bool {
+	if IsEnglish() {
+		return wordPattern.MatchString(s)
+	}
+	return unicodeWordPattern.MatchString(s)
+}
+
+// typographic apostrophes and hyphens, as typed on phones or copied from
+// ebooks
+var wordReplacer = strings.NewReplacer(
+	"\u2019", "'", "\u2018", "'", "\u02bc", "'",
+	"\u2010", "-", "\u2011", "-",
+)
+
+// NormalizeWord lower cases s, replaces typographic apostrophes and hyphens
+// with ascii ones and collapses white space, so "Give  Up" and "O’Clock"
+// become give up and o'clock. Accents are composed, so an é typed as e and a
+// combining accent is the same word as é. The result may still be invalid.
+func NormalizeWord(s string) string {
+	s = norm.NFC.String(wordReplacer.Replace(strings.ToLower(s)))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// WAL lets readers run while the web server or another command writes, busy
+// timeout waits for the lock instead of failing with "database is locked",
+// and immediate transactions take the write lock upfront, so they never fail
+// halfway when upgrading from a read lock
+const dbOptions = "_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+
+// sqlite decodes file: uris, so these would start the query or fragment of
+// the uri, or decode into another path
+var uriEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// FileURI returns the file: uri opening the sqlite file at path with params,
+// like "mode=ro", escaping the characters of path sqlite would take for the
+// query or fragment of the uri.
+func FileURI(path, params string) string {
+	uri := "file:" + uriEscaper.Replace(path)
+	if params != "" {
+		uri += "?" + params
+	}
+	return uri
+}
+
+// driver of remote databases, registered by the libsql build tag
+var remoteDriver string
+
+// IsRemote reports whether path is the url of a remote libSQL database, like
+// libsql://words-user.turso.io.
+func IsRemote(path string) bool {
+	return strings.HasPrefix(path, "libsql://")
+}
+
+// Store is a word database.
+type Store struct {
+	db     *sql.DB
+	remote bool
+}
+
+// Open opens the sqlite database at path, creating it if it doesn't exist,
+// and upgrades its schema. A libsql:// url opens a remote libSQL database,
+// like Turso, if w2r is built with the libsql tag, the auth token is passed
+// as the authToken parameter of the url.
+func Open(ctx context.Context, path string) (*Store, error) {
+	var db *sql.DB
+	var err error
+	// the queries are written for sqlite, a postgres dsn would otherwise
+	// become a sqlite file named like it
+	if strings.HasPrefix(path, "postgres://") || strings.HasPrefix(path, "postgresql://") {
+		return nil, errors.New("PostgreSQL is not supported, use a sqlite file, or a libsql:// database shared by several machines")
+	}
+	if IsRemote(path) {
+		if remoteDriver == "" {
+			return nil, errors.New("remote databases need w2r built with -tags libsql")
+		}
+		db, err = sql.Open(remoteDriver, path)
+	} else {
+		db, err = sql.Open("sqlite3", FileURI(path, dbOptions))
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := New(db)
+	s.remote = IsRemote(path)
+	if _, err := s.Migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// New returns a store using db, the schema must be up to date, see Migrate.
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// DB returns the underlying database.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Queries returns the generated queries of the database.
+func (s *Store) Queries() *worddb.Queries {
+	return worddb.New(s.db)
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
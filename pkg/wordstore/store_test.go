@@ -0,0 +1,98 @@
+package wordstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsValidWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"apple", true},
+		{"well-being", true},
+		{"o'clock", true},
+		{"give up", true},
+		{"mother-in-law", true},
+		{"rock 'n' roll", false},
+		{"", false},
+		{"-well", false},
+		{"well-", false},
+		{"'tis", false},
+		{"well--being", false},
+		{"o''clock", false},
+		{"well-'being", false},
+		{"give  up", false},
+		{" give up", false},
+		{"give up ", false},
+		{"mp3", false},
+		{"42", false},
+		{"Apple", false},
+		{"café", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidWord(tt.word); got != tt.want {
+			t.Errorf("IsValidWord(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"apple", "apple"},
+		{"Apple", "apple"},
+		{"O’Clock", "o'clock"},
+		{"o‘clock", "o'clock"},
+		{"o\u02bcclock", "o'clock"},
+		{"well\u2010being", "well-being"},
+		{"well\u2011being", "well-being"},
+		{"cafe\u0301", "caf\u00e9"},
+		{"Give  Up", "give up"},
+		{"  give\tup\n", "give up"},
+		{"", ""},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeWord(tt.word); got != tt.want {
+			t.Errorf("NormalizeWord(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	tests := []struct {
+		path   string
+		params string
+		want   string
+	}{
+		{"/home/alice/word.sqlite", "", "file:/home/alice/word.sqlite"},
+		{"word.sqlite", "mode=ro", "file:word.sqlite?mode=ro"},
+		{"/tmp/what?.sqlite", "mode=ro", "file:/tmp/what%3f.sqlite?mode=ro"},
+		{"/tmp/#1.sqlite", "", "file:/tmp/%231.sqlite"},
+		{"/tmp/100%.sqlite", "", "file:/tmp/100%25.sqlite"},
+		{"/tmp/a%3f.sqlite", "", "file:/tmp/a%253f.sqlite"},
+	}
+	for _, tt := range tests {
+		if got := FileURI(tt.path, tt.params); got != tt.want {
+			t.Errorf("FileURI(%q, %q) = %q, want %q", tt.path, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestOpenEscapedPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words?#100%.sqlite")
+	s, err := Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database not at %s: %v", path, err)
+	}
+}
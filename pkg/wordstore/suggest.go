@@ -0,0 +1,76 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+)
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Suggest returns at most n words in the store close to word in spelling,
+// the closest first, for "did you mean" hints. The lemma of word comes first
+// if word was added as one of its variants.
+func (s *Store) Suggest(ctx context.Context, word string, n int) ([]string, error) {
+	queries := s.Queries()
+	words, err := queries.ListWordNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// allow one typo in short words, and more in longer ones
+	maxDistance := 1 + len(word)/4
+
+	type candidate struct {
+		word     string
+		distance int
+	}
+	candidates := make([]candidate, 0)
+	for _, w := range words {
+		if d := editDistance(word, w); d > 0 && d <= maxDistance {
+			candidates = append(candidates, candidate{w, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].word < candidates[j].word
+	})
+
+	results := make([]string, 0, n)
+	lemma, err := queries.GetVariantWord(ctx, word)
+	if err == nil {
+		results = append(results, lemma)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	for _, c := range candidates {
+		if len(results) == n {
+			break
+		}
+		if c.word != lemma {
+			results = append(results, c.word)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,173 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// SyncWord is a word exchanged by sync. Words in the trash are sent too, so
+// deletions propagate, and the deck is sent by name since deck ids differ
+// between databases.
+type SyncWord struct {
+	Word        string     `json:"word"`
+	ZhTrans     string     `json:"zh_trans,omitempty"`
+	AddedCount  int64      `json:"added_count"`
+	LookupCount int64      `json:"lookup_count"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	Context     string     `json:"context,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Deck        string     `json:"deck,omitempty"`
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// Changes returns the words updated at or after since, or all words if since
+// is zero. Timestamps have a precision of seconds, so words updated in the
+// second of since are returned again.
+func (s *Store) Changes(ctx context.Context, since time.Time) ([]SyncWord, error) {
+	words, err := s.Queries().ListChangedWords(ctx, sql.NullTime{Time: since.UTC(), Valid: !since.IsZero()})
+	if err != nil {
+		return nil, err
+	}
+	decks, err := s.Decks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deckNames := make(map[int64]string, len(decks))
+	for _, deck := range decks {
+		deckNames[deck.ID] = deck.Name
+	}
+
+	changes := make([]SyncWord, len(words))
+	for i, word := range words {
+		changes[i] = SyncWord{
+			Word:        word.Word,
+			ZhTrans:     word.ZhTrans.String,
+			AddedCount:  word.AddedCount.Int64,
+			LookupCount: word.LookupCount.Int64,
+			CreatedAt:   timePtr(word.CreatedAt),
+			UpdatedAt:   timePtr(word.UpdatedAt),
+			Note:        word.Note.String,
+			Context:     word.Context.String,
+			DeletedAt:   timePtr(word.DeletedAt),
+			Deck:        deckNames[word.DeckID],
+		}
+	}
+	return changes, nil
+}
+
+// ApplyChanges stores the words received by sync which are newer than the
+// local ones, the last update of a word wins. Missing decks are created. It
+// returns the number of words changed.
+func (s *Store) ApplyChanges(ctx context.Context, words []SyncWord) (int, error) {
+	applied := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		journal, err := StartJournal(ctx, queries, "sync")
+		if err != nil {
+			return err
+		}
+		deckIDs := make(map[string]int64)
+		for _, word := range words {
+			if !IsValidWord(word.Word) {
+				continue
+			}
+			updatedAt, err := queries.GetWordUpdatedAt(ctx, word.Word)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+			case err != nil:
+				return err
+			// unknown update time of words added by old versions, keep it
+			case word.UpdatedAt == nil:
+				continue
+			case updatedAt.Valid && !word.UpdatedAt.After(updatedAt.Time):
+				continue
+			}
+
+			deckID, err := syncDeckID(ctx, queries, deckIDs, word.Deck)
+			if err != nil {
+				return err
+			}
+			if err := journal.Record(ctx, word.Word); err != nil {
+				return err
+			}
+			err = queries.SyncWord(ctx, worddb.SyncWordParams{
+				Word:        word.Word,
+				ZhTrans:     nullString(word.ZhTrans),
+				AddedCount:  sql.NullInt64{Int64: word.AddedCount, Valid: true},
+				LookupCount: sql.NullInt64{Int64: word.LookupCount, Valid: true},
+				CreatedAt:   nullTime(word.CreatedAt),
+				UpdatedAt:   nullTime(word.UpdatedAt),
+				Note:        nullString(word.Note),
+				Context:     nullString(word.Context),
+				DeletedAt:   nullTime(word.DeletedAt),
+				DeckID:      deckID,
+				Level:       nullString(Level(word.Word)),
+			})
+			if err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// id of the deck named name, created if it doesn't exist, ids are cached in
+// deckIDs
+func syncDeckID(ctx context.Context, queries *worddb.Queries, deckIDs map[string]int64, name string) (int64, error) {
+	if name == "" {
+		return DefaultDeckID, nil
+	}
+	if id, ok := deckIDs[name]; ok {
+		return id, nil
+	}
+	id, err := queries.GetDeckID(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		id, err = queries.CreateDeck(ctx, name)
+	}
+	if err != nil {
+		return 0, err
+	}
+	deckIDs[name] = id
+	return id, nil
+}
+
+// SyncState returns the time of the last sync with remote in the local and
+// the remote clock, zero if it was never synced.
+func (s *Store) SyncState(ctx context.Context, remote string) (local, remoteTime time.Time, err error) {
+	state, err := s.Queries().GetSyncState(ctx, remote)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, time.Time{}, nil
+	}
+	return state.LocalSyncedAt, state.RemoteSyncedAt, err
+}
+
+// SetSyncState saves the time of a sync with remote in the local and the
+// remote clock.
+func (s *Store) SetSyncState(ctx context.Context, remote string, local, remoteTime time.Time) error {
+	return s.Queries().SetSyncState(ctx, worddb.SetSyncStateParams{
+		Remote:         remote,
+		LocalSyncedAt:  local.UTC(),
+		RemoteSyncedAt: remoteTime.UTC(),
+	})
+}
@@ -0,0 +1,75 @@
+package wordstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApplyChanges(t *testing.T) {
+	older := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	local := &SyncWord{Word: "abate", ZhTrans: "减轻", AddedCount: 1, CreatedAt: &older, UpdatedAt: &older}
+
+	tests := []struct {
+		name        string
+		local       *SyncWord
+		remote      SyncWord
+		wantApplied int
+		wantTrans   string
+		wantDeck    string
+		wantDeleted bool
+	}{
+		{"new word", nil, SyncWord{Word: "abate", ZhTrans: "减少", UpdatedAt: &older}, 1, "减少", "default", false},
+		{"newer remote", local, SyncWord{Word: "abate", ZhTrans: "减少", UpdatedAt: &newer}, 1, "减少", "default", false},
+		{"older remote", &SyncWord{Word: "abate", ZhTrans: "减轻", UpdatedAt: &newer}, SyncWord{Word: "abate", ZhTrans: "减少", UpdatedAt: &older}, 0, "减轻", "default", false},
+		{"same time", local, SyncWord{Word: "abate", ZhTrans: "减少", UpdatedAt: &older}, 0, "减轻", "default", false},
+		{"unknown update time", local, SyncWord{Word: "abate", ZhTrans: "减少"}, 0, "减轻", "default", false},
+		{"deleted remotely", local, SyncWord{Word: "abate", ZhTrans: "减轻", UpdatedAt: &newer, DeletedAt: &newer}, 1, "减轻", "default", true},
+		{"missing deck", local, SyncWord{Word: "abate", ZhTrans: "减轻", UpdatedAt: &newer, Deck: "GRE"}, 1, "减轻", "GRE", false},
+		{"invalid word", nil, SyncWord{Word: "Abate", ZhTrans: "减少", UpdatedAt: &newer}, 0, "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := Open(ctx, filepath.Join(t.TempDir(), "words.sqlite"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if tt.local != nil {
+				if _, err := s.ApplyChanges(ctx, []SyncWord{*tt.local}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			applied, err := s.ApplyChanges(ctx, []SyncWord{tt.remote})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("applied = %d, want %d", applied, tt.wantApplied)
+			}
+
+			changes, err := s.Changes(ctx, time.Time{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantTrans == "" {
+				if len(changes) != 0 {
+					t.Errorf("words = %+v, want none", changes)
+				}
+				return
+			}
+			if len(changes) != 1 {
+				t.Fatalf("words = %+v, want abate", changes)
+			}
+			got := changes[0]
+			if got.ZhTrans != tt.wantTrans || got.Deck != tt.wantDeck || (got.DeletedAt != nil) != tt.wantDeleted {
+				t.Errorf("abate = %q in %q, deleted %v, want %q in %q, deleted %v",
+					got.ZhTrans, got.Deck, got.DeletedAt != nil, tt.wantTrans, tt.wantDeck, tt.wantDeleted)
+			}
+		})
+	}
+}
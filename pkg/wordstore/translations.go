@@ -0,0 +1,80 @@
+package wordstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// Translations returns the translations of word into other languages than
+// the default one of its zh_trans, by language code like fr or zh-tw. Language
+// codes are case insensitive and stored lower case. These translations aren't
+// journaled, merged or synced.
+func (s *Store) Translations(ctx context.Context, word string) (map[string]string, error) {
+	rows, err := s.Queries().ListTranslations(ctx, word)
+	if err != nil {
+		return nil, err
+	}
+	translations := make(map[string]string, len(rows))
+	for _, row := range rows {
+		translations[row.Lang] = row.Trans
+	}
+	return translations, nil
+}
+
+// LangTranslations returns the translations of all words into lang, by word.
+func (s *Store) LangTranslations(ctx context.Context, lang string) (map[string]string, error) {
+	rows, err := s.Queries().ListTranslationsByLang(ctx, strings.ToLower(lang))
+	if err != nil {
+		return nil, err
+	}
+	translations := make(map[string]string, len(rows))
+	for _, row := range rows {
+		translations[row.Word] = row.Trans
+	}
+	return translations, nil
+}
+
+// SetLangTranslation sets the translation of word into lang, empty trans
+// clears it, or returns ErrNotFound if word isn't in the store or is in the
+// trash.
+func (s *Store) SetLangTranslation(ctx context.Context, lang, word, trans string) error {
+	if _, err := s.Get(ctx, word); err != nil {
+		return err
+	}
+	_, err := s.SetLangTranslations(ctx, lang, map[string]string{word: trans})
+	return err
+}
+
+// SetLangTranslations sets the translations of words into lang in one
+// transaction, words not in the store are skipped. It returns the number of
+// words set.
+func (s *Store) SetLangTranslations(ctx context.Context, lang string, translations map[string]string) (int, error) {
+	lang = strings.ToLower(lang)
+	words := make([]string, 0, len(translations))
+	for word := range translations {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	set := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		set = 0
+		for _, word := range words {
+			var n int64
+			var err error
+			if trans := strings.TrimSpace(translations[word]); trans != "" {
+				n, err = queries.SetLangTranslation(ctx, worddb.SetLangTranslationParams{Lang: lang, Trans: trans, Word: word})
+			} else {
+				n, err = queries.DeleteLangTranslation(ctx, worddb.DeleteLangTranslationParams{Word: word, Lang: lang})
+			}
+			if err != nil {
+				return err
+			}
+			set += int(n)
+		}
+		return nil
+	})
+	return set, err
+}
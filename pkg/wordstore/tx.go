@@ -0,0 +1,76 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// transaction that prepares every query once and reuses the statement, for
+// bulk writes which run the same few queries many times. Statements are
+// closed with the transaction.
+type stmtCache struct {
+	tx    *sql.Tx
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func newStmtCache(tx *sql.Tx) *stmtCache {
+	return &stmtCache{tx: tx, stmts: make(map[string]*sql.Stmt)}
+}
+
+func (c *stmtCache) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (c *stmtCache) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+func (c *stmtCache) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+func (c *stmtCache) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	stmt, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		// let Scan report the error
+		return c.tx.QueryRowContext(ctx, query, args...)
+	}
+	return stmt.QueryRowContext(ctx, args...)
+}
+
+// Tx runs fn in a transaction, which is committed if fn returns nil. Queries
+// in the transaction are prepared once and reused, so it's fast for bulk
+// writes.
+func (s *Store) Tx(ctx context.Context, fn func(queries *worddb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(worddb.New(newStmtCache(tx))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,182 @@
+package wordstore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/notsobad/w2r/worddb"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUserNotFound is returned when a user doesn't exist, or no user has a
+// token.
+var ErrUserNotFound = errors.New("user not found")
+
+// names of users, which name their database files by default
+var userPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// shortest password of users
+const minPasswordLength = 8
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must have at least %d characters", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// hex of n random bytes
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// tokens are random, so a fast hash is enough to keep them out of the
+// database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckPassword reports whether password is the password of user. It's slow
+// on purpose, callers checking the password of every request should
+// remember the passwords which matched.
+func CheckPassword(user worddb.User, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+// Users returns all users with the number of their tokens, by name.
+func (s *Store) Users(ctx context.Context) ([]worddb.ListUsersRow, error) {
+	return s.Queries().ListUsers(ctx)
+}
+
+// User returns the user named name, or ErrUserNotFound.
+func (s *Store) User(ctx context.Context, name string) (worddb.User, error) {
+	user, err := s.Queries().GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, fmt.Errorf("%w: %s", ErrUserNotFound, name)
+	}
+	return user, err
+}
+
+// UserByToken returns the user of an api token, or ErrUserNotFound.
+func (s *Store) UserByToken(ctx context.Context, token string) (worddb.User, error) {
+	user, err := s.Queries().GetUserByToken(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// AddUser adds a user with password, whose words are in the database at
+// dbPath, and returns the id of the user. Names are letters, digits, dots,
+// hyphens and underscores, and unique regardless of case.
+func (s *Store) AddUser(ctx context.Context, name, password, dbPath string) (int64, error) {
+	if !userPattern.MatchString(name) {
+		return 0, fmt.Errorf("invalid user name %q, use letters, digits, '.', '-' and '_'", name)
+	}
+	if dbPath == "" {
+		return 0, errors.New("database path of the user is empty")
+	}
+	if _, err := s.User(ctx, name); err == nil {
+		return 0, fmt.Errorf("user %s already exists", name)
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+	return s.Queries().CreateUser(ctx, worddb.CreateUserParams{Name: name, PasswordHash: hash, DbPath: dbPath})
+}
+
+// SetPassword changes the password of a user, or returns ErrUserNotFound.
+func (s *Store) SetPassword(ctx context.Context, name, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	n, err := s.Queries().SetUserPassword(ctx, worddb.SetUserPasswordParams{PasswordHash: hash, Name: name})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, name)
+	}
+	return nil
+}
+
+// NewToken creates an api token of a user and returns it, it can't be
+// shown again, as only its hash is kept. A user may have several tokens,
+// like one for each device.
+func (s *Store) NewToken(ctx context.Context, name string) (string, error) {
+	user, err := s.User(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	token, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	err = s.Queries().CreateUserToken(ctx, worddb.CreateUserTokenParams{TokenHash: hashToken(token), UserID: user.ID})
+	return token, err
+}
+
+// RevokeTokens deletes all tokens of a user, and returns their number.
+func (s *Store) RevokeTokens(ctx context.Context, name string) (int64, error) {
+	user, err := s.User(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return s.Queries().DeleteUserTokens(ctx, user.ID)
+}
+
+// DeleteUser deletes a user, its tokens and the records of its shares, the
+// database of the user is left as it is.
+func (s *Store) DeleteUser(ctx context.Context, name string) error {
+	return s.Tx(ctx, func(queries *worddb.Queries) error {
+		user, err := queries.GetUser(ctx, name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrUserNotFound, name)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := queries.DeleteUserTokens(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := queries.DeleteUserShares(ctx, user.ID); err != nil {
+			return err
+		}
+		return queries.DeleteUser(ctx, user.ID)
+	})
+}
+
+// AddUserShare records that the share of token is in the database of the
+// user with id userID.
+func (s *Store) AddUserShare(ctx context.Context, userID int64, token string) error {
+	return s.Queries().CreateUserShare(ctx, worddb.CreateUserShareParams{Token: token, UserID: userID})
+}
+
+// UserByShare returns the user in whose database the share of token is, or
+// ErrUserNotFound.
+func (s *Store) UserByShare(ctx context.Context, token string) (worddb.User, error) {
+	user, err := s.Queries().GetUserByShare(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
+
+// DeleteUserShare forgets the user of the share of token.
+func (s *Store) DeleteUserShare(ctx context.Context, token string) error {
+	return s.Queries().DeleteUserShare(ctx, token)
+}
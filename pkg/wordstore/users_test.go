@@ -0,0 +1,48 @@
+package wordstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddUser(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "users.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	tests := []struct {
+		name     string
+		password string
+		dbPath   string
+		wantErr  bool
+	}{
+		{"alice", "correct horse", "alice.sqlite", false},
+		{"bob.smith-2", "12345678", "bob.sqlite", false},
+		{"carol", "1234567", "carol.sqlite", true},
+		{"carol", "correct horse", "", true},
+		{"ALICE", "correct horse", "alice2.sqlite", true},
+		{".alice", "correct horse", "alice2.sqlite", true},
+		{"alice/../bob", "correct horse", "alice2.sqlite", true},
+		{"", "correct horse", "nobody.sqlite", true},
+	}
+	for _, tt := range tests {
+		_, err := s.AddUser(ctx, tt.name, tt.password, tt.dbPath)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("AddUser(%q, %q, %q) = %v, want error %v", tt.name, tt.password, tt.dbPath, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			user, err := s.User(ctx, tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !CheckPassword(user, tt.password) || CheckPassword(user, tt.password+"!") {
+				t.Errorf("CheckPassword of %s doesn't match its password only", tt.name)
+			}
+		}
+	}
+}
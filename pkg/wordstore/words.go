@@ -0,0 +1,470 @@
+package wordstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// AddOptions are the optional attributes of added words.
+type AddOptions struct {
+	// translations of new words by word, existing translations are kept
+	Translations map[string]string
+	Tags         []string
+	// deck of new words, 0 for the default deck, existing words stay in
+	// their decks
+	DeckID int64
+	// personal note and the sentence where the words were seen
+	Note    string
+	Context string
+	// ipa pronunciations of new words by word
+	Phonetics map[string]string
+	// inflected forms the words were added as by word, like running of run
+	Variants map[string][]string
+	// where the words are from, like a book title, added to the sources of
+	// existing words too
+	Source string
+}
+
+// AddResult tells whether a word is created, or already exists and its
+// added_count is increased, or failed to add.
+type AddResult struct {
+	Word    string
+	Created bool
+	Err     error
+}
+
+// Add adds words in one transaction, words already in the store get
+// added_count++. A word failed to add doesn't stop the others, check Err of
+// the results, the returned error is only for the transaction itself.
+func (s *Store) Add(ctx context.Context, words []string, opts AddOptions) ([]AddResult, error) {
+	results := make([]AddResult, 0, len(words))
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		journal, err := StartJournal(ctx, queries, "add")
+		if err != nil {
+			return err
+		}
+		for _, word := range words {
+			created, err := addWord(ctx, queries, journal, word, opts)
+			results = append(results, AddResult{Word: word, Created: created, Err: err})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func addWord(ctx context.Context, queries *worddb.Queries, journal *Journal, word string, opts AddOptions) (bool, error) {
+	if !IsValidWord(word) {
+		return false, ErrInvalidWord
+	}
+	if err := journal.Record(ctx, word); err != nil {
+		return false, err
+	}
+	count, err := queries.CountWord(ctx, word)
+	if err != nil {
+		return false, err
+	}
+	if count == 0 {
+		_, err = queries.CreateWord(ctx, worddb.CreateWordParams{
+			Word:    word,
+			ZhTrans: nullString(opts.Translations[word]),
+			DeckID:  deckOrDefault(opts.DeckID),
+			Level:   nullString(Level(word)),
+		})
+		if phonetic := opts.Phonetics[word]; err == nil && phonetic != "" {
+			_, err = queries.UpdatePhonetic(ctx, worddb.UpdatePhoneticParams{Phonetic: nullString(phonetic), Word: word})
+		}
+	} else {
+		err = queries.AddWordCount(ctx, word)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := TagWord(ctx, queries, word, opts.Tags); err != nil {
+		return count == 0, err
+	}
+	for _, variant := range opts.Variants[word] {
+		if err := queries.AddVariant(ctx, worddb.AddVariantParams{Variant: variant, Word: word}); err != nil {
+			return count == 0, err
+		}
+	}
+	if opts.Source != "" {
+		if err := queries.AddWordSource(ctx, worddb.AddWordSourceParams{Word: word, Source: opts.Source}); err != nil {
+			return count == 0, err
+		}
+	}
+	if opts.Note != "" || opts.Context != "" {
+		err := queries.UpdateNote(ctx, worddb.UpdateNoteParams{Note: nullString(opts.Note), Context: nullString(opts.Context), Word: word})
+		if err != nil {
+			return count == 0, err
+		}
+	}
+	return count == 0, nil
+}
+
+// Exists reports whether word is in the store.
+func (s *Store) Exists(ctx context.Context, word string) (bool, error) {
+	count, err := s.Queries().CountWord(ctx, word)
+	return count > 0, err
+}
+
+// Variants returns the inflected forms word was added as.
+func (s *Store) Variants(ctx context.Context, word string) ([]string, error) {
+	return s.Queries().ListVariants(ctx, word)
+}
+
+// WordSources returns where word is from, in the order they were added.
+// Sources aren't journaled or synced.
+func (s *Store) WordSources(ctx context.Context, word string) ([]string, error) {
+	return s.Queries().ListWordSources(ctx, word)
+}
+
+// AddSource records word to be from source, or returns ErrNotFound if word
+// isn't in the store or is in the trash.
+func (s *Store) AddSource(ctx context.Context, word, source string) error {
+	if _, err := s.Get(ctx, word); err != nil {
+		return err
+	}
+	return s.Queries().AddWordSource(ctx, worddb.AddWordSourceParams{Word: word, Source: source})
+}
+
+// Sources returns all sources with the number of their words.
+func (s *Store) Sources(ctx context.Context) ([]worddb.ListSourcesRow, error) {
+	return s.Queries().ListSources(ctx)
+}
+
+// Get returns a word, or ErrNotFound.
+func (s *Store) Get(ctx context.Context, word string) (worddb.Word, error) {
+	result, err := s.Queries().GetWord(ctx, word)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, ErrNotFound
+	}
+	return result, err
+}
+
+// Delete moves word to the trash, or returns ErrNotFound. Trashed words are
+// hidden from everything else until restored, or removed by EmptyTrash.
+func (s *Store) Delete(ctx context.Context, word string) error {
+	return s.journaled(ctx, "del", word, func(queries *worddb.Queries) (int64, error) {
+		return queries.TrashWord(ctx, word)
+	})
+}
+
+// Restore moves word back from the trash, or returns ErrNotFound if it isn't
+// in the trash.
+func (s *Store) Restore(ctx context.Context, word string) error {
+	return s.journaled(ctx, "restore", word, func(queries *worddb.Queries) (int64, error) {
+		return queries.RestoreWord(ctx, word)
+	})
+}
+
+// Trash returns the deleted words, the most recently deleted first.
+func (s *Store) Trash(ctx context.Context) ([]worddb.Word, error) {
+	return s.Queries().ListTrash(ctx)
+}
+
+// EmptyTrash permanently deletes the words in the trash and everything
+// attached to them, and returns how many words are deleted.
+func (s *Store) EmptyTrash(ctx context.Context) (int, error) {
+	deleted := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		words, err := queries.ListTrash(ctx)
+		if err != nil {
+			return err
+		}
+		// undo can't bring back what's attached to the words, so earlier
+		// operations forget them and undo stops at emptying the trash
+		if _, err := StartJournal(ctx, queries, emptyTrashOp); err != nil {
+			return err
+		}
+		for _, word := range words {
+			if _, err := queries.DeleteWord(ctx, word.Word); err != nil {
+				return err
+			}
+			if err := deleteAttached(ctx, queries, word.Word); err != nil {
+				return err
+			}
+			if err := queries.ForgetJournalWord(ctx, word.Word); err != nil {
+				return err
+			}
+		}
+		deleted = len(words)
+		return nil
+	})
+	return deleted, err
+}
+
+// delete everything attached to a permanently deleted word
+func deleteAttached(ctx context.Context, queries *worddb.Queries, word string) error {
+	if err := queries.DeleteReview(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteSentences(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteQuizStat(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteEnrichment(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteRelatedWords(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteVariants(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteTranslations(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteWordSources(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteMastery(ctx, word); err != nil {
+		return err
+	}
+	if err := queries.DeleteReviewLog(ctx, word); err != nil {
+		return err
+	}
+	return queries.DeleteWordTags(ctx, word)
+}
+
+// SetTranslation sets the translation of word, empty zhTrans clears it.
+func (s *Store) SetTranslation(ctx context.Context, word, zhTrans string) error {
+	return s.journaled(ctx, "set-trans", word, func(queries *worddb.Queries) (int64, error) {
+		return queries.UpdateTranslation(ctx, worddb.UpdateTranslationParams{ZhTrans: nullString(zhTrans), Word: word})
+	})
+}
+
+// SetTranslations sets the translations of words in one journaled
+// transaction, words not in the store are skipped. It returns the number of
+// words set.
+func (s *Store) SetTranslations(ctx context.Context, translations map[string]string) (int, error) {
+	words := make([]string, 0, len(translations))
+	for word := range translations {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	set := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		set = 0
+		journal, err := StartJournal(ctx, queries, "translate")
+		if err != nil {
+			return err
+		}
+		for _, word := range words {
+			if err := journal.Record(ctx, word); err != nil {
+				return err
+			}
+			n, err := queries.UpdateTranslation(ctx, worddb.UpdateTranslationParams{ZhTrans: nullString(translations[word]), Word: word})
+			if err != nil {
+				return err
+			}
+			set += int(n)
+		}
+		return nil
+	})
+	return set, err
+}
+
+// SetPhonetics sets the ipa pronunciations of words in one transaction,
+// words not in the store are skipped. It's not journaled, as pronunciations
+// are fetched rather than edited. It returns the number of words set.
+func (s *Store) SetPhonetics(ctx context.Context, phonetics map[string]string) (int, error) {
+	set := 0
+	err := s.Tx(ctx, func(queries *worddb.Queries) error {
+		set = 0
+		for word, phonetic := range phonetics {
+			n, err := queries.UpdatePhonetic(ctx, worddb.UpdatePhoneticParams{Phonetic: nullString(phonetic), Word: word})
+			if err != nil {
+				return err
+			}
+			set += int(n)
+		}
+		return nil
+	})
+	return set, err
+}
+
+// change a single word in a journaled transaction, update returns the number
+// of rows changed, ErrNotFound if none
+func (s *Store) journaled(ctx context.Context, op, word string, update func(*worddb.Queries) (int64, error)) error {
+	return s.Tx(ctx, func(queries *worddb.Queries) error {
+		journal, err := StartJournal(ctx, queries, op)
+		if err != nil {
+			return err
+		}
+		if err := journal.Record(ctx, word); err != nil {
+			return err
+		}
+		n, err := update(queries)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// SortKeys are the valid values of ListOptions.Sort.
+var SortKeys = []string{"added", "lookup", "alpha", "date", "level"}
+
+// ListOptions selects and orders words of List.
+type ListOptions struct {
+	// added, lookup, alpha, date or level, otherwise in database order
+	Sort string
+	// max number of words, 0 for no limit
+	Limit  int
+	Offset int
+	// only words or translations containing this substring
+	Filter string
+	// only words with this tag
+	Tag string
+	// only words in this deck, 0 for all decks
+	DeckID int64
+	// only words of this level of Levels
+	Level string
+	// only words from sources starting with this, like a book title
+	Source string
+	// only archived words, otherwise archived words are left out
+	Archived bool
+	// only words of this mastery state of MasteryStates
+	Mastery string
+}
+
+// escapes the wildcards of LIKE patterns, so filters match them literally,
+// the queries use \ as the escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// List returns words selected by opts.
+func (s *Store) List(ctx context.Context, opts ListOptions) ([]worddb.Word, error) {
+	limit := int64(opts.Limit)
+	if limit <= 0 {
+		limit = -1
+	}
+	return s.Queries().ListWordsSorted(ctx, worddb.ListWordsSortedParams{
+		Filter:   likeEscaper.Replace(opts.Filter),
+		Tag:      opts.Tag,
+		DeckID:   opts.DeckID,
+		Level:    opts.Level,
+		Source:   likeEscaper.Replace(opts.Source),
+		Archived: opts.Archived,
+		Mastery:  opts.Mastery,
+		Sort:     opts.Sort,
+		Limit:    limit,
+		Offset:   int64(opts.Offset),
+	})
+}
+
+// Count returns the number of words selected by the filter, tag, deck, level
+// and source of opts.
+func (s *Store) Count(ctx context.Context, opts ListOptions) (int64, error) {
+	return s.Queries().CountWordsFiltered(ctx, worddb.CountWordsFilteredParams{
+		Filter:   likeEscaper.Replace(opts.Filter),
+		Tag:      opts.Tag,
+		DeckID:   opts.DeckID,
+		Level:    opts.Level,
+		Source:   likeEscaper.Replace(opts.Source),
+		Archived: opts.Archived,
+		Mastery:  opts.Mastery,
+	})
+}
+
+// TagWord adds tags to word, creating the tags if needed.
+func TagWord(ctx context.Context, queries *worddb.Queries, word string, tags []string) error {
+	for _, tag := range tags {
+		id, err := queries.CreateTag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		if err := queries.TagWord(ctx, worddb.TagWordParams{Word: word, TagID: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tag adds tags to word.
+func (s *Store) Tag(ctx context.Context, word string, tags []string) error {
+	return TagWord(ctx, s.Queries(), word, tags)
+}
+
+// WordTags returns the tags of every tagged word.
+func (s *Store) WordTags(ctx context.Context) (map[string][]string, error) {
+	rows, err := s.Queries().ListWordTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string][]string)
+	for _, row := range rows {
+		tags[row.Word] = append(tags[row.Word], row.Name)
+	}
+	return tags, nil
+}
+
+// Stats are totals of the store.
+type Stats struct {
+	Words       int64
+	Translated  int64
+	AddedCount  int64
+	LookupCount int64
+	// words due for review now
+	Due  int64
+	Tags int64
+	// words by level of Levels, "" for words without one
+	Levels map[string]int64
+	// words by mastery state of MasteryStates, and archived words
+	Mastery  map[string]int64
+	Archived int64
+}
+
+// Stats returns totals of the words in a deck, or all decks if deckID is 0,
+// tags are counted in all decks.
+func (s *Store) Stats(ctx context.Context, deckID int64) (Stats, error) {
+	queries := s.Queries()
+	row, err := queries.GetWordStats(ctx, deckID)
+	if err != nil {
+		return Stats{}, err
+	}
+	stats := Stats{Words: row.Words, Translated: row.Translated, AddedCount: row.AddedCount, LookupCount: row.LookupCount}
+	if stats.Due, err = queries.CountDueWords(ctx, worddb.CountDueWordsParams{DueAt: time.Now().UTC(), DeckID: deckID}); err != nil {
+		return stats, err
+	}
+	levels, err := queries.CountWordsByLevel(ctx, deckID)
+	if err != nil {
+		return stats, err
+	}
+	stats.Levels = make(map[string]int64, len(levels))
+	for _, row := range levels {
+		stats.Levels[row.Level] = row.Words
+	}
+	mastery, err := queries.CountWordsByMastery(ctx, deckID)
+	if err != nil {
+		return stats, err
+	}
+	stats.Mastery = make(map[string]int64, len(mastery))
+	for _, row := range mastery {
+		stats.Mastery[row.State] = row.Words
+	}
+	if stats.Archived, err = queries.CountArchivedWords(ctx, deckID); err != nil {
+		return stats, err
+	}
+	tags, err := queries.ListTags(ctx)
+	stats.Tags = int64(len(tags))
+	return stats, err
+}
@@ -1,15 +1,16 @@
 -- name: GetWord :one
 SELECT * FROM word
-WHERE word = ? LIMIT 1;
+WHERE word = ? AND deleted_at IS NULL LIMIT 1;
 
 -- name: Listword :many
-SELECT * FROM word;
+SELECT * FROM word
+WHERE deleted_at IS NULL AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id));
 
 -- name: CreateWord :one
 INSERT INTO word (
-  word, zh_trans, added_count, lookup_count
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, deck_id, level
 ) VALUES (
-  ?, ?, 0, 0
+  ?, ?, 0, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, ?, ?
 )
 RETURNING *;
 
@@ -19,9 +20,685 @@ SELECT COUNT(*) FROM word WHERE word = ?;
 
 -- name: AddWordCount :exec
 UPDATE word
-set added_count=added_count+1
+set added_count=added_count+1, updated_at=CURRENT_TIMESTAMP, deleted_at=NULL
 WHERE word = ?;
 
--- name: DeleteWord :exec
+-- name: DeleteWord :execrows
 DELETE FROM word
-WHERE word = ?;
\ No newline at end of file
+WHERE word = ?;
+
+-- name: TrashWord :execrows
+UPDATE word
+SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND deleted_at IS NULL;
+
+-- name: RestoreWord :execrows
+UPDATE word
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND deleted_at IS NOT NULL;
+
+-- name: ListTrash :many
+SELECT * FROM word
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, word;
+
+-- name: ListWordNames :many
+SELECT word FROM word
+WHERE deleted_at IS NULL;
+
+-- name: ListDueWords :many
+SELECT word.word, word.zh_trans, review.ease, review.interval_days, review.repetitions
+FROM word LEFT JOIN review ON review.word = word.word
+WHERE word.deleted_at IS NULL AND (review.due_at IS NULL OR review.due_at <= sqlc.arg(due_at))
+  AND (sqlc.arg(deck_id) = 0 OR word.deck_id = sqlc.arg(deck_id))
+  AND word.word NOT IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  )
+ORDER BY review.due_at
+LIMIT sqlc.arg(limit);
+
+-- name: UpsertReview :exec
+INSERT INTO review (
+  word, ease, interval_days, repetitions, due_at
+) VALUES (
+  ?, ?, ?, ?, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  ease = excluded.ease,
+  interval_days = excluded.interval_days,
+  repetitions = excluded.repetitions,
+  due_at = excluded.due_at;
+
+-- name: GetReview :one
+SELECT * FROM review
+WHERE word = ?;
+
+-- name: DeleteReview :exec
+DELETE FROM review
+WHERE word = ?;
+
+-- name: UpdateTranslation :execrows
+UPDATE word
+SET zh_trans = ?, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND deleted_at IS NULL;
+
+-- name: UpdatePhonetic :execrows
+UPDATE word
+SET phonetic = ?
+WHERE word = ? AND deleted_at IS NULL;
+
+-- name: AddSentence :execrows
+INSERT OR IGNORE INTO sentence (
+  word, sentence, source
+) VALUES (
+  ?, ?, ?
+);
+
+-- name: DeleteSentences :exec
+DELETE FROM sentence
+WHERE word = ?;
+
+-- name: ListWordsSorted :many
+SELECT * FROM word
+WHERE deleted_at IS NULL
+  AND (word LIKE '%' || sqlc.arg(filter) || '%' ESCAPE '\' OR IFNULL(zh_trans, '') LIKE '%' || sqlc.arg(filter) || '%' ESCAPE '\')
+  AND (CAST(sqlc.arg(tag) AS TEXT) = '' OR word IN (
+    SELECT word_tag.word FROM word_tag JOIN tag ON tag.id = word_tag.tag_id
+    WHERE tag.name = sqlc.arg(tag)
+  ))
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+  AND (CAST(sqlc.arg(level) AS TEXT) = '' OR level = sqlc.arg(level))
+  AND (CAST(sqlc.arg(source) AS TEXT) = '' OR word IN (
+    SELECT word_source.word FROM word_source
+    WHERE word_source.source LIKE sqlc.arg(source) || '%' ESCAPE '\'
+  ))
+  AND (word IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  )) = CAST(sqlc.arg(archived) AS BOOLEAN)
+  AND (CAST(sqlc.arg(mastery) AS TEXT) = '' OR IFNULL((
+    SELECT word_mastery.state FROM word_mastery WHERE word_mastery.word = word.word
+  ), 'new') = sqlc.arg(mastery))
+ORDER BY
+  CASE WHEN CAST(sqlc.arg(sort) AS TEXT) = 'alpha' THEN word END ASC,
+  CASE WHEN CAST(sqlc.arg(sort) AS TEXT) = 'added' THEN added_count END DESC,
+  CASE WHEN CAST(sqlc.arg(sort) AS TEXT) = 'lookup' THEN lookup_count END DESC,
+  CASE WHEN CAST(sqlc.arg(sort) AS TEXT) = 'date' THEN created_at END DESC,
+  CASE WHEN CAST(sqlc.arg(sort) AS TEXT) = 'level' THEN level IS NULL END ASC,
+  CASE WHEN CAST(sqlc.arg(sort) AS TEXT) = 'level' THEN level END ASC,
+  word
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountWordsFiltered :one
+SELECT count(*) FROM word
+WHERE deleted_at IS NULL
+  AND (word LIKE '%' || sqlc.arg(filter) || '%' ESCAPE '\' OR IFNULL(zh_trans, '') LIKE '%' || sqlc.arg(filter) || '%' ESCAPE '\')
+  AND (CAST(sqlc.arg(tag) AS TEXT) = '' OR word IN (
+    SELECT word_tag.word FROM word_tag JOIN tag ON tag.id = word_tag.tag_id
+    WHERE tag.name = sqlc.arg(tag)
+  ))
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+  AND (CAST(sqlc.arg(level) AS TEXT) = '' OR level = sqlc.arg(level))
+  AND (CAST(sqlc.arg(source) AS TEXT) = '' OR word IN (
+    SELECT word_source.word FROM word_source
+    WHERE word_source.source LIKE sqlc.arg(source) || '%' ESCAPE '\'
+  ))
+  AND (word IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  )) = CAST(sqlc.arg(archived) AS BOOLEAN)
+  AND (CAST(sqlc.arg(mastery) AS TEXT) = '' OR IFNULL((
+    SELECT word_mastery.state FROM word_mastery WHERE word_mastery.word = word.word
+  ), 'new') = sqlc.arg(mastery));
+
+-- name: CreateTag :one
+INSERT INTO tag (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = name
+RETURNING id;
+
+-- name: TagWord :exec
+INSERT OR IGNORE INTO word_tag (
+  word, tag_id
+) VALUES (
+  ?, ?
+);
+
+-- name: ListTags :many
+SELECT tag.name, COUNT(word.word) AS word_count
+FROM tag LEFT JOIN word_tag ON word_tag.tag_id = tag.id
+  LEFT JOIN word ON word.word = word_tag.word AND word.deleted_at IS NULL
+GROUP BY tag.id
+ORDER BY tag.name;
+
+-- name: ListWordTags :many
+SELECT word_tag.word, tag.name
+FROM word_tag JOIN tag ON tag.id = word_tag.tag_id
+ORDER BY word_tag.word, tag.name;
+
+-- name: DeleteWordTags :exec
+DELETE FROM word_tag
+WHERE word = ?;
+
+-- name: UpdateNote :exec
+UPDATE word
+SET note = COALESCE(sqlc.narg(note), note),
+  context = COALESCE(sqlc.narg(context), context),
+  updated_at = CURRENT_TIMESTAMP
+WHERE word = sqlc.arg(word);
+
+-- name: ListSentences :many
+SELECT * FROM sentence
+WHERE word = ?
+ORDER BY id;
+
+-- name: GetRandomSentence :one
+SELECT * FROM sentence
+WHERE word = ?
+ORDER BY random()
+LIMIT 1;
+
+-- name: DeleteSentence :execrows
+DELETE FROM sentence
+WHERE id = ?;
+
+-- name: AddLookupCount :exec
+UPDATE word
+SET lookup_count = IFNULL(lookup_count, 0) + 1, updated_at = CURRENT_TIMESTAMP
+WHERE word = ?;
+
+-- name: ListUntranslatedWords :many
+SELECT word FROM word
+WHERE deleted_at IS NULL AND (zh_trans IS NULL OR zh_trans = '')
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+ORDER BY created_at, word
+LIMIT sqlc.arg(limit);
+
+-- name: ListWordsWithoutPhonetic :many
+SELECT word FROM word
+WHERE deleted_at IS NULL AND (phonetic IS NULL OR phonetic = '')
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+ORDER BY created_at, word
+LIMIT sqlc.arg(limit);
+
+-- name: ListQuizWords :many
+SELECT word, zh_trans FROM word
+WHERE deleted_at IS NULL AND zh_trans IS NOT NULL AND zh_trans != ''
+  AND word NOT IN (SELECT word_list.word FROM word_list)
+  AND word NOT IN (SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL)
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+ORDER BY random()
+LIMIT sqlc.arg(limit);
+
+-- name: ListDistractors :many
+SELECT DISTINCT zh_trans FROM word
+WHERE deleted_at IS NULL AND word != sqlc.arg(word) AND zh_trans IS NOT NULL AND zh_trans != '' AND zh_trans != sqlc.arg(zh_trans)
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+ORDER BY random()
+LIMIT sqlc.arg(limit);
+
+-- name: RecordQuizAnswer :one
+INSERT INTO quiz_stat (
+  word, attempts, correct
+) VALUES (
+  ?, 1, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  attempts = attempts + 1,
+  correct = correct + excluded.correct
+RETURNING *;
+
+-- name: DeleteQuizStat :exec
+DELETE FROM quiz_stat
+WHERE word = ?;
+
+-- name: GetWordStats :one
+SELECT
+  COUNT(*) AS words,
+  CAST(IFNULL(SUM(zh_trans IS NOT NULL AND zh_trans != ''), 0) AS INTEGER) AS translated,
+  CAST(IFNULL(SUM(added_count), 0) AS INTEGER) AS added_count,
+  CAST(IFNULL(SUM(lookup_count), 0) AS INTEGER) AS lookup_count
+FROM word
+WHERE deleted_at IS NULL AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id));
+
+-- name: CountWordsByLevel :many
+SELECT IFNULL(level, '') AS level, COUNT(*) AS words
+FROM word
+WHERE deleted_at IS NULL AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+GROUP BY level
+ORDER BY level;
+
+-- name: CountDueWords :one
+SELECT COUNT(*)
+FROM word LEFT JOIN review ON review.word = word.word
+WHERE word.deleted_at IS NULL AND (review.due_at IS NULL OR review.due_at <= sqlc.arg(due_at))
+  AND (sqlc.arg(deck_id) = 0 OR word.deck_id = sqlc.arg(deck_id))
+  AND word.word NOT IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  );
+
+-- name: CreateJournal :one
+INSERT INTO journal (op, created_at) VALUES (?, CURRENT_TIMESTAMP)
+RETURNING id;
+
+-- name: JournalWord :exec
+INSERT INTO journal_word (
+  journal_id, word, existed, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id
+)
+SELECT sqlc.arg(journal_id), sqlc.arg(word), word.word IS NOT NULL, word.zh_trans, word.added_count, word.lookup_count,
+  word.created_at, word.updated_at, word.note, word.context, word.deleted_at, word.deck_id
+FROM (SELECT 1) LEFT JOIN word ON word.word = sqlc.arg(word);
+
+-- name: GetLastJournal :one
+SELECT * FROM journal
+ORDER BY id DESC LIMIT 1;
+
+-- name: ListJournalWords :many
+SELECT * FROM journal_word
+WHERE journal_id = ?
+ORDER BY id DESC;
+
+-- name: RevertWord :exec
+INSERT INTO word (
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, level, deck_id
+) VALUES (
+  sqlc.arg(word), sqlc.arg(zh_trans), sqlc.arg(added_count), sqlc.arg(lookup_count), sqlc.arg(created_at),
+  sqlc.arg(updated_at), sqlc.arg(note), sqlc.arg(context), sqlc.arg(deleted_at), sqlc.arg(level),
+  IFNULL((SELECT deck.id FROM deck WHERE deck.id = sqlc.narg(deck_id)), 1)
+)
+ON CONFLICT (word) DO UPDATE SET
+  zh_trans = excluded.zh_trans,
+  added_count = excluded.added_count,
+  lookup_count = excluded.lookup_count,
+  created_at = excluded.created_at,
+  updated_at = excluded.updated_at,
+  note = excluded.note,
+  context = excluded.context,
+  deleted_at = excluded.deleted_at,
+  deck_id = IFNULL((SELECT deck.id FROM deck WHERE deck.id = sqlc.narg(deck_id)), word.deck_id);
+
+-- name: DeleteJournal :exec
+DELETE FROM journal
+WHERE id = ?;
+
+-- name: DeleteJournalWords :exec
+DELETE FROM journal_word
+WHERE journal_id = ?;
+
+-- name: PruneJournal :exec
+DELETE FROM journal
+WHERE id <= (SELECT MAX(id) FROM journal) - sqlc.arg(keep);
+
+-- name: PruneJournalWords :exec
+DELETE FROM journal_word
+WHERE journal_id NOT IN (SELECT id FROM journal);
+
+-- name: CreateDeck :one
+INSERT INTO deck (name) VALUES (?)
+RETURNING id;
+
+-- name: GetDeckID :one
+SELECT id FROM deck
+WHERE name = ?;
+
+-- name: ListDecks :many
+SELECT deck.id, deck.name, COUNT(word.word) AS word_count
+FROM deck LEFT JOIN word ON word.deck_id = deck.id AND word.deleted_at IS NULL
+GROUP BY deck.id
+ORDER BY deck.id;
+
+-- name: RenameDeck :execrows
+UPDATE deck
+SET name = sqlc.arg(new_name)
+WHERE name = sqlc.arg(name);
+
+-- name: MoveDeckWords :execrows
+UPDATE word
+SET deck_id = sqlc.arg(to_deck_id), updated_at = CURRENT_TIMESTAMP
+WHERE deck_id = sqlc.arg(from_deck_id);
+
+-- name: DeleteDeck :exec
+DELETE FROM deck
+WHERE id = ?;
+
+-- name: MergeWord :exec
+INSERT INTO word (
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deck_id, level
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  zh_trans = COALESCE(NULLIF(word.zh_trans, ''), excluded.zh_trans),
+  added_count = IFNULL(word.added_count, 0) + IFNULL(excluded.added_count, 0),
+  lookup_count = IFNULL(word.lookup_count, 0) + IFNULL(excluded.lookup_count, 0),
+  created_at = COALESCE(MIN(word.created_at, excluded.created_at), word.created_at, excluded.created_at),
+  updated_at = COALESCE(MAX(word.updated_at, excluded.updated_at), word.updated_at, excluded.updated_at),
+  note = COALESCE(NULLIF(word.note, ''), excluded.note),
+  context = COALESCE(NULLIF(word.context, ''), excluded.context);
+
+-- name: ListChangedWords :many
+SELECT * FROM word
+WHERE sqlc.narg(since) IS NULL OR datetime(updated_at) >= datetime(sqlc.narg(since))
+ORDER BY word;
+
+-- name: GetWordUpdatedAt :one
+SELECT updated_at FROM word
+WHERE word = ?;
+
+-- name: SyncWord :exec
+INSERT INTO word (
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, level
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  zh_trans = excluded.zh_trans,
+  added_count = excluded.added_count,
+  lookup_count = excluded.lookup_count,
+  created_at = excluded.created_at,
+  updated_at = excluded.updated_at,
+  note = excluded.note,
+  context = excluded.context,
+  deleted_at = excluded.deleted_at,
+  deck_id = excluded.deck_id;
+
+-- name: GetSyncState :one
+SELECT * FROM sync_state
+WHERE remote = ?;
+
+-- name: SetSyncState :exec
+INSERT INTO sync_state (
+  remote, local_synced_at, remote_synced_at
+) VALUES (
+  ?, ?, ?
+)
+ON CONFLICT (remote) DO UPDATE SET
+  local_synced_at = excluded.local_synced_at,
+  remote_synced_at = excluded.remote_synced_at;
+
+-- name: CountWordsAddedPerDay :many
+SELECT CAST(date(created_at, 'localtime') AS TEXT) AS day, COUNT(*) AS words
+FROM word
+WHERE deleted_at IS NULL AND created_at IS NOT NULL
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+GROUP BY day
+ORDER BY day;
+
+-- name: ListTopLookups :many
+SELECT word, zh_trans, lookup_count FROM word
+WHERE deleted_at IS NULL AND lookup_count > 0
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+ORDER BY lookup_count DESC, word
+LIMIT sqlc.arg(limit);
+
+-- name: AddDailyReview :exec
+INSERT INTO daily_activity (day, reviews) VALUES (?, 1)
+ON CONFLICT (day) DO UPDATE SET reviews = reviews + 1;
+
+-- name: ListDailyActivity :many
+SELECT * FROM daily_activity
+ORDER BY day;
+
+-- name: CountReviews :one
+SELECT CAST(IFNULL(SUM(reviews), 0) AS INTEGER) AS reviews FROM daily_activity;
+
+-- name: GetEnrichment :one
+SELECT * FROM enrichment
+WHERE word = ?;
+
+-- name: SetEnrichment :exec
+INSERT INTO enrichment (
+  word, definition, mnemonic, examples, model, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
+)
+ON CONFLICT (word) DO UPDATE SET
+  definition = excluded.definition,
+  mnemonic = excluded.mnemonic,
+  examples = excluded.examples,
+  model = excluded.model,
+  created_at = excluded.created_at;
+
+-- name: DeleteEnrichment :exec
+DELETE FROM enrichment
+WHERE word = ?;
+
+-- name: ListRelatedWords :many
+SELECT related_word.related, related_word.kind,
+  EXISTS (SELECT 1 FROM word WHERE word.word = related_word.related AND word.deleted_at IS NULL) AS added
+FROM related_word
+WHERE related_word.word = ?
+ORDER BY related_word.kind DESC, related_word.score DESC, related_word.related;
+
+-- name: AddRelatedWord :exec
+INSERT OR REPLACE INTO related_word (
+  word, related, kind, score
+) VALUES (
+  ?, ?, ?, ?
+);
+
+-- name: DeleteRelatedWords :exec
+DELETE FROM related_word
+WHERE word = ?;
+
+-- name: AddVariant :exec
+INSERT INTO word_variant (variant, word) VALUES (?, ?)
+ON CONFLICT (variant) DO UPDATE SET word = excluded.word;
+
+-- name: ListVariants :many
+SELECT variant FROM word_variant
+WHERE word = ?
+ORDER BY variant;
+
+-- name: GetVariantWord :one
+SELECT word FROM word_variant
+WHERE variant = ?;
+
+-- name: DeleteVariants :exec
+DELETE FROM word_variant
+WHERE word = ?;
+
+-- name: ListTranslations :many
+SELECT lang, trans FROM translation
+WHERE word = ?
+ORDER BY lang;
+
+-- name: ListTranslationsByLang :many
+SELECT word, trans FROM translation
+WHERE lang = ?;
+
+-- name: SetLangTranslation :execrows
+INSERT INTO translation (word, lang, trans, updated_at)
+SELECT word, sqlc.arg(lang), sqlc.arg(trans), CURRENT_TIMESTAMP FROM word
+WHERE word = sqlc.arg(word) AND deleted_at IS NULL
+ON CONFLICT (word, lang) DO UPDATE SET trans = excluded.trans, updated_at = excluded.updated_at;
+
+-- name: DeleteLangTranslation :execrows
+DELETE FROM translation
+WHERE word = ? AND lang = ?;
+
+-- name: DeleteTranslations :exec
+DELETE FROM translation
+WHERE word = ?;
+
+-- name: ListWordsWithoutLangTranslation :many
+SELECT word FROM word
+WHERE deleted_at IS NULL
+  AND word NOT IN (SELECT translation.word FROM translation WHERE translation.lang = sqlc.arg(lang))
+  AND (sqlc.arg(deck_id) = 0 OR deck_id = sqlc.arg(deck_id))
+ORDER BY created_at, word
+LIMIT sqlc.arg(limit);
+
+-- name: ListListedWords :many
+SELECT word, list FROM word_list
+ORDER BY list, word;
+
+-- name: AddListedWord :execrows
+INSERT INTO word_list (word, list, added_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (word) DO UPDATE SET list = excluded.list, added_at = excluded.added_at
+WHERE word_list.list != excluded.list;
+
+-- name: DeleteListedWord :execrows
+DELETE FROM word_list
+WHERE word = ? AND list = ?;
+
+-- name: AddWordSource :exec
+INSERT INTO word_source (word, source, added_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (word, source) DO NOTHING;
+
+-- name: ListWordSources :many
+SELECT source FROM word_source
+WHERE word = ?
+ORDER BY added_at, source;
+
+-- name: ListSources :many
+SELECT word_source.source, COUNT(word.word) AS word_count
+FROM word_source JOIN word ON word.word = word_source.word AND word.deleted_at IS NULL
+GROUP BY word_source.source
+ORDER BY word_source.source;
+
+-- name: DeleteWordSources :exec
+DELETE FROM word_source
+WHERE word = ?;
+
+-- name: UpsertMastery :exec
+INSERT INTO word_mastery (word, state, archived_at, updated_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (word) DO UPDATE SET
+  state = excluded.state,
+  archived_at = COALESCE(word_mastery.archived_at, excluded.archived_at),
+  updated_at = excluded.updated_at;
+
+-- name: GetMastery :one
+SELECT * FROM word_mastery
+WHERE word = ?;
+
+-- name: ArchiveWord :execrows
+INSERT INTO word_mastery (word, state, archived_at, updated_at)
+VALUES (?, 'new', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT (word) DO UPDATE SET
+  archived_at = excluded.archived_at,
+  updated_at = excluded.updated_at
+WHERE word_mastery.archived_at IS NULL;
+
+-- name: UnarchiveWord :execrows
+UPDATE word_mastery
+SET archived_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND archived_at IS NOT NULL;
+
+-- name: CountWordsByMastery :many
+SELECT IFNULL(word_mastery.state, 'new') AS state, COUNT(*) AS words
+FROM word LEFT JOIN word_mastery ON word_mastery.word = word.word
+WHERE word.deleted_at IS NULL AND (sqlc.arg(deck_id) = 0 OR word.deck_id = sqlc.arg(deck_id))
+GROUP BY 1
+ORDER BY 1;
+
+-- name: CountArchivedWords :one
+SELECT COUNT(*)
+FROM word JOIN word_mastery ON word_mastery.word = word.word
+WHERE word.deleted_at IS NULL AND word_mastery.archived_at IS NOT NULL
+  AND (sqlc.arg(deck_id) = 0 OR word.deck_id = sqlc.arg(deck_id));
+
+-- name: DeleteMastery :exec
+DELETE FROM word_mastery
+WHERE word = ?;
+
+-- name: AddReviewLog :exec
+INSERT INTO review_log (word, grade, latency_ms, interval_days, next_interval_days, reviewed_at)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: ListReviewLog :many
+SELECT * FROM review_log
+WHERE reviewed_at >= sqlc.arg(since)
+  AND (CAST(sqlc.arg(word) AS TEXT) = '' OR word = sqlc.arg(word))
+  AND (sqlc.arg(deck_id) = 0 OR word IN (
+    SELECT word.word FROM word WHERE word.deck_id = sqlc.arg(deck_id)
+  ))
+ORDER BY reviewed_at DESC, id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: DeleteReviewLog :exec
+DELETE FROM review_log
+WHERE word = ?;
+
+-- name: GetDataRevision :one
+SELECT revision, updated_at FROM data_revision
+WHERE id = 1;
+
+-- name: CreateUser :one
+INSERT INTO user (name, password_hash, db_path, created_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING id;
+
+-- name: GetUser :one
+SELECT * FROM user
+WHERE name = ?;
+
+-- name: GetUserByToken :one
+SELECT user.* FROM user
+JOIN user_token ON user_token.user_id = user.id
+WHERE user_token.token_hash = ?;
+
+-- name: ListUsers :many
+SELECT user.id, user.name, user.db_path, user.created_at,
+  (SELECT COUNT(*) FROM user_token WHERE user_token.user_id = user.id) AS tokens
+FROM user
+ORDER BY user.name;
+
+-- name: SetUserPassword :execrows
+UPDATE user SET password_hash = ?
+WHERE name = ?;
+
+-- name: DeleteUser :exec
+DELETE FROM user
+WHERE id = ?;
+
+-- name: CreateUserToken :exec
+INSERT INTO user_token (token_hash, user_id, created_at)
+VALUES (?, ?, CURRENT_TIMESTAMP);
+
+-- name: DeleteUserTokens :execrows
+DELETE FROM user_token
+WHERE user_id = ?;
+
+-- name: CreateShare :exec
+INSERT INTO share (token, title, deck_id, tag, words, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?);
+
+-- name: GetShare :one
+SELECT * FROM share
+WHERE token = ?;
+
+-- name: ListShares :many
+SELECT * FROM share
+ORDER BY created_at DESC;
+
+-- name: DeleteShare :execrows
+DELETE FROM share
+WHERE token = ?;
+
+-- name: CreateUserShare :exec
+INSERT INTO user_share (token, user_id, created_at)
+VALUES (?, ?, CURRENT_TIMESTAMP);
+
+-- name: GetUserByShare :one
+SELECT user.* FROM user
+JOIN user_share ON user_share.user_id = user.id
+WHERE user_share.token = ?;
+
+-- name: DeleteUserShare :exec
+DELETE FROM user_share
+WHERE token = ?;
+
+-- name: DeleteUserShares :exec
+DELETE FROM user_share
+WHERE user_id = ?;
+
+-- name: GetSearchRevision :one
+SELECT revision FROM search_index
+WHERE id = 1;
+
+-- name: SetSearchRevision :exec
+INSERT INTO search_index (id, revision, rebuilt_at) VALUES (1, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (id) DO UPDATE SET revision = excluded.revision, rebuilt_at = excluded.rebuilt_at;
+
+-- name: ForgetJournalWord :exec
+DELETE FROM journal_word
+WHERE word = ?;
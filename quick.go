@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// most words of a phrase selected, longer selections are sentences rather
+// than words to learn
+const maxQuickWords = 3
+
+// the word of text selected in another app, without the punctuation or
+// quotes selected around it
+func selectedWord(text string) string {
+	text = strings.TrimFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsMark(r) })
+	return wordstore.NormalizeWord(text)
+}
+
+// add the word of text selected in another app and show a desktop
+// notification of its translation, for text selection services like the
+// quick actions of macos. The translation is fetched if the word is new,
+// errors are notified too since there's no terminal to see them.
+func (w *WordDB) QuickAdd(text string) error {
+	err := w.quickAdd(text)
+	if err != nil {
+		if err := notifyDesktop(w.Ctx, "w2r", err.Error()); err != nil {
+			log.Printf("notify: %v", err)
+		}
+	}
+	return err
+}
+
+func (w *WordDB) quickAdd(text string) error {
+	word := selectedWord(text)
+	if !wordstore.IsValidWord(word) || len(strings.Fields(word)) > maxQuickWords {
+		return fmt.Errorf("not a word: %q", strings.TrimSpace(text))
+	}
+	words, _ := w.lemmatize(w.Ctx, []string{word})
+	word = words[0]
+	existed, err := w.Store.Exists(w.Ctx, word)
+	if err != nil {
+		return err
+	}
+	if err := w.AddWords(words, nil, "", "", ""); err != nil {
+		return err
+	}
+
+	result, err := w.Store.Get(w.Ctx, word)
+	if err != nil {
+		return err
+	}
+	trans := result.ZhTrans.String
+	if w.otherLang() {
+		translations, err := w.Store.Translations(w.Ctx, word)
+		if err != nil {
+			return err
+		}
+		trans = translations[strings.ToLower(config.Translate.Lang)]
+	}
+	title := "w2r: " + word
+	if result.Phonetic.String != "" {
+		title += " " + result.Phonetic.String
+	}
+	body := trans
+	switch {
+	case body == "" && existed:
+		body = "already added, no translation"
+	case body == "":
+		body = "added, no translation found"
+	case existed:
+		body += " (already added)"
+	}
+	fmt.Printf("%s: %s\n", word, body)
+	if err := notifyDesktop(w.Ctx, title, body); err != nil {
+		log.Printf("notify: %v", err)
+	}
+	return nil
+}
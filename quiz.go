@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// a multiple-choice question, pick the translation of Word from Choices
+type Question struct {
+	Word    string
+	Choices []string
+	Answer  int
+}
+
+// build a question of word with distractors sampled from other translations
+// in the deck of the quiz, it may have fewer than n choices when the deck is
+// small
+func (w *WordDB) newQuestion(queries *worddb.Queries, word worddb.ListQuizWordsRow, n int) (Question, error) {
+	distractors, err := queries.ListDistractors(w.Ctx, worddb.ListDistractorsParams{
+		Word:    word.Word,
+		ZhTrans: word.ZhTrans,
+		DeckID:  w.DeckID,
+		Limit:   int64(n - 1),
+	})
+	if err != nil {
+		return Question{}, err
+	}
+
+	q := Question{Word: word.Word, Choices: []string{word.ZhTrans.String}}
+	for _, d := range distractors {
+		q.Choices = append(q.Choices, d.String)
+	}
+	rand.Shuffle(len(q.Choices), func(i, j int) {
+		q.Choices[i], q.Choices[j] = q.Choices[j], q.Choices[i]
+	})
+	for i, choice := range q.Choices {
+		if choice == word.ZhTrans.String {
+			q.Answer = i
+		}
+	}
+	return q, nil
+}
+
+// record an answer of word, returns the updated accuracy
+func (w *WordDB) recordAnswer(queries *worddb.Queries, word string, correct bool) (worddb.QuizStat, error) {
+	var c int64
+	if correct {
+		c = 1
+	}
+	return queries.RecordQuizAnswer(w.Ctx, worddb.RecordQuizAnswerParams{Word: word, Correct: c})
+}
+
+// ask count multiple-choice questions of words with translation, reading
+// answers from in, and print the score
+func (w *WordDB) Quiz(in io.Reader, count, choices int) error {
+	queries := worddb.New(w.Db)
+	words, err := queries.ListQuizWords(w.Ctx, worddb.ListQuizWordsParams{DeckID: w.DeckID, Limit: int64(count)})
+	if err != nil {
+		return err
+	}
+	if len(words) < 2 {
+		fmt.Println("not enough words with translation for a quiz")
+		return nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	score, asked := 0, 0
+	defer func() {
+		if asked > 0 {
+			fmt.Printf("\nscore: %d/%d (%.0f%%)\n", score, asked, float64(score)*100/float64(asked))
+		}
+	}()
+	for i, word := range words {
+		q, err := w.newQuestion(queries, word, choices)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(words), q.Word)
+		if sentence := w.quizSentence(w.Ctx, q.Word); sentence != "" {
+			fmt.Printf("  “%s”\n", sentence)
+		}
+		for j, choice := range q.Choices {
+			fmt.Printf("  %d) %s\n", j+1, choice)
+		}
+
+		var answer int
+		for {
+			fmt.Printf("answer [1-%d] or [q]uit: ", len(q.Choices))
+			if !scanner.Scan() {
+				return scanner.Err()
+			}
+			text := strings.TrimSpace(scanner.Text())
+			if text == "q" {
+				return nil
+			}
+			if n, err := strconv.Atoi(text); err == nil && n >= 1 && n <= len(q.Choices) {
+				answer = n - 1
+				break
+			}
+		}
+
+		asked++
+		correct := answer == q.Answer
+		if correct {
+			score++
+			fmt.Print("  correct!")
+		} else {
+			fmt.Printf("  wrong, the answer is %d) %s", q.Answer+1, q.Choices[q.Answer])
+		}
+		stat, err := w.recordAnswer(queries, q.Word, correct)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  (accuracy of '%s': %d/%d)\n", q.Word, stat.Correct, stat.Attempts)
+	}
+	return nil
+}
+
+// hint of a word, the first letter followed by blanks for the other letters,
+// spaces, hyphens and apostrophes of phrases are kept
+func spellingHint(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	return string(first) + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return '_'
+		}
+		return r
+	}, word[size:])
+}
+
+// number of letters of word, without spaces, hyphens and apostrophes
+func countLetters(word string) int {
+	n := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// show the translation of count words and ask to type the word, reading
+// answers from in, and print the score
+func (w *WordDB) Spell(in io.Reader, count int) error {
+	queries := worddb.New(w.Db)
+	words, err := queries.ListQuizWords(w.Ctx, worddb.ListQuizWordsParams{DeckID: w.DeckID, Limit: int64(count)})
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		fmt.Println("no words with translation for a spelling test")
+		return nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	score, asked := 0, 0
+	defer func() {
+		if asked > 0 {
+			fmt.Printf("\nscore: %d/%d (%.0f%%)\n", score, asked, float64(score)*100/float64(asked))
+		}
+	}()
+	for i, word := range words {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(words), word.ZhTrans.String)
+		fmt.Printf("  hint: %s (%d letters)\n", spellingHint(word.Word), countLetters(word.Word))
+		if sentence := w.quizSentence(w.Ctx, word.Word); sentence != "" {
+			fmt.Printf("  “%s”\n", clozeSentence(sentence, word.Word))
+		}
+		fmt.Print("type the word or [q]uit: ")
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		text := wordstore.NormalizeWord(scanner.Text())
+		if text == "q" {
+			return nil
+		}
+
+		asked++
+		correct := text == word.Word
+		if correct {
+			score++
+			fmt.Print("  correct!")
+		} else {
+			fmt.Printf("  wrong, the word is '%s'", word.Word)
+		}
+		stat, err := w.recordAnswer(queries, word.Word, correct)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  (accuracy of '%s': %d/%d)\n", word.Word, stat.Correct, stat.Attempts)
+	}
+	return nil
+}
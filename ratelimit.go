@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokens of a client, refilled at the rate of the limiter up to its burst
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// per client token bucket rate limiter, each request takes a token
+type rateLimiter struct {
+	// tokens per second and the most tokens a bucket holds
+	rate  float64
+	burst float64
+	// take the client address from X-Forwarded-For or X-Real-IP
+	trustProxy bool
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(rate float64, burst int, trustProxy bool) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: float64(burst), trustProxy: trustProxy, buckets: make(map[string]*tokenBucket)}
+}
+
+// take a token of client at now, or return how long to wait for one
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// buckets refilled to the burst are the same as new ones, drop them so
+	// the map doesn't grow with every client ever seen
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) > max(full, time.Minute) {
+		for key, b := range l.buckets {
+			if now.Sub(b.last) >= full {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b := l.buckets[client]
+	if b == nil {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// address of the client of r, the one added by the reverse proxy in front if
+// it's trusted
+func (l *rateLimiter) client(r *http.Request) string {
+	if l.trustProxy {
+		// the last address is added by the proxy, the others can be forged
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addrs := strings.Split(forwarded, ",")
+			return strings.TrimSpace(addrs[len(addrs)-1])
+		}
+		if ip := r.Header.Get("X-Real-IP"); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// take a token of the client of r, without a limiter every request is
+// allowed
+func (l *rateLimiter) allowRequest(r *http.Request, now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	return l.allow(l.client(r), now)
+}
+
+// whether requests to path are limited, the api and quick add, which write
+// to the database, and shares, whose tokens could be guessed
+func rateLimited(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/add" || strings.HasPrefix(path, "/share/")
+}
+
+// wrap h to reply 429 to clients sending requests to the api, quick add or
+// shares faster than the rate, it's checked before authentication so guessing
+// tokens is limited too
+func (l *rateLimiter) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !rateLimited(r.URL.Path) {
+			h.ServeHTTP(rw, r)
+			return
+		}
+		if ok, wait := l.allowRequest(r, time.Now()); !ok {
+			rw.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				writeJSONError(rw, http.StatusTooManyRequests, "too many requests")
+			} else {
+				http.Error(rw, "too many requests", http.StatusTooManyRequests)
+			}
+			return
+		}
+		h.ServeHTTP(rw, r)
+	})
+}
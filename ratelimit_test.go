@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	// 1 token per second, 2 at most, requests in order
+	tests := []struct {
+		client   string
+		after    time.Duration
+		want     bool
+		wantWait time.Duration
+	}{
+		{"1.1.1.1", 0, true, 0},
+		{"1.1.1.1", 0, true, 0},
+		{"1.1.1.1", 0, false, time.Second},
+		{"2.2.2.2", 0, true, 0},
+		{"1.1.1.1", 500 * time.Millisecond, false, 500 * time.Millisecond},
+		{"1.1.1.1", time.Second, true, 0},
+		{"1.1.1.1", time.Second, false, time.Second},
+		// refilled up to the burst only
+		{"1.1.1.1", time.Hour, true, 0},
+		{"1.1.1.1", time.Hour, true, 0},
+		{"1.1.1.1", time.Hour, false, time.Second},
+	}
+	l := newRateLimiter(1, 2, false)
+	for i, tt := range tests {
+		got, wait := l.allow(tt.client, start.Add(tt.after))
+		if got != tt.want || wait != tt.wantWait {
+			t.Errorf("request %d of %s after %s = %v, wait %s, want %v, wait %s", i, tt.client, tt.after, got, wait, tt.want, tt.wantWait)
+		}
+	}
+}
+
+func TestRateLimiterClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		headers    map[string]string
+		want       string
+	}{
+		{"remote addr", false, nil, "192.0.2.1"},
+		{"untrusted proxy", false, map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Real-IP": "2.2.2.2"}, "192.0.2.1"},
+		{"forwarded for", true, map[string]string{"X-Forwarded-For": "1.1.1.1"}, "1.1.1.1"},
+		{"forged forwarded for", true, map[string]string{"X-Forwarded-For": "6.6.6.6, 1.1.1.1"}, "1.1.1.1"},
+		{"real ip", true, map[string]string{"X-Real-IP": "2.2.2.2"}, "2.2.2.2"},
+		{"no proxy headers", true, nil, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/words", nil)
+		for key, value := range tt.headers {
+			r.Header.Set(key, value)
+		}
+		if got := newRateLimiter(1, 1, tt.trustProxy).client(r); got != tt.want {
+			t.Errorf("%s: client = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// max size of a fetched web page
+const maxPageSize = 10 << 20
+
+var (
+	// elements which are never part of the readable text, with their content
+	hiddenElements = regexp.MustCompile(`(?is)<(?:script|style|noscript|template|svg|iframe|head|nav|header|footer|aside|form|button|select)\b.*?</(?:script|style|noscript|template|svg|iframe|head|nav|header|footer|aside|form|button|select)\s*>|<!--.*?-->`)
+	// the main content of the page, if it's marked up
+	mainElement = regexp.MustCompile(`(?is)<(?:article|main)\b[^>]*>(.*)</(?:article|main)\s*>`)
+	// elements of text blocks
+	blockElement = regexp.MustCompile(`(?is)<(?:p|h[1-6]|li|blockquote|pre|dd|figcaption)\b[^>]*>(.*?)</(?:p|h[1-6]|li|blockquote|pre|dd|figcaption)\s*>`)
+	linkElement  = regexp.MustCompile(`(?is)<a\b[^>]*>(.*?)</a\s*>`)
+	anyTag       = regexp.MustCompile(`(?s)<[^>]*>`)
+	titleElement = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	metaCharset  = regexp.MustCompile(`(?i)<meta\b[^>]*charset=["']?([\w-]+)`)
+)
+
+// text of an html fragment, without tags and entities, white space collapsed
+func htmlText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(anyTag.ReplaceAllString(s, " "))), " ")
+}
+
+// text of the block elements like paragraphs of an html page, leaving out
+// hidden elements, and the blocks keep returns false for if it's not nil, it's
+// given the text and the html of a block
+func htmlBlocks(page string, keep func(text, inner string) bool) []string {
+	page = hiddenElements.ReplaceAllString(page, " ")
+	var blocks []string
+	for _, m := range blockElement.FindAllStringSubmatch(page, -1) {
+		text := htmlText(m[1])
+		if text != "" && (keep == nil || keep(text, m[1])) {
+			blocks = append(blocks, text)
+		}
+	}
+	return blocks
+}
+
+// the readable text of an html page, like the reader view of browsers: the
+// paragraphs of the article if the page has one, leaving out menus, short
+// lines and lists of links. Paragraphs are separated by blank lines.
+func readableText(page string) string {
+	page = hiddenElements.ReplaceAllString(page, " ")
+	if m := mainElement.FindStringSubmatch(page); m != nil {
+		page = m[1]
+	}
+
+	paragraphs := htmlBlocks(page, func(text, inner string) bool {
+		// menus and buttons are a few words
+		if len(strings.Fields(text)) < 5 {
+			return false
+		}
+		links := 0
+		for _, link := range linkElement.FindAllStringSubmatch(inner, -1) {
+			links += len(htmlText(link[1]))
+		}
+		return links*2 <= len(text)
+	})
+	// a page without paragraphs, all of its text is better than nothing
+	if len(paragraphs) == 0 {
+		return htmlText(page)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// html page or plain text decoded to utf-8 by the charset of contentType or
+// of the meta element of the page
+func decodePage(body []byte, contentType string) (string, error) {
+	_, params, _ := mime.ParseMediaType(contentType)
+	charset := params["charset"]
+	if charset == "" {
+		if m := metaCharset.FindSubmatch(body); m != nil {
+			charset = string(m[1])
+		}
+	}
+	if charset == "" || strings.EqualFold(charset, "utf-8") {
+		if !utf8.Valid(body) {
+			return strings.ToValidUTF8(string(body), "\uFFFD"), nil
+		}
+		return string(body), nil
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return "", fmt.Errorf("unknown charset %q", charset)
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	return string(decoded), err
+}
+
+// fetch the web page at u, and return its readable text and title, or u if it
+// has no title
+func fetchReadable(ctx context.Context, u string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.Translate.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Accept", "text/html, text/plain;q=0.9")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", &httpStatusError{method: req.Method, url: u, code: resp.StatusCode, status: resp.Status}
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "text/") && !strings.Contains(contentType, "html") {
+		return "", "", fmt.Errorf("%s is %s, not a web page", u, contentType)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return "", "", err
+	}
+	page, err := decodePage(body, contentType)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", u, err)
+	}
+
+	if strings.HasPrefix(contentType, "text/plain") {
+		return page, u, nil
+	}
+	title := u
+	if m := titleElement.FindStringSubmatch(page); m != nil && htmlText(m[1]) != "" {
+		title = htmlText(m[1])
+	}
+	return readableText(page), title, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// max number of synonyms, and of antonyms, fetched of a word
+const maxRelated = 20
+
+// related words of word by datamuse, rel is syn or ant, see
+// https://www.datamuse.com/api/, which only has english words
+func datamuseRelated(ctx context.Context, word, rel string) ([]worddb.AddRelatedWordParams, error) {
+	if !wordstore.IsEnglish() {
+		return nil, fmt.Errorf("datamuse has no %s words", config.Language)
+	}
+	var results []struct {
+		Word  string `json:"word"`
+		Score int64  `json:"score"`
+	}
+	u := fmt.Sprintf("https://api.datamuse.com/words?rel_%s=%s&max=%d", rel, url.QueryEscape(word), maxRelated)
+	if err := getJSON(ctx, u, &results); err != nil {
+		return nil, err
+	}
+	kind := wordstore.Synonym
+	if rel == "ant" {
+		kind = wordstore.Antonym
+	}
+	var related []worddb.AddRelatedWordParams
+	for _, r := range results {
+		// skip abbreviations like "u.s." which can't be added to the list
+		r.Word = wordstore.NormalizeWord(r.Word)
+		if !wordstore.IsValidWord(r.Word) || r.Word == word {
+			continue
+		}
+		related = append(related, worddb.AddRelatedWordParams{Related: r.Word, Kind: kind, Score: r.Score})
+	}
+	return related, nil
+}
+
+// synonyms and antonyms of word, fetched from datamuse and stored if there
+// are none stored yet or refresh
+func (w *WordDB) related(ctx context.Context, word string, refresh bool) ([]worddb.ListRelatedWordsRow, error) {
+	if _, err := w.Store.Get(ctx, word); err != nil {
+		return nil, err
+	}
+	if !refresh {
+		related, err := w.Store.Related(ctx, word)
+		if err != nil || len(related) > 0 {
+			return related, err
+		}
+	}
+	var fetched []worddb.AddRelatedWordParams
+	for _, rel := range []string{"syn", "ant"} {
+		related, err := datamuseRelated(ctx, word, rel)
+		if err != nil {
+			return nil, fmt.Errorf("related words of '%s': %w", word, err)
+		}
+		fetched = append(fetched, related...)
+	}
+	if err := w.Store.SetRelated(ctx, word, fetched); err != nil {
+		return nil, err
+	}
+	return w.Store.Related(ctx, word)
+}
+
+// print the synonyms and antonyms of word, those in the list marked with *
+func (w *WordDB) ShowRelated(word string, refresh bool) error {
+	related, err := w.related(w.Ctx, word, refresh)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		return fmt.Errorf("related words of '%s': %w%s", word, err, w.didYouMean(word))
+	}
+	if err != nil {
+		return err
+	}
+	if len(related) == 0 {
+		fmt.Printf("no related words of '%s' found\n", word)
+		return nil
+	}
+	byKind := make(map[string][]string)
+	for _, r := range related {
+		s := r.Related
+		if r.Added != 0 {
+			s += "*"
+		}
+		byKind[r.Kind] = append(byKind[r.Kind], s)
+	}
+	for _, kind := range []string{wordstore.Synonym, wordstore.Antonym} {
+		if len(byKind[kind]) > 0 {
+			fmt.Printf("%ss: %s\n", kind, strings.Join(byKind[kind], ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// remote storage of backups, keys are slash separated paths
+type remoteStorage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// storage and key of a remote url like s3://bucket/path or
+// webdavs://host/path, webdav:// is plain http
+func parseRemote(raw string) (remoteStorage, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, "", fmt.Errorf("remote %s has no bucket", u.Redacted())
+		}
+		return newS3Storage(u.Host), key, nil
+	case "webdav", "webdavs":
+		return newWebDAVStorage(u), key, nil
+	}
+	return nil, "", fmt.Errorf("unknown remote %s, use s3://bucket/path or webdavs://host/path", u.Redacted())
+}
+
+// do req and check the status, the body of error responses is included in
+// the error, s3 explains errors there
+func doRemote(req *http.Request, want ...int) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range want {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	err = fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+	if body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)); len(bytes.TrimSpace(body)) > 0 {
+		err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(body))
+	}
+	return nil, err
+}
+
+// webdav server, files are written with PUT, so the directory must exist
+type webDAVStorage struct {
+	base     url.URL
+	user     string
+	password string
+}
+
+// credentials in the url override the config
+func newWebDAVStorage(u *url.URL) *webDAVStorage {
+	s := &webDAVStorage{base: url.URL{Scheme: "https", Host: u.Host}, user: config.Backup.WebDAVUser, password: config.Backup.WebDAVPassword}
+	if u.Scheme == "webdav" {
+		s.base.Scheme = "http"
+	}
+	if u.User != nil {
+		s.user = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	return s
+}
+
+func (s *webDAVStorage) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	u := s.base
+	u.Path = "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if s.user != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	return req, nil
+}
+
+func (s *webDAVStorage) Put(ctx context.Context, key string, data []byte) error {
+	req, err := s.request(ctx, http.MethodPut, key, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp, err := doRemote(req, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *webDAVStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := doRemote(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// s3 compatible storage, like aws, minio or cloudflare r2, with path-style
+// urls and signature version 4
+type s3Storage struct {
+	bucket    string
+	endpoint  string
+	region    string
+	accessKey string
+	secretKey string
+}
+
+func newS3Storage(bucket string) *s3Storage {
+	c := config.Backup
+	endpoint := c.S3Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + c.S3Region + ".amazonaws.com"
+	}
+	return &s3Storage{
+		bucket:    bucket,
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		region:    c.S3Region,
+		accessKey: c.S3AccessKey,
+		secretKey: c.S3SecretKey,
+	}
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	resp, err := doRemote(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := doRemote(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// signed request for the object key
+func (s *s3Storage) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("s3 credentials are missing, set s3_access_key and s3_secret_key in [backup] of the config file, or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	canonicalPath := "/" + awsEscape(s.bucket) + "/" + awsEscape(key)
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+canonicalPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, canonicalPath, body, time.Now().UTC())
+	return req, nil
+}
+
+// add the authorization header of aws signature version 4, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *s3Storage) sign(req *http.Request, canonicalPath string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		"", // no query string
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uri encode a path for signing, everything but unreserved characters and
+// slashes is escaped, url.PathEscape leaves some reserved characters
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// key of a new backup under the remote key, which is used as is if it
+// names a .sqlite file
+func remoteBackupKey(key string) string {
+	if strings.HasSuffix(key, ".sqlite") {
+		return key
+	}
+	return path.Join(key, time.Now().Format("20060102-150405.000")+".sqlite")
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/w2rclient"
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// commands run with -remote call the api of a w2r server with c instead of
+// opening the database, only the commands with such a function can
+type remoteCommand func(ctx context.Context, c *w2rclient.Client, args []string) error
+
+// names of the commands which can run with -remote
+func remoteCommandNames() string {
+	var names []string
+	for _, c := range commands {
+		if c.remote != nil {
+			names = append(names, c.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// run cmd against the server at remote
+func runRemote(cmd *command, args []string, remote, token, deck string) error {
+	if cmd.remote == nil {
+		return fmt.Errorf("can't run with -remote, only %s can", remoteCommandNames())
+	}
+	c := w2rclient.New(remote, token)
+	c.Deck = deck
+	c.HTTPClient = httpClient
+	return cmd.remote(context.Background(), c, args)
+}
+
+// a date of the api as local date, "-" when unknown
+func formatRemoteDate(t time.Time) string {
+	return formatDate(sql.NullTime{Time: t, Valid: !t.IsZero()})
+}
+
+func remoteAdd(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("add", "<word>[,<word>...] ... | -")
+	tags := fs.String("tag", "", "comma separated tags of the words")
+	note := fs.String("note", "", "personal note of the words")
+	context := fs.String("context", "", "sentence where the words were seen")
+	source := fs.String("source", "", "where the words are from, like a book title and chapter")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var words []string
+	if (len(args) == 1 && args[0] == "-") || (len(args) == 0 && stdinIsPipe()) {
+		words, err = readWords(os.Stdin)
+		if err != nil {
+			return err
+		}
+	} else if len(args) > 0 {
+		words = filterWords(strings.Join(args, ","))
+	} else {
+		fs.Usage()
+		return errUsage
+	}
+
+	failed := 0
+	for _, word := range words {
+		result, created, err := c.AddWord(ctx, w2rclient.WordRequest{Word: word, Tags: parseTags(*tags), Note: *note, Context: *context, Source: *source})
+		switch {
+		case err != nil:
+			failed++
+			log.Printf("add word '%s': %v", word, err)
+		case created && result.Level != "":
+			log.Printf("add word '%s', level %s", word, result.Level)
+		case created:
+			log.Printf("add word '%s'", word)
+		default:
+			log.Printf("word '%s' already in database, added_count++", word)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to add %d of %d word(s)", failed, len(words))
+	}
+	return nil
+}
+
+func remoteDel(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("del", "<word> ...")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	// delete the rest even if some words fail
+	var errs []error
+	for _, word := range args {
+		if err := c.DeleteWord(ctx, word); err != nil {
+			errs = append(errs, fmt.Errorf("del word '%s': %w", word, err))
+			continue
+		}
+		log.Printf("del word '%s', moved to trash", word)
+	}
+	return errors.Join(errs...)
+}
+
+func remoteSetTrans(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("set-trans", "<word> <translation>")
+	lang := fs.String("lang", "", "language of translations like zh-CN or fr, default the language of the server")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	word := wordstore.NormalizeWord(args[0])
+	if _, err := c.SetTranslation(ctx, word, *lang, strings.Join(args[1:], " ")); err != nil {
+		return fmt.Errorf("set translation of '%s': %w", word, err)
+	}
+	log.Printf("set translation of '%s'", word)
+	return nil
+}
+
+func remoteList(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("list", "")
+	var opts w2rclient.ListOptions
+	limit := fs.Int("limit", 0, "show at most N words")
+	filter := fs.String("filter", "", "only show words or translations containing substring")
+	fs.StringVar(&opts.Tag, "tag", "", "only show words with tag")
+	fs.StringVar(&opts.Level, "level", "", "only show words of cefr level "+strings.Join(wordstore.Levels, "|"))
+	fs.StringVar(&opts.Source, "source", "", "only show words from sources starting with this, like a book title")
+	fs.BoolVar(&opts.Archived, "archived", false, "only show archived words, which are left out otherwise")
+	fs.StringVar(&opts.Mastery, "mastery", "", "only show words of mastery state "+strings.Join(wordstore.MasteryStates, "|"))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts.Level = strings.ToUpper(opts.Level)
+	if opts.Level != "" && !slices.Contains(wordstore.Levels, opts.Level) {
+		return fmt.Errorf("unknown level %q, available: %s", opts.Level, strings.Join(wordstore.Levels, "|"))
+	}
+	if opts.Mastery != "" && !slices.Contains(wordstore.MasteryStates, opts.Mastery) {
+		return fmt.Errorf("unknown mastery state %q, available: %s", opts.Mastery, strings.Join(wordstore.MasteryStates, "|"))
+	}
+	words, err := c.ListWords(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%15s %-18s %-5s %10s %12s %10s %10s %-12s\n", "Word", "IPA", "Level", "Added Count", "Lookup Count", "Created", "Updated", "Translation")
+	shown := 0
+	for _, word := range words {
+		if *filter != "" && !strings.Contains(word.Word, *filter) && !strings.Contains(word.ZhTrans, *filter) {
+			continue
+		}
+		if *limit > 0 && shown == *limit {
+			break
+		}
+		shown++
+		fmt.Printf("%15s %-18s %-5s %10d %12d %10s %10s %-12s\n",
+			word.Word, word.Phonetic, word.Level, word.AddedCount, word.LookupCount, formatRemoteDate(word.CreatedAt), formatRemoteDate(word.UpdatedAt), word.ZhTrans)
+	}
+	return nil
+}
+
+func remoteSearch(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("search", "<query>")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	words, err := c.Search(ctx, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		fmt.Println("no words found")
+		return nil
+	}
+	for _, word := range words {
+		fmt.Printf("%15s  %s\n", word.Word, word.ZhTrans)
+		if word.Note != "" {
+			fmt.Printf("%15s  note: %s\n", "", word.Note)
+		}
+		if word.Context != "" {
+			fmt.Printf("%15s  “%s”\n", "", word.Context)
+		}
+	}
+	return nil
+}
+
+func remoteTags(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("tags", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	tags, err := c.Tags(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%15s %10s\n", "Tag", "Words")
+	for _, tag := range tags {
+		fmt.Printf("%15s %10d\n", tag.Name, tag.WordCount)
+	}
+	return nil
+}
+
+func remoteSources(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("sources", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	sources, err := c.Sources(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%10s  %s\n", "Words", "Source")
+	for _, source := range sources {
+		fmt.Printf("%10d  %s\n", source.WordCount, source.Source)
+	}
+	return nil
+}
+
+func remoteSentence(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("sentence", "[list] <word> | add <word> <sentence> | del <id>")
+	source := fs.String("source", "", "where the sentence is from, like a book title, for add")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "list"
+	if len(args) > 0 && slices.Contains([]string{"list", "add", "del"}, args[0]) {
+		action, args = args[0], args[1:]
+	}
+	switch {
+	case action == "list" && len(args) == 1:
+		word := wordstore.NormalizeWord(args[0])
+		sentences, err := c.Sentences(ctx, word)
+		if err != nil {
+			return fmt.Errorf("sentences of '%s': %w", word, err)
+		}
+		if len(sentences) == 0 {
+			fmt.Printf("no sentences of '%s' yet\n", word)
+			return nil
+		}
+		for _, s := range sentences {
+			line := fmt.Sprintf("%4d) %s", s.ID, s.Sentence)
+			if s.Source != "" {
+				line += " — " + s.Source
+			}
+			fmt.Println(line)
+		}
+		return nil
+	case action == "add" && len(args) >= 2:
+		word := wordstore.NormalizeWord(args[0])
+		if _, err := c.AddSentence(ctx, word, strings.Join(args[1:], " "), *source); err != nil {
+			return fmt.Errorf("add sentence of '%s': %w", word, err)
+		}
+		log.Printf("add sentence of '%s'", word)
+		return nil
+	case action == "del" && len(args) == 1:
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			break
+		}
+		if err := c.DeleteSentence(ctx, id); err != nil {
+			return fmt.Errorf("del sentence %d: %w", id, err)
+		}
+		log.Printf("del sentence %d", id)
+		return nil
+	}
+	fs.Usage()
+	return errUsage
+}
+
+func remoteRelated(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("related", "<word>")
+	refresh := fs.Bool("refresh", false, "fetch again instead of showing the stored words")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	word := wordstore.NormalizeWord(args[0])
+	related, err := c.Related(ctx, word, *refresh)
+	if err != nil {
+		return fmt.Errorf("related words of '%s': %w", word, err)
+	}
+	if len(related.Synonyms) == 0 && len(related.Antonyms) == 0 {
+		fmt.Printf("no related words of '%s' found\n", word)
+		return nil
+	}
+	for _, kind := range []struct {
+		name  string
+		words []w2rclient.RelatedWord
+	}{{wordstore.Synonym, related.Synonyms}, {wordstore.Antonym, related.Antonyms}} {
+		if len(kind.words) == 0 {
+			continue
+		}
+		names := make([]string, len(kind.words))
+		for i, r := range kind.words {
+			names[i] = r.Word
+			if r.Added {
+				names[i] += "*"
+			}
+		}
+		fmt.Printf("%ss: %s\n", kind.name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func remoteEnrich(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("enrich", "<word> ...")
+	force := fs.Bool("force", false, "generate again instead of showing the cached result")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	failed := 0
+	for _, word := range args {
+		e, err := c.Enrich(ctx, word, *force)
+		if err != nil {
+			failed++
+			log.Printf("enrich '%s': %v", word, err)
+			continue
+		}
+		fmt.Printf("%s\n", e.Word)
+		fmt.Printf("  definition: %s\n", e.Definition)
+		fmt.Printf("  mnemonic:   %s\n", e.Mnemonic)
+		for _, example := range e.Examples {
+			fmt.Printf("  - %s\n", example)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to enrich %d of %d word(s)", failed, len(args))
+	}
+	return nil
+}
+
+func remoteWotd(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("wotd", "")
+	short := fs.Bool("short", false, "print only the word, for shell prompts")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	wotd, err := c.WordOfTheDay(ctx)
+	if err != nil {
+		return err
+	}
+	if *short || wotd.ZhTrans == "" {
+		fmt.Println(wotd.Word)
+		return nil
+	}
+	fmt.Printf("%s: %s\n", wotd.Word, wotd.ZhTrans)
+	return nil
+}
+
+func remoteStats(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("stats", "")
+	days := fs.Int("days", 7, "number of days of words added per day")
+	weeks := fs.Int("weeks", 4, "number of weeks of words added per week")
+	months := fs.Int("months", 6, "number of months of words added per month")
+	top := fs.Int("top", 10, "number of most looked up words")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *days < 0 || *weeks < 0 || *months < 0 || *top < 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	stats, err := c.Stats(ctx, *top)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("words:     %d, translated %d, due %d\n", stats.Words, stats.Translated, stats.Due)
+	fmt.Printf("tags:      %d\n", stats.Tags)
+	var avgAdded float64
+	if stats.Words > 0 {
+		avgAdded = float64(stats.AddedCount) / float64(stats.Words)
+	}
+	fmt.Printf("added:     %d times again, %.1f per word\n", stats.AddedCount, avgAdded)
+	fmt.Printf("lookups:   %d\n", stats.LookupCount)
+	fmt.Printf("levels:    %s\n", formatLevels(stats.Levels))
+	fmt.Printf("mastery:   %s, archived %d\n", formatMastery(stats.Mastery), stats.Archived)
+	if streak := stats.Streak; streak.GoalWords > 0 || streak.GoalReviews > 0 {
+		fmt.Printf("streak:    %d day(s), longest %d, today %d/%d new words, %d/%d reviews\n",
+			streak.Current, streak.Longest, streak.Words, streak.GoalWords, streak.Reviews, streak.GoalReviews)
+	}
+
+	// days of the server
+	printAddedPeriods(stats.AddedPerDay, *days, *weeks, *months)
+	if len(stats.TopLookups) > 0 {
+		fmt.Printf("\n%15s %10s %s\n", "Top lookups", "Lookups", "Translation")
+		for _, row := range stats.TopLookups {
+			fmt.Printf("%15s %10d %s\n", row.Word, row.LookupCount, row.ZhTrans)
+		}
+	}
+	printGrowth(stats.Words, stats.AddedPerDay)
+	return nil
+}
+
+// review due words with line based prompts, like review -plain, the words
+// are scheduled by the server
+func remoteReview(ctx context.Context, c *w2rclient.Client, args []string) error {
+	fs := newFlagSet("review", "")
+	limit := fs.Int("n", config.Review.Limit, "max number of words to review")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	total := 0
+	for i := 0; i == 0 || i < total; i++ {
+		due, err := c.NextReview(ctx)
+		if err != nil {
+			return err
+		}
+		if due == nil {
+			if i == 0 {
+				fmt.Println("no words due for review")
+			}
+			return nil
+		}
+		if i == 0 {
+			total = due.Due
+			if *limit > 0 {
+				total = min(total, *limit)
+			}
+		}
+
+		fmt.Printf("\n[%d/%d] %s\n", i+1, total, due.Word)
+		shown := time.Now()
+		fmt.Print("press enter to show translation...")
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		fmt.Printf("  %s\n", cmp.Or(due.ZhTrans, "(no translation)"))
+
+		for {
+			fmt.Print("grade [1]again [2]hard [3]good [4]easy [q]uit: ")
+			if !scanner.Scan() {
+				return scanner.Err()
+			}
+			text := scanner.Text()
+			if strings.TrimSpace(text) == "q" {
+				return nil
+			}
+			g, ok := parseGrade(text)
+			if !ok {
+				continue
+			}
+			result, err := c.AnswerReview(ctx, due.Word, g.String(), time.Since(shown))
+			if err != nil {
+				return err
+			}
+			switch {
+			case result.Mastery == wordstore.MasteryMastered:
+				fmt.Println("  mastered, archived")
+			case result.Box > 0:
+				fmt.Printf("  box %d, next review in %d day(s)\n", result.Box, result.IntervalDays)
+			default:
+				fmt.Printf("  next review in %d day(s)\n", result.IntervalDays)
+			}
+			break
+		}
+	}
+	return nil
+}
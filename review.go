@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// self-assessment of a review, mapped to SM-2 quality 0~5
+type Grade int
+
+const (
+	GradeAgain Grade = 1
+	GradeHard  Grade = 3
+	GradeGood  Grade = 4
+	GradeEasy  Grade = 5
+)
+
+const (
+	defaultEase = 2.5
+	minEase     = 1.3
+)
+
+// parse a grade typed by user, accepts 1~4 or the grade name
+func parseGrade(s string) (Grade, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "1", "again", "a":
+		return GradeAgain, true
+	case "2", "hard", "h":
+		return GradeHard, true
+	case "3", "good", "g":
+		return GradeGood, true
+	case "4", "easy", "e":
+		return GradeEasy, true
+	}
+	return 0, false
+}
+
+// name of the grade, again, hard, good or easy
+func (g Grade) String() string {
+	switch g {
+	case GradeAgain:
+		return "again"
+	case GradeHard:
+		return "hard"
+	case GradeGood:
+		return "good"
+	case GradeEasy:
+		return "easy"
+	}
+	return fmt.Sprintf("grade %d", int(g))
+}
+
+// scheduling state of a word
+type Card struct {
+	Ease         float64
+	IntervalDays int64
+	Repetitions  int64
+}
+
+// compute the next state of card with the SM-2 algorithm
+func (c Card) Next(g Grade) Card {
+	q := float64(g)
+	if c.Ease == 0 {
+		c.Ease = defaultEase
+	}
+
+	if g < GradeHard {
+		c.Repetitions = 0
+		c.IntervalDays = 1
+	} else {
+		switch c.Repetitions {
+		case 0:
+			c.IntervalDays = 1
+		case 1:
+			c.IntervalDays = 6
+		default:
+			c.IntervalDays = int64(math.Round(float64(c.IntervalDays) * c.Ease))
+		}
+		c.Repetitions++
+	}
+
+	c.Ease += 0.1 - (5-q)*(0.08+(5-q)*0.02)
+	if c.Ease < minEase {
+		c.Ease = minEase
+	}
+	return c
+}
+
+// review algorithms of the review config
+const (
+	algorithmSM2     = "sm2"
+	algorithmLeitner = "leitner"
+)
+
+// number of boxes of the leitner system
+const leitnerBoxes = 5
+
+// box of the leitner system of card, from 1 of new and forgotten words to
+// leitnerBoxes, the number of correct answers in a row moves it up
+func (c Card) Box() int {
+	return int(min(c.Repetitions, leitnerBoxes-1)) + 1
+}
+
+// compute the next state of card with the leitner system, a simpler
+// alternative to SM-2: a correct answer moves the word up a box, a wrong one
+// back to box 1, and it's reviewed again after the interval of its box in
+// intervals. Hard counts as correct like in SM-2.
+func (c Card) NextLeitner(g Grade, intervals []int) Card {
+	if c.Ease == 0 {
+		c.Ease = defaultEase
+	}
+	if g < GradeHard {
+		c.Repetitions = 0
+	} else {
+		c.Repetitions++
+	}
+	c.IntervalDays = int64(intervals[c.Box()-1])
+	return c
+}
+
+// check the algorithm of the review config and its settings
+func checkReviewAlgorithm() error {
+	switch config.Review.Algorithm {
+	case "", algorithmSM2:
+		return nil
+	case algorithmLeitner:
+		intervals := config.Review.LeitnerIntervals
+		if len(intervals) != leitnerBoxes || slices.ContainsFunc(intervals, func(days int) bool { return days < 1 }) {
+			return fmt.Errorf("leitner_intervals in [review] of the config needs %d intervals of 1 day or more", leitnerBoxes)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown algorithm %q in [review] of the config, available: %s|%s", config.Review.Algorithm, algorithmSM2, algorithmLeitner)
+}
+
+// compute the next state of card with the algorithm of the review config
+func nextCard(c Card, g Grade) (Card, error) {
+	if err := checkReviewAlgorithm(); err != nil {
+		return c, err
+	}
+	if config.Review.Algorithm == algorithmLeitner {
+		return c.NextLeitner(g, config.Review.LeitnerIntervals), nil
+	}
+	return c.Next(g), nil
+}
+
+// the next review of card, with its box in the leitner system
+func (c Card) describe() string {
+	if config.Review.Algorithm == algorithmLeitner {
+		return fmt.Sprintf("box %d, next review in %d day(s)", c.Box(), c.IntervalDays)
+	}
+	return fmt.Sprintf("next review in %d day(s)", c.IntervalDays)
+}
+
+// mastery state of a word with the card, mastered words are archived after
+// archive_after successful reviews in a row
+func (c Card) Mastery() string {
+	return wordstore.Mastery(c.IntervalDays, c.Repetitions, config.Review.ArchiveAfter)
+}
+
+// schedule the next review of word, update its mastery state, archiving it
+// when mastered, count the review for the daily goal and log it with the
+// time taken to answer, 0 if unknown
+func (w *WordDB) gradeWord(ctx context.Context, word string, card Card, g Grade, latency time.Duration) (Card, error) {
+	next, err := nextCard(card, g)
+	if err != nil {
+		return card, err
+	}
+	err = w.Store.Tx(ctx, func(queries *worddb.Queries) error {
+		now := time.Now()
+		err := queries.UpsertReview(ctx, worddb.UpsertReviewParams{
+			Word:         word,
+			Ease:         next.Ease,
+			IntervalDays: next.IntervalDays,
+			Repetitions:  next.Repetitions,
+			DueAt:        now.UTC().AddDate(0, 0, int(next.IntervalDays)),
+		})
+		if err != nil {
+			return err
+		}
+		if err := wordstore.UpdateMastery(ctx, queries, word, next.Mastery()); err != nil {
+			return err
+		}
+		err = queries.AddReviewLog(ctx, worddb.AddReviewLogParams{
+			Word:             word,
+			Grade:            int64(g),
+			LatencyMs:        sql.NullInt64{Int64: latency.Milliseconds(), Valid: latency > 0},
+			IntervalDays:     card.IntervalDays,
+			NextIntervalDays: next.IntervalDays,
+			ReviewedAt:       now.UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		return queries.AddDailyReview(ctx, now.Format(time.DateOnly))
+	})
+	return next, err
+}
+
+func (w *WordDB) dueWords(limit int) ([]worddb.ListDueWordsRow, error) {
+	queries := worddb.New(w.Db)
+	return queries.ListDueWords(w.Ctx, worddb.ListDueWordsParams{DueAt: time.Now().UTC(), DeckID: w.DeckID, Limit: int64(limit)})
+}
+
+func cardOf(word worddb.ListDueWordsRow) Card {
+	return Card{Ease: word.Ease.Float64, IntervalDays: word.IntervalDays.Int64, Repetitions: word.Repetitions.Int64}
+}
+
+// present due words one by one, and record grades read from in
+func (w *WordDB) Review(in io.Reader, limit int) error {
+	words, err := w.dueWords(limit)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		fmt.Println("no words due for review")
+		return nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	for i, word := range words {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(words), word.Word)
+		shown := time.Now()
+		fmt.Print("press enter to show translation...")
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		zhTrans := "(no translation)"
+		if word.ZhTrans.Valid {
+			zhTrans = word.ZhTrans.String
+		}
+		fmt.Printf("  %s\n", zhTrans)
+
+		card := cardOf(word)
+		for {
+			fmt.Print("grade [1]again [2]hard [3]good [4]easy [q]uit: ")
+			if !scanner.Scan() {
+				return scanner.Err()
+			}
+			text := scanner.Text()
+			if strings.TrimSpace(text) == "q" {
+				return nil
+			}
+			g, ok := parseGrade(text)
+			if !ok {
+				continue
+			}
+			next, err := w.gradeWord(w.Ctx, word.Word, card, g, time.Since(shown))
+			if err != nil {
+				return err
+			}
+			if next.Mastery() == wordstore.MasteryMastered {
+				fmt.Println("  mastered, archived")
+			} else {
+				fmt.Printf("  %s\n", next.describe())
+			}
+			break
+		}
+	}
+	return nil
+}
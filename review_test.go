@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCardNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		card  Card
+		grade Grade
+		want  Card
+	}{
+		{"new good", Card{}, GradeGood, Card{Ease: 2.5, IntervalDays: 1, Repetitions: 1}},
+		{"new easy", Card{}, GradeEasy, Card{Ease: 2.6, IntervalDays: 1, Repetitions: 1}},
+		{"new hard", Card{}, GradeHard, Card{Ease: 2.36, IntervalDays: 1, Repetitions: 1}},
+		{"new again", Card{}, GradeAgain, Card{Ease: 1.96, IntervalDays: 1, Repetitions: 0}},
+		{"second good", Card{Ease: 2.5, IntervalDays: 1, Repetitions: 1}, GradeGood, Card{Ease: 2.5, IntervalDays: 6, Repetitions: 2}},
+		{"third good", Card{Ease: 2.5, IntervalDays: 6, Repetitions: 2}, GradeGood, Card{Ease: 2.5, IntervalDays: 15, Repetitions: 3}},
+		{"third easy", Card{Ease: 2.5, IntervalDays: 6, Repetitions: 2}, GradeEasy, Card{Ease: 2.6, IntervalDays: 15, Repetitions: 3}},
+		{"forgotten", Card{Ease: 2.5, IntervalDays: 15, Repetitions: 3}, GradeAgain, Card{Ease: 1.96, IntervalDays: 1, Repetitions: 0}},
+		{"min ease", Card{Ease: 1.4, IntervalDays: 6, Repetitions: 2}, GradeAgain, Card{Ease: minEase, IntervalDays: 1, Repetitions: 0}},
+	}
+	for _, tt := range tests {
+		got := tt.card.Next(tt.grade)
+		if math.Abs(got.Ease-tt.want.Ease) > 1e-9 || got.IntervalDays != tt.want.IntervalDays || got.Repetitions != tt.want.Repetitions {
+			t.Errorf("%s: %+v.Next(%s) = %+v, want %+v", tt.name, tt.card, tt.grade, got, tt.want)
+		}
+	}
+}
+
+func TestCardNextLeitner(t *testing.T) {
+	intervals := []int{1, 3, 7, 14, 30}
+	tests := []struct {
+		name    string
+		card    Card
+		grade   Grade
+		wantBox int
+		want    Card
+	}{
+		{"new good", Card{}, GradeGood, 2, Card{Ease: defaultEase, IntervalDays: 3, Repetitions: 1}},
+		{"new hard", Card{}, GradeHard, 2, Card{Ease: defaultEase, IntervalDays: 3, Repetitions: 1}},
+		{"new again", Card{}, GradeAgain, 1, Card{Ease: defaultEase, IntervalDays: 1, Repetitions: 0}},
+		{"box 3 easy", Card{Ease: 2.5, IntervalDays: 7, Repetitions: 2}, GradeEasy, 4, Card{Ease: 2.5, IntervalDays: 14, Repetitions: 3}},
+		{"last box", Card{Ease: 2.5, IntervalDays: 30, Repetitions: 4}, GradeGood, 5, Card{Ease: 2.5, IntervalDays: 30, Repetitions: 5}},
+		{"forgotten", Card{Ease: 2.5, IntervalDays: 30, Repetitions: 6}, GradeAgain, 1, Card{Ease: 2.5, IntervalDays: 1, Repetitions: 0}},
+		{"keeps sm-2 ease", Card{Ease: 1.8, IntervalDays: 6, Repetitions: 1}, GradeGood, 3, Card{Ease: 1.8, IntervalDays: 7, Repetitions: 2}},
+	}
+	for _, tt := range tests {
+		got := tt.card.NextLeitner(tt.grade, intervals)
+		if got != tt.want || got.Box() != tt.wantBox {
+			t.Errorf("%s: %+v.NextLeitner(%s) = %+v in box %d, want %+v in box %d", tt.name, tt.card, tt.grade, got, got.Box(), tt.want, tt.wantBox)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// reviews of the log since, the latest first, of word if not empty, at most
+// limit reviews, all if it's -1
+func (w *WordDB) reviewLog(ctx context.Context, deckID int64, word string, since time.Time, limit int) ([]worddb.ReviewLog, error) {
+	return w.Store.Queries().ListReviewLog(ctx, worddb.ListReviewLogParams{
+		Since:  since.UTC(),
+		Word:   word,
+		DeckID: deckID,
+		Limit:  int64(limit),
+	})
+}
+
+// a review is correct if the word is remembered, hard counts as correct like
+// in the scheduling
+func correct(grade int64) bool {
+	return Grade(grade) >= GradeHard
+}
+
+// totals of reviews of the log
+type reviewSummary struct {
+	Reviews int64
+	Correct int64
+	Grades  map[Grade]int64
+	// sum and number of the recorded times taken to answer
+	latency   time.Duration
+	latencies int64
+}
+
+func summarizeReviews(logs []worddb.ReviewLog) reviewSummary {
+	s := reviewSummary{Grades: make(map[Grade]int64)}
+	for _, review := range logs {
+		s.Reviews++
+		if correct(review.Grade) {
+			s.Correct++
+		}
+		s.Grades[Grade(review.Grade)]++
+		if review.LatencyMs.Valid {
+			s.latency += time.Duration(review.LatencyMs.Int64) * time.Millisecond
+			s.latencies++
+		}
+	}
+	return s
+}
+
+// percentage of correct reviews, 0 without reviews
+func (s reviewSummary) Accuracy() float64 {
+	if s.Reviews == 0 {
+		return 0
+	}
+	return float64(s.Correct) * 100 / float64(s.Reviews)
+}
+
+// average time taken to answer, 0 if it's never recorded
+func (s reviewSummary) AverageLatency() time.Duration {
+	if s.latencies == 0 {
+		return 0
+	}
+	return s.latency / time.Duration(s.latencies)
+}
+
+// print the reviews of the last days: totals, reviews per day, the retention
+// curve of all reviews and the words forgotten most often
+func (w *WordDB) ShowReviewStats(days, top int) error {
+	today := startOfDay(time.Now())
+	since := today.AddDate(0, 0, 1-days)
+	logs, err := w.reviewLog(w.Ctx, w.DeckID, "", since, -1)
+	if err != nil {
+		return err
+	}
+	summary := summarizeReviews(logs)
+	fmt.Printf("reviews:   %d in the last %d day(s), %.0f%% correct", summary.Reviews, days, summary.Accuracy())
+	if latency := summary.AverageLatency(); latency > 0 {
+		fmt.Printf(", %.1fs per answer on average", latency.Seconds())
+	}
+	fmt.Println()
+	fmt.Printf("grades:    again %d, hard %d, good %d, easy %d\n",
+		summary.Grades[GradeAgain], summary.Grades[GradeHard], summary.Grades[GradeGood], summary.Grades[GradeEasy])
+	if len(logs) > 0 {
+		perDay := make(map[string][]worddb.ReviewLog)
+		for _, review := range logs {
+			day := review.ReviewedAt.Local().Format(time.DateOnly)
+			perDay[day] = append(perDay[day], review)
+		}
+		fmt.Printf("\n%15s %10s %8s\n", "Reviews per day", "Reviews", "Correct")
+		for _, p := range lastPeriods(days, today, addDays, "2006-01-02 Mon") {
+			day := summarizeReviews(perDay[p.start.Format(time.DateOnly)])
+			if day.Reviews == 0 {
+				fmt.Printf("%15s %10d %8s\n", p.label, 0, "-")
+				continue
+			}
+			fmt.Printf("%15s %10d %7.0f%%\n", p.label, day.Reviews, day.Accuracy())
+		}
+	}
+
+	// the retention curve is of the whole log, not only the last days
+	all, err := w.reviewLog(w.Ctx, w.DeckID, "", time.Time{}, -1)
+	if err != nil {
+		return err
+	}
+	curve, _ := retention(all)
+	if curve.summary().Reviews > 0 {
+		fmt.Printf("\n%15s %10s %8s\n", "Retention after", "Reviews", "Correct")
+		for i, bucket := range curve {
+			if bucket.Reviews == 0 {
+				fmt.Printf("%15s %10d %8s\n", retentionBuckets[i].Label, 0, "-")
+				continue
+			}
+			fmt.Printf("%15s %10d %7.0f%%\n", retentionBuckets[i].Label, bucket.Reviews, bucket.Accuracy())
+		}
+	}
+
+	if top > 0 {
+		var words []string
+		forgotten := make(map[string]int64)
+		reviews := make(map[string]int64)
+		for _, review := range logs {
+			if reviews[review.Word] == 0 {
+				words = append(words, review.Word)
+			}
+			reviews[review.Word]++
+			if Grade(review.Grade) == GradeAgain {
+				forgotten[review.Word]++
+			}
+		}
+		// the words forgotten most often first, then the words reviewed most
+		slices.SortStableFunc(words, func(a, b string) int {
+			return cmp.Or(cmp.Compare(forgotten[b], forgotten[a]), cmp.Compare(reviews[b], reviews[a]))
+		})
+		for i, word := range words[:min(top, len(words))] {
+			if forgotten[word] == 0 {
+				break
+			}
+			if i == 0 {
+				fmt.Printf("\n%15s %10s %8s\n", "Most forgotten", "Again", "Reviews")
+			}
+			fmt.Printf("%15s %10d %8d\n", word, forgotten[word], reviews[word])
+		}
+	}
+	return nil
+}
+
+// reply the review log, the latest first, ?word= selects the reviews of a
+// word, ?since=2006-01-02 or an RFC 3339 time the reviews since then, and
+// ?limit=n the number of reviews, 1000 by default
+func (w *WordDB) apiReviewHistory(rw http.ResponseWriter, r *http.Request) {
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	var since time.Time
+	if s := query.Get("since"); s != "" {
+		var err error
+		if since, err = time.ParseInLocation(time.DateOnly, s, time.Local); err != nil {
+			if since, err = time.Parse(time.RFC3339, s); err != nil {
+				writeJSONError(rw, http.StatusBadRequest, "invalid since, expected 2006-01-02 or an RFC 3339 time")
+				return
+			}
+		}
+	}
+	limit := min(max(queryInt(query, "limit", 1000), 1), 10000)
+	logs, err := w.reviewLog(r.Context(), deckID, query.Get("word"), since, limit)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	type apiReview struct {
+		Word       string    `json:"word"`
+		ReviewedAt time.Time `json:"reviewed_at"`
+		// again, hard, good or easy
+		Grade   string `json:"grade"`
+		Correct bool   `json:"correct"`
+		// milliseconds taken to answer, omitted if unknown
+		LatencyMs *int64 `json:"latency_ms,omitempty"`
+		// interval scheduled before the review, 0 for the first review, and
+		// after the review
+		IntervalDays     int64 `json:"interval_days"`
+		NextIntervalDays int64 `json:"next_interval_days"`
+	}
+	results := make([]apiReview, 0, len(logs))
+	for _, review := range logs {
+		result := apiReview{
+			Word:             review.Word,
+			ReviewedAt:       review.ReviewedAt,
+			Grade:            Grade(review.Grade).String(),
+			Correct:          correct(review.Grade),
+			IntervalDays:     review.IntervalDays,
+			NextIntervalDays: review.NextIntervalDays,
+		}
+		if review.LatencyMs.Valid {
+			result.LatencyMs = &review.LatencyMs.Int64
+		}
+		results = append(results, result)
+	}
+	writeJSON(rw, http.StatusOK, results)
+}
+
+// a range of intervals between reviews of a word, for the retention curve
+type retentionBucket struct {
+	Label string
+	// longest interval of the bucket in days, the buckets before cover the
+	// shorter ones
+	MaxDays int64
+}
+
+// intervals of the retention curve from reviews again on the same day, the
+// last one covers all longer intervals
+var retentionBuckets = []retentionBucket{
+	{"<1d", 0},
+	{"1d", 1},
+	{"2-3d", 3},
+	{"4-7d", 7},
+	{"8-14d", 14},
+	{"15-30d", 30},
+	{"31-90d", 90},
+	{">90d", math.MaxInt64},
+}
+
+// index of the bucket of retentionBuckets of interval days
+func retentionBucketOf(days int64) int {
+	for i, bucket := range retentionBuckets {
+		if days <= bucket.MaxDays {
+			return i
+		}
+	}
+	return len(retentionBuckets) - 1
+}
+
+// reviews and correct reviews by bucket of retentionBuckets, the accuracy of
+// each bucket is how much is remembered after the interval
+type retentionCurve []reviewSummary
+
+// retention curve of all reviews of logs and of each word. The interval of a
+// review is the number of days since the previous review of the word in the
+// log, or the scheduled interval for the first review logged of words
+// reviewed before there was a log. First reviews of new words are left out,
+// there's nothing to remember yet.
+func retention(logs []worddb.ReviewLog) (retentionCurve, map[string]retentionCurve) {
+	total := make(retentionCurve, len(retentionBuckets))
+	perWord := make(map[string]retentionCurve)
+	// the log is the latest first
+	previous := make(map[string]time.Time)
+	for i := len(logs) - 1; i >= 0; i-- {
+		review := logs[i]
+		days := review.IntervalDays
+		if last, ok := previous[review.Word]; ok {
+			days = int64(math.Round(review.ReviewedAt.Sub(last).Hours() / 24))
+		} else if days == 0 {
+			previous[review.Word] = review.ReviewedAt
+			continue
+		}
+		previous[review.Word] = review.ReviewedAt
+
+		if perWord[review.Word] == nil {
+			perWord[review.Word] = make(retentionCurve, len(retentionBuckets))
+		}
+		bucket := retentionBucketOf(days)
+		for _, curve := range []retentionCurve{total, perWord[review.Word]} {
+			curve[bucket].Reviews++
+			if correct(review.Grade) {
+				curve[bucket].Correct++
+			}
+		}
+	}
+	return total, perWord
+}
+
+// reviews and correct reviews of all buckets of the curve
+func (c retentionCurve) summary() reviewSummary {
+	var s reviewSummary
+	for _, bucket := range c {
+		s.Reviews += bucket.Reviews
+		s.Correct += bucket.Correct
+	}
+	return s
+}
+
+// words with at least minReviews reviews in the curves, the lowest retention
+// first, then the most reviewed
+func lowestRetention(perWord map[string]retentionCurve, minReviews int64) []string {
+	var words []string
+	for word, curve := range perWord {
+		if curve.summary().Reviews >= minReviews {
+			words = append(words, word)
+		}
+	}
+	slices.SortFunc(words, func(a, b string) int {
+		sa, sb := perWord[a].summary(), perWord[b].summary()
+		return cmp.Or(cmp.Compare(sa.Accuracy(), sb.Accuracy()), cmp.Compare(sb.Reviews, sa.Reviews), cmp.Compare(a, b))
+	})
+	return words
+}
+
+// reply the retention curves of the review log, of all reviews and of the
+// words with the lowest retention, ?word= selects the reviews of a word, and
+// ?top=n the number of words, 10 by default
+func (w *WordDB) apiRetention(rw http.ResponseWriter, r *http.Request) {
+	deckID, ok := w.apiDeck(rw, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	word := query.Get("word")
+	logs, err := w.reviewLog(r.Context(), deckID, word, time.Time{}, -1)
+	if err != nil {
+		writeJSONError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	total, perWord := retention(logs)
+
+	type apiBucket struct {
+		Label string `json:"label"`
+		// shortest interval of the bucket in days
+		MinDays int64 `json:"min_days"`
+		Reviews int64 `json:"reviews"`
+		Correct int64 `json:"correct"`
+		// percentage of correct reviews, 0 without reviews
+		Accuracy float64 `json:"accuracy"`
+	}
+	buckets := func(curve retentionCurve) []apiBucket {
+		result := make([]apiBucket, len(curve))
+		for i, s := range curve {
+			result[i] = apiBucket{Label: retentionBuckets[i].Label, Reviews: s.Reviews, Correct: s.Correct, Accuracy: s.Accuracy()}
+			if i > 0 {
+				result[i].MinDays = retentionBuckets[i-1].MaxDays + 1
+			}
+		}
+		return result
+	}
+	type apiWordRetention struct {
+		Word     string      `json:"word"`
+		Reviews  int64       `json:"reviews"`
+		Correct  int64       `json:"correct"`
+		Accuracy float64     `json:"accuracy"`
+		Buckets  []apiBucket `json:"buckets"`
+	}
+	// a word needs a few reviews for its retention to mean something, unless
+	// it's asked for
+	minReviews := int64(3)
+	if word != "" {
+		minReviews = 1
+	}
+	top := min(max(queryInt(query, "top", 10), 0), 100)
+	words := lowestRetention(perWord, minReviews)
+	result := struct {
+		Buckets []apiBucket        `json:"buckets"`
+		Words   []apiWordRetention `json:"words"`
+	}{
+		Buckets: buckets(total),
+		Words:   make([]apiWordRetention, 0, min(top, len(words))),
+	}
+	for _, word := range words[:min(top, len(words))] {
+		s := perWord[word].summary()
+		result.Words = append(result.Words, apiWordRetention{
+			Word:     word,
+			Reviews:  s.Reviews,
+			Correct:  s.Correct,
+			Accuracy: s.Accuracy(),
+			Buckets:  buckets(perWord[word]),
+		})
+	}
+	writeJSON(rw, http.StatusOK, result)
+}
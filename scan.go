@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// options of scanning a text for unknown words
+type ScanOptions struct {
+	// print the unknown words instead of asking which to add
+	List bool
+	// at most N words, the most frequent ones, 0 for all
+	Limit int
+	// tags of the added words
+	Tags []string
+}
+
+// a part of a text to scan, like a chapter of a book or a subtitle, and where
+// it's from, like the chapter title or the time of the subtitle
+type textPart struct {
+	Text   string
+	Source string
+}
+
+// a word of a text, with the number of times it occurs and the first
+// sentence it occurs in, and the source of its part
+type scannedWord struct {
+	Word     string
+	Count    int
+	Sentence string
+	Source   string
+}
+
+// letters joined by apostrophes or hyphens, typographic ones too
+var tokenPattern = regexp.MustCompile(`[\p{L}\p{M}]+(?:['\x{2019}\x{2010}-][\p{L}\p{M}]+)*`)
+
+// end of a sentence, punctuation followed by white space, maybe after closing
+// quotes, or a blank line
+var sentenceEnd = regexp.MustCompile(`[.!?\x{2026}]+["'\x{201d}\x{2019})\]]*\s+|\n\s*\n`)
+
+// sentences of text with their white space collapsed
+func splitSentences(text string) []string {
+	var sentences []string
+	add := func(s string) {
+		if s = strings.Join(strings.Fields(s), " "); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		add(text[start:loc[1]])
+		start = loc[1]
+	}
+	add(text[start:])
+	return sentences
+}
+
+// english contractions like it's or we'll, and possessives like cat's, which
+// are cut off the word
+var contractions = []string{"'s", "'re", "'ve", "'ll", "'d", "'m"}
+
+// a contraction of the word, or the word itself, like it of it's. Negations
+// like don't are of common verbs, so they are left out as "".
+func uncontract(word string) string {
+	if strings.HasSuffix(word, "n't") {
+		return ""
+	}
+	for _, suffix := range contractions {
+		if base, ok := strings.CutSuffix(word, suffix); ok {
+			return base
+		}
+	}
+	return word
+}
+
+// words of the parts of a text in the order they first occur. In english
+// text contractions are cut off, and words which are always capitalized are
+// left out, they are most likely names.
+func scanWords(parts []textPart) []scannedWord {
+	index := make(map[string]int)
+	lowerCase := make(map[string]bool)
+	var words []scannedWord
+	for _, part := range parts {
+		for _, sentence := range splitSentences(part.Text) {
+			for _, token := range tokenPattern.FindAllString(sentence, -1) {
+				word := wordstore.NormalizeWord(token)
+				if wordstore.IsEnglish() {
+					word = uncontract(word)
+				}
+				if !wordstore.IsValidWord(word) {
+					continue
+				}
+				if first, _ := utf8.DecodeRuneInString(token); !unicode.IsUpper(first) {
+					lowerCase[word] = true
+				}
+				if i, ok := index[word]; ok {
+					words[i].Count++
+					continue
+				}
+				index[word] = len(words)
+				words = append(words, scannedWord{Word: word, Count: 1, Sentence: sentence, Source: part.Source})
+			}
+		}
+	}
+	if wordstore.IsEnglish() {
+		words = slices.DeleteFunc(words, func(word scannedWord) bool { return !lowerCase[word.Word] })
+	}
+	return words
+}
+
+// words skipped by scan besides the words in the database, the known words
+// file and the common words of the config, and the word lists
+type knownWords struct {
+	words  map[string]bool
+	levels map[string]bool
+	// words on the word lists of the database, with their list
+	listed map[string]string
+}
+
+func loadKnownWords(path, commonLevel string) (knownWords, error) {
+	known := knownWords{words: make(map[string]bool), levels: make(map[string]bool)}
+	if commonLevel != "" {
+		i := slices.Index(wordstore.Levels, strings.ToUpper(commonLevel))
+		if i < 0 {
+			return known, fmt.Errorf("unknown level %q, available: %s", commonLevel, strings.Join(wordstore.Levels, "|"))
+		}
+		for _, level := range wordstore.Levels[:i+1] {
+			known.levels[level] = true
+		}
+	}
+	if path == "" {
+		return known, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return known, fmt.Errorf("known words: %w", err)
+	}
+	defer f.Close()
+	words, err := readWords(f)
+	if err != nil {
+		return known, fmt.Errorf("known words %s: %w", path, err)
+	}
+	for _, word := range words {
+		known.words[word] = true
+	}
+	return known, nil
+}
+
+// the known words of the config, and the word lists of the database
+func (w *WordDB) knownWords() (knownWords, error) {
+	known, err := loadKnownWords(config.Scan.KnownWords, config.Scan.CommonLevel)
+	if err != nil {
+		return known, err
+	}
+	known.listed, err = w.Store.ListedWords(w.Ctx)
+	return known, err
+}
+
+func (k knownWords) has(word string) bool {
+	return k.words[word] || k.listed[word] == wordstore.KnownList || k.levels[wordstore.Level(word)]
+}
+
+// whether word is on the ignore list, it's left out of the text
+func (k knownWords) ignored(word string) bool {
+	return k.listed[word] == wordstore.IgnoreList
+}
+
+// how much of a text is covered by the words in the database and the known
+// words, by the number of times the words occur, and the rest. Ignored words
+// aren't counted in the tokens.
+type textCoverage struct {
+	Tokens    int
+	Different int
+	Ignored   int
+	Collected int
+	Known     int
+	// words which are neither known nor in the database, by their lemma
+	// too, the most frequent first. With lemmatize in the config, forms of
+	// a word are counted as their lemma.
+	Unknown []scannedWord
+}
+
+func (w *WordDB) coverage(ctx context.Context, words []scannedWord, known knownWords) (textCoverage, error) {
+	var c textCoverage
+	index := make(map[string]int)
+	for _, word := range words {
+		lemma, err := w.Store.Lemma(ctx, word.Word)
+		if err != nil {
+			return c, err
+		}
+		if known.ignored(word.Word) || known.ignored(lemma) {
+			c.Ignored += word.Count
+			continue
+		}
+		c.Tokens += word.Count
+		c.Different++
+		// the lemma of a word in the database is the word itself
+		exists, err := w.Store.Exists(ctx, lemma)
+		if err != nil {
+			return c, err
+		}
+		switch {
+		case exists:
+			c.Collected += word.Count
+			continue
+		case known.has(word.Word) || known.has(lemma):
+			c.Known += word.Count
+			continue
+		}
+		if config.Lemmatize {
+			word.Word = lemma
+		}
+		if i, ok := index[word.Word]; ok {
+			c.Unknown[i].Count += word.Count
+			continue
+		}
+		index[word.Word] = len(c.Unknown)
+		c.Unknown = append(c.Unknown, word)
+	}
+	sort.SliceStable(c.Unknown, func(i, j int) bool { return c.Unknown[i].Count > c.Unknown[j].Count })
+	return c, nil
+}
+
+// answers of which words of a text to add, and the words to put on a word
+// list instead, by list
+type scanAnswers struct {
+	Add    []scannedWord
+	Listed map[string][]string
+}
+
+// ask which of words to add, reading answers from in
+func askWords(in io.Reader, words []scannedWord) (scanAnswers, error) {
+	scanner := bufio.NewScanner(in)
+	answers := scanAnswers{Listed: make(map[string][]string)}
+	for i, word := range words {
+		fmt.Printf("\n[%d/%d] %s ×%d\n", i+1, len(words), word.Word, word.Count)
+		fmt.Printf("  “%s” — %s\n", word.Sentence, word.Source)
+		fmt.Print("add? [y]es, [n]o, [k]nown, [i]gnore, [a]ll the rest or [q]uit: ")
+		if !scanner.Scan() {
+			return answers, scanner.Err()
+		}
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "y", "yes":
+			answers.Add = append(answers.Add, word)
+		case "k", "known":
+			answers.Listed[wordstore.KnownList] = append(answers.Listed[wordstore.KnownList], word.Word)
+		case "i", "ignore":
+			answers.Listed[wordstore.IgnoreList] = append(answers.Listed[wordstore.IgnoreList], word.Word)
+		case "a", "all":
+			answers.Add = append(answers.Add, words[i:]...)
+			return answers, nil
+		case "q", "quit":
+			return answers, nil
+		}
+	}
+	return answers, nil
+}
+
+// read the text at path to scan, stdin if it's "-". EPUB books are read by
+// chapter, subtitles by cue, and the readable text of html files is read.
+func readScanFile(path string) ([]textPart, error) {
+	source := filepath.Base(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epub":
+		return readEPUB(path)
+	case ".srt", ".vtt":
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseSubtitles(string(text), source), nil
+	}
+
+	var text []byte
+	var err error
+	if path == "-" {
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm", ".xhtml":
+		return []textPart{{Text: readableText(string(text)), Source: source}}, nil
+	}
+	return []textPart{{Text: string(text), Source: source}}, nil
+}
+
+// the text to scan, the readable text of the web page at pageURL if it's set,
+// or of the file at path
+func readScanInput(ctx context.Context, path, pageURL string) ([]textPart, error) {
+	if pageURL == "" {
+		return readScanFile(path)
+	}
+	text, title, err := fetchReadable(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	return []textPart{{Text: text, Source: title}}, nil
+}
+
+// find the words of the parts of a text which aren't in the database, known
+// or ignored, and ask which to add, with the sentence they occur in as example
+// sentence and the source of its part as their source, or which to put on a
+// word list
+func (w *WordDB) Scan(parts []textPart, opts ScanOptions) error {
+	known, err := w.knownWords()
+	if err != nil {
+		return err
+	}
+
+	words := scanWords(parts)
+	c, err := w.coverage(w.Ctx, words, known)
+	if err != nil {
+		return err
+	}
+	unknown := c.Unknown
+	log.Printf("%d unknown of %d different words", len(unknown), len(words))
+	if opts.Limit > 0 && len(unknown) > opts.Limit {
+		unknown = unknown[:opts.Limit]
+	}
+	if opts.List {
+		for _, word := range unknown {
+			fmt.Println(word.Word)
+		}
+		return nil
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	answers, err := askWords(os.Stdin, unknown)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	for _, list := range wordstore.WordLists {
+		if len(answers.Listed[list]) == 0 {
+			continue
+		}
+		if err := w.AddListedWords(list, answers.Listed[list]); err != nil {
+			return err
+		}
+	}
+	chosen := answers.Add
+	if len(chosen) == 0 {
+		return nil
+	}
+	names := make([]string, len(chosen))
+	for i, word := range chosen {
+		names[i] = word.Word
+	}
+	err = w.AddWords(names, opts.Tags, "", "", "")
+	// failed words aren't in the database
+	for _, word := range chosen {
+		if err := w.Store.AddSource(w.Ctx, word.Word, word.Source); err != nil && !errors.Is(err, wordstore.ErrNotFound) {
+			log.Printf("source of '%s': %v", word.Word, err)
+		}
+		if _, err := w.Store.AddSentence(w.Ctx, word.Word, word.Sentence, word.Source); err != nil && !errors.Is(err, wordstore.ErrNotFound) {
+			log.Printf("sentence of '%s': %v", word.Word, err)
+		}
+	}
+	return err
+}
+
+// percent of n of total
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// print how much of the text of parts is covered by the words in the database
+// and the known words, and the top most frequent unknown words
+func (w *WordDB) Coverage(parts []textPart, top int) error {
+	known, err := w.knownWords()
+	if err != nil {
+		return err
+	}
+	words := scanWords(parts)
+	c, err := w.coverage(w.Ctx, words, known)
+	if err != nil {
+		return err
+	}
+
+	unknownTokens := c.Tokens - c.Collected - c.Known
+	fmt.Printf("words: %d, %d different", c.Tokens, c.Different)
+	if c.Ignored > 0 {
+		fmt.Printf(", %d ignored", c.Ignored)
+	}
+	fmt.Println()
+	fmt.Printf("in the database: %.1f%% (%d)\n", percent(c.Collected, c.Tokens), c.Collected)
+	fmt.Printf("known or common: %.1f%% (%d)\n", percent(c.Known, c.Tokens), c.Known)
+	fmt.Printf("coverage: %.1f%%\n", percent(c.Collected+c.Known, c.Tokens))
+	fmt.Printf("unknown: %.1f%% (%d), %d different\n", percent(unknownTokens, c.Tokens), unknownTokens, len(c.Unknown))
+	if top > 0 && len(c.Unknown) > 0 {
+		fmt.Println("\ntop unknown words:")
+		for _, word := range c.Unknown[:min(top, len(c.Unknown))] {
+			fmt.Printf("%15s %5d  %.1f%%\n", word.Word, word.Count, percent(word.Count, c.Tokens))
+		}
+	}
+	return nil
+}
@@ -2,5 +2,185 @@ CREATE TABLE word (
 	word TEXT PRIMARY KEY,
 	zh_trans TEXT,
 	added_count INTEGER,
-	lookup_count INTEGER
-);
\ No newline at end of file
+	lookup_count INTEGER,
+	created_at DATETIME,
+	updated_at DATETIME,
+	note TEXT,
+	context TEXT,
+	deleted_at DATETIME,
+	deck_id INTEGER NOT NULL DEFAULT 1,
+	phonetic TEXT,
+	level TEXT
+);
+CREATE TABLE review (
+	word TEXT PRIMARY KEY,
+	ease REAL NOT NULL DEFAULT 2.5,
+	interval_days INTEGER NOT NULL DEFAULT 0,
+	repetitions INTEGER NOT NULL DEFAULT 0,
+	due_at DATETIME NOT NULL
+);
+
+CREATE TABLE sentence (
+	id INTEGER PRIMARY KEY,
+	word TEXT NOT NULL,
+	sentence TEXT NOT NULL,
+	source TEXT,
+	UNIQUE (word, sentence)
+);
+
+CREATE TABLE tag (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE word_tag (
+	word TEXT NOT NULL,
+	tag_id INTEGER NOT NULL,
+	PRIMARY KEY (word, tag_id)
+);
+
+CREATE TABLE quiz_stat (
+	word TEXT PRIMARY KEY,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	correct INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE journal (
+	id INTEGER PRIMARY KEY,
+	op TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE journal_word (
+	id INTEGER PRIMARY KEY,
+	journal_id INTEGER NOT NULL,
+	word TEXT NOT NULL,
+	existed BOOLEAN NOT NULL,
+	zh_trans TEXT,
+	added_count INTEGER,
+	lookup_count INTEGER,
+	created_at DATETIME,
+	updated_at DATETIME,
+	note TEXT,
+	context TEXT,
+	deleted_at DATETIME,
+	deck_id INTEGER
+);
+
+CREATE TABLE deck (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE sync_state (
+	remote TEXT PRIMARY KEY,
+	local_synced_at DATETIME NOT NULL,
+	remote_synced_at DATETIME NOT NULL
+);
+
+CREATE TABLE daily_activity (
+	day TEXT PRIMARY KEY,
+	reviews INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE enrichment (
+	word TEXT PRIMARY KEY,
+	definition TEXT NOT NULL,
+	mnemonic TEXT NOT NULL,
+	examples TEXT NOT NULL,
+	model TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE related_word (
+	word TEXT NOT NULL,
+	related TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	score INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (word, kind, related)
+);
+
+CREATE TABLE word_variant (
+	variant TEXT PRIMARY KEY,
+	word TEXT NOT NULL
+);
+
+CREATE TABLE word_list (
+	word TEXT PRIMARY KEY,
+	list TEXT NOT NULL,
+	added_at DATETIME NOT NULL
+);
+
+CREATE TABLE word_source (
+	word TEXT NOT NULL,
+	source TEXT NOT NULL,
+	added_at DATETIME NOT NULL,
+	PRIMARY KEY (word, source)
+);
+
+CREATE TABLE word_mastery (
+	word TEXT PRIMARY KEY,
+	state TEXT NOT NULL,
+	archived_at DATETIME,
+	updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE review_log (
+	id INTEGER PRIMARY KEY,
+	word TEXT NOT NULL,
+	grade INTEGER NOT NULL,
+	latency_ms INTEGER,
+	interval_days INTEGER NOT NULL,
+	next_interval_days INTEGER NOT NULL,
+	reviewed_at DATETIME NOT NULL
+);
+
+CREATE TABLE data_revision (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	revision INTEGER NOT NULL,
+	updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE translation (
+	word TEXT NOT NULL,
+	lang TEXT NOT NULL,
+	trans TEXT NOT NULL,
+	updated_at DATETIME NOT NULL,
+	PRIMARY KEY (word, lang)
+);
+
+CREATE TABLE user (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	password_hash TEXT NOT NULL,
+	db_path TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE user_token (
+	token_hash TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE share (
+	token TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	deck_id INTEGER NOT NULL DEFAULT 0,
+	tag TEXT NOT NULL DEFAULT '',
+	words TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL,
+	expires_at DATETIME
+);
+
+CREATE TABLE user_share (
+	token TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE search_index (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	revision INTEGER NOT NULL,
+	rebuilt_at DATETIME NOT NULL
+);
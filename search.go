@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// full-text index of word, translation, note and context. It's an external
+// content table reading from word, and is rebuilt before searching when the
+// words changed, instead of kept in sync with triggers, so binaries built
+// without FTS5 can still write to a database indexed by another build.
+const searchIndexSchema = `CREATE VIRTUAL TABLE IF NOT EXISTS word_fts USING fts5(
+    word, zh_trans, note, context,
+    content='word'
+);`
+
+// create the full-text index, and rebuild it if the data revision changed
+// since it was last rebuilt, so searches of unchanged words don't write
+func refreshSearchIndex(ctx context.Context, db *sql.DB) error {
+	queries := worddb.New(db)
+	current, err := queries.GetDataRevision(ctx)
+	if err != nil {
+		return err
+	}
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name = 'word_fts')`).Scan(&exists); err != nil {
+		return err
+	}
+	indexed, err := queries.GetSearchRevision(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if exists && err == nil && indexed == current.Revision {
+		return nil
+	}
+
+	if _, err := db.ExecContext(ctx, searchIndexSchema); err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return errors.New("full-text search needs FTS5, build w2r with -tags sqlite_fts5")
+		}
+		return err
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO word_fts(word_fts) VALUES ('rebuild')`); err != nil {
+		return err
+	}
+	// words changed while rebuilding only cause another rebuild
+	return queries.SetSearchRevision(ctx, current.Revision)
+}
+
+// convert user input into an FTS5 query, every term must match the prefix of
+// a token, so "短暂" finds "短暂的"
+func ftsQuery(q string) string {
+	terms := strings.Fields(q)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// find words matching q in spelling, translation, note or context in a deck,
+// or all decks if deckID is 0, the best matches first
+func searchWords(ctx context.Context, db *sql.DB, q string, deckID int64) ([]worddb.Word, error) {
+	if err := refreshSearchIndex(ctx, db); err != nil {
+		return nil, err
+	}
+	words := make([]worddb.Word, 0)
+	query := ftsQuery(q)
+	if query == "" {
+		return words, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `
+    SELECT word.word, word.zh_trans, word.added_count, word.lookup_count,
+        word.created_at, word.updated_at, word.note, word.context, word.deck_id
+    FROM word_fts JOIN word ON word.rowid = word_fts.rowid
+    WHERE word_fts MATCH ?1 AND word.deleted_at IS NULL AND (?2 = 0 OR word.deck_id = ?2)
+    ORDER BY rank
+    `, query, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i worddb.Word
+		if err := rows.Scan(&i.Word, &i.ZhTrans, &i.AddedCount, &i.LookupCount,
+			&i.CreatedAt, &i.UpdatedAt, &i.Note, &i.Context, &i.DeckID); err != nil {
+			return nil, err
+		}
+		words = append(words, i)
+	}
+	return words, rows.Err()
+}
+
+// search words and print the matches
+func (w *WordDB) Search(q string) error {
+	words, err := searchWords(w.Ctx, w.Db, q, w.DeckID)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		fmt.Println("no words found")
+		return nil
+	}
+	for _, word := range words {
+		fmt.Printf("%15s  %s\n", word.Word, word.ZhTrans.String)
+		if word.Note.Valid {
+			fmt.Printf("%15s  note: %s\n", "", word.Note.String)
+		}
+		if word.Context.Valid {
+			fmt.Printf("%15s  “%s”\n", "", word.Context.String)
+		}
+	}
+	return nil
+}
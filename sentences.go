@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+// show the example sentences of word with their ids
+func (w *WordDB) ShowSentences(word string) error {
+	if _, err := w.Store.Get(w.Ctx, word); errors.Is(err, wordstore.ErrNotFound) {
+		return fmt.Errorf("sentences of '%s': %w%s", word, err, w.didYouMean(word))
+	}
+	sentences, err := w.Store.Sentences(w.Ctx, word)
+	if err != nil {
+		return err
+	}
+	if len(sentences) == 0 {
+		fmt.Printf("no sentences of '%s' yet\n", word)
+		return nil
+	}
+	for _, s := range sentences {
+		line := fmt.Sprintf("%4d) %s", s.ID, s.Sentence)
+		if s.Source.Valid {
+			line += " — " + s.Source.String
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func (w *WordDB) AddSentence(word, sentence, source string) error {
+	added, err := w.Store.AddSentence(w.Ctx, word, sentence, source)
+	if errors.Is(err, wordstore.ErrNotFound) {
+		return fmt.Errorf("add sentence of '%s': %w%s", word, err, w.didYouMean(word))
+	}
+	if err != nil {
+		return fmt.Errorf("add sentence of '%s': %w", word, err)
+	}
+	if !added {
+		log.Printf("'%s' already has the sentence", word)
+		return nil
+	}
+	log.Printf("add sentence of '%s'", word)
+	return nil
+}
+
+func (w *WordDB) DeleteSentence(id int64) error {
+	if err := w.Store.DeleteSentence(w.Ctx, id); err != nil {
+		return fmt.Errorf("del sentence %d: %w", id, err)
+	}
+	log.Printf("del sentence %d", id)
+	return nil
+}
+
+// example sentences of word in the free dictionary api
+func fetchSentences(ctx context.Context, word string) ([]string, error) {
+	entries, err := dictionaryAPILookup(ctx, word)
+	if err != nil {
+		return nil, err
+	}
+	var sentences []string
+	for _, entry := range entries {
+		for _, meaning := range entry.Meanings {
+			for _, definition := range meaning.Definitions {
+				if definition.Example != "" {
+					sentences = append(sentences, definition.Example)
+				}
+			}
+		}
+	}
+	return sentences, nil
+}
+
+// fetch example sentences of words and add them, a failed word doesn't stop
+// the rest
+func (w *WordDB) FetchSentences(words []string) error {
+	failed := 0
+	for _, word := range words {
+		n, err := w.fetchSentences(w.Ctx, word)
+		if errors.Is(err, wordstore.ErrNotFound) {
+			err = fmt.Errorf("%w%s", err, w.didYouMean(word))
+		}
+		if err != nil {
+			failed++
+			log.Printf("fetch sentences of '%s': %v", word, err)
+			continue
+		}
+		log.Printf("add %d sentence(s) of '%s'", n, word)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to fetch sentences of %d of %d word(s)", failed, len(words))
+	}
+	return nil
+}
+
+// add the example sentences of word in the free dictionary api, returns the
+// number of new sentences
+func (w *WordDB) fetchSentences(ctx context.Context, word string) (int, error) {
+	if _, err := w.Store.Get(ctx, word); err != nil {
+		return 0, err
+	}
+	sentences, err := fetchSentences(ctx, word)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, sentence := range sentences {
+		ok, err := w.Store.AddSentence(ctx, word, sentence, "dictionaryapi")
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// a random example sentence of word for quiz prompts, or the context where
+// the word was seen, empty if it has neither
+func (w *WordDB) quizSentence(ctx context.Context, word string) string {
+	s, err := w.Store.Queries().GetRandomSentence(ctx, word)
+	if err == nil {
+		return s.Sentence
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("sentence of '%s': %v", word, err)
+	}
+	if result, err := w.Store.Get(ctx, word); err == nil {
+		return result.Context.String
+	}
+	return ""
+}
+
+// sentence with word and its inflections like words or worded blanked out,
+// for spelling prompts
+func clozeSentence(sentence, word string) string {
+	// chinese and japanese aren't written with spaces between words
+	if first, _ := utf8.DecodeRuneInString(word); unicode.In(first, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return strings.ReplaceAll(sentence, word, "_____")
+	}
+	// \b and \w are ascii only, so letters are matched by their class
+	re := regexp.MustCompile(`(?i)(^|[^\p{L}\p{M}])` + regexp.QuoteMeta(word) + `[\p{L}\p{M}]*`)
+	return re.ReplaceAllString(sentence, "${1}_____")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// url of the server for links to it, the url of the serve config, or the
+// local address of serve
+func serverURL() string {
+	if config.Serve.URL != "" {
+		return strings.TrimRight(config.Serve.URL, "/")
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d%s", config.Serve.Port, cleanBasePath(config.Serve.BasePath))
+}
+
+func shareURL(base, token string) string {
+	return strings.TrimRight(base, "/") + "/share/" + token
+}
+
+// share the words selected by opts by a link, the words of the deck of
+// --deck, and print the link to the server at base
+func (w *WordDB) CreateShare(opts wordstore.ShareOptions, base string) error {
+	for _, word := range opts.Words {
+		if _, err := w.Store.Get(w.Ctx, word); errors.Is(err, wordstore.ErrNotFound) {
+			return fmt.Errorf("share word '%s': %w%s", word, err, w.didYouMean(word))
+		} else if err != nil {
+			return err
+		}
+	}
+	opts.DeckID = w.DeckID
+	if opts.Title == "" {
+		opts.Title = shareTitle(w.DeckName, opts.Tag)
+	}
+	token, err := w.Store.CreateShare(w.Ctx, opts)
+	if err != nil {
+		return err
+	}
+	// serve -users finds the database of a share by its user
+	if w.Users != nil {
+		if err := w.Users.AddUserShare(w.Ctx, w.UserID, token); err != nil {
+			return errors.Join(err, w.Store.DeleteShare(w.Ctx, token))
+		}
+	}
+	log.Printf("share '%s', anyone with the link can see the words while the server runs:", opts.Title)
+	fmt.Println(shareURL(base, token))
+	return nil
+}
+
+// title of a share without one
+func shareTitle(deck, tag string) string {
+	var parts []string
+	for _, part := range []string{deck, tag} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "Words"
+	}
+	return strings.Join(parts, " · ")
+}
+
+// show all shares with their links to the server at base
+func (w *WordDB) ShowShares(base string) error {
+	shares, err := w.Store.Shares(w.Ctx)
+	if err != nil {
+		return err
+	}
+	decks, err := w.Store.Decks(w.Ctx)
+	if err != nil {
+		return err
+	}
+	deckNames := make(map[int64]string, len(decks))
+	for _, deck := range decks {
+		deckNames[deck.ID] = deck.Name
+	}
+
+	fmt.Printf("%-20s %-24s %10s %10s  %s\n", "Title", "Words", "Created", "Expires", "Link")
+	for _, share := range shares {
+		var selection []string
+		if share.DeckID != 0 {
+			selection = append(selection, "deck "+deckNames[share.DeckID])
+		}
+		if share.Tag != "" {
+			selection = append(selection, "tag "+share.Tag)
+		}
+		if share.Words != "" {
+			selection = append(selection, fmt.Sprintf("%d chosen", strings.Count(share.Words, ",")+1))
+		}
+		if len(selection) == 0 {
+			selection = append(selection, "all")
+		}
+		expires := "never"
+		if share.ExpiresAt.Valid {
+			expires = share.ExpiresAt.Time.Local().Format("2006-01-02")
+			if time.Now().After(share.ExpiresAt.Time) {
+				expires = "expired"
+			}
+		}
+		fmt.Printf("%-20s %-24s %10s %10s  %s\n", share.Title, strings.Join(selection, ", "), share.CreatedAt.Local().Format("2006-01-02"), expires, shareURL(base, share.Token))
+	}
+	return nil
+}
+
+// delete a share by its token or link, the link stops working at once
+func (w *WordDB) DelShare(token string) error {
+	token = path.Base(strings.TrimRight(token, "/"))
+	if err := w.Store.DeleteShare(w.Ctx, token); err != nil {
+		return fmt.Errorf("del share '%s': %w", token, err)
+	}
+	if w.Users != nil {
+		if err := w.Users.DeleteUserShare(w.Ctx, token); err != nil {
+			return err
+		}
+	}
+	log.Printf("del share '%s'", token)
+	return nil
+}
+
+// read-only page of the words of a share, for GET /share/{token}, without the
+// notes, contexts and tags of the words, which are personal. It's served without
+// authentication, the token is the secret, so it's kept out of the referrers
+// of the dictionary links and search engines are asked not to index it. It
+// isn't cached, as shares expire.
+func (w *WordDB) shareHandler(tmpl *template.Template) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		share, err := w.Store.Share(r.Context(), r.PathValue("token"))
+		if errors.Is(err, wordstore.ErrShareNotFound) {
+			http.Error(rw, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		words, err := w.Store.SharedWords(r.Context(), share)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		header := rw.Header()
+		header.Set("Referrer-Policy", "no-referrer")
+		header.Set("X-Robots-Tag", "noindex")
+		header.Set("Cache-Control", "no-store")
+		data := struct {
+			Title      string
+			Words      []worddb.Word
+			Dictionary Dictionary
+			Created    time.Time
+		}{share.Title, words, dictionaries[0], share.CreatedAt}
+		if err := tmpl.ExecuteTemplate(rw, "share.html", data); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
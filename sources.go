@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+// show where words are from and the number of their words, sources are
+// printed last as they can be long, like book titles with chapters
+func (w *WordDB) ShowSources() error {
+	sources, err := w.Store.Sources(w.Ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%10s  %s\n", "Words", "Source")
+	for _, source := range sources {
+		fmt.Printf("%10d  %s\n", source.WordCount, source.Source)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// words added per local day, keyed like 2006-01-02, words added before
+// timestamps were recorded are not counted
+func (w *WordDB) addedPerDay(ctx context.Context, deckID int64) (map[string]int64, error) {
+	rows, err := w.Store.Queries().CountWordsAddedPerDay(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+	days := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		days[row.Day] = row.Words
+	}
+	return days, nil
+}
+
+// a period of the added words table, from start to end exclusive
+type period struct {
+	label      string
+	start, end time.Time
+}
+
+// the last n periods ending with the one of today, each one step after the
+// one before, the oldest first
+func lastPeriods(n int, first time.Time, step func(time.Time, int) time.Time, layout string) []period {
+	periods := make([]period, n)
+	for i := range periods {
+		start := step(first, i-n+1)
+		periods[i] = period{label: start.Format(layout), start: start, end: step(start, 1)}
+	}
+	return periods
+}
+
+func addDays(t time.Time, n int) time.Time   { return t.AddDate(0, 0, n) }
+func addWeeks(t time.Time, n int) time.Time  { return t.AddDate(0, 0, 7*n) }
+func addMonths(t time.Time, n int) time.Time { return t.AddDate(0, n, 0) }
+
+// sum of words added per day in p
+func (p period) added(days map[string]int64) int64 {
+	var n int64
+	for day := p.start; day.Before(p.end); day = day.AddDate(0, 0, 1) {
+		n += days[day.Format(time.DateOnly)]
+	}
+	return n
+}
+
+// start of the local day of t
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// print totals, words added per day, week and month, the most looked up
+// words, and the growth at the pace of the last 30 days
+func (w *WordDB) ShowStats(days, weeks, months, top int) error {
+	stats, err := w.Store.Stats(w.Ctx, w.DeckID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("words:     %d, translated %d, due %d\n", stats.Words, stats.Translated, stats.Due)
+	fmt.Printf("tags:      %d\n", stats.Tags)
+	var avgAdded float64
+	if stats.Words > 0 {
+		avgAdded = float64(stats.AddedCount) / float64(stats.Words)
+	}
+	fmt.Printf("added:     %d times again, %.1f per word\n", stats.AddedCount, avgAdded)
+	fmt.Printf("lookups:   %d\n", stats.LookupCount)
+	fmt.Printf("levels:    %s\n", formatLevels(stats.Levels))
+	fmt.Printf("mastery:   %s, archived %d\n", formatMastery(stats.Mastery), stats.Archived)
+	if config.Goal.Words > 0 || config.Goal.Reviews > 0 {
+		streak, err := w.streak(w.Ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("streak:    %d day(s), longest %d, today %d/%d new words, %d/%d reviews\n",
+			streak.Current, streak.Longest, streak.Words, streak.GoalWords, streak.Reviews, streak.GoalReviews)
+	}
+
+	perDay, err := w.addedPerDay(w.Ctx, w.DeckID)
+	if err != nil {
+		return err
+	}
+	printAddedPeriods(perDay, days, weeks, months)
+
+	if top > 0 {
+		lookups, err := w.Store.Queries().ListTopLookups(w.Ctx, worddb.ListTopLookupsParams{DeckID: w.DeckID, Limit: int64(top)})
+		if err != nil {
+			return err
+		}
+		if len(lookups) > 0 {
+			fmt.Printf("\n%15s %10s %s\n", "Top lookups", "Lookups", "Translation")
+			for _, row := range lookups {
+				fmt.Printf("%15s %10d %s\n", row.Word, row.LookupCount.Int64, row.ZhTrans.String)
+			}
+		}
+	}
+
+	printGrowth(stats.Words, perDay)
+	return nil
+}
+
+// print the words added in the last days, weeks and months, of the words
+// added per day
+func printAddedPeriods(perDay map[string]int64, days, weeks, months int) {
+	today := startOfDay(time.Now())
+	// weeks start on monday
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	tables := []struct {
+		title   string
+		periods []period
+	}{
+		{"day", lastPeriods(days, today, addDays, "2006-01-02 Mon")},
+		{"week", lastPeriods(weeks, monday, addWeeks, "2006-01-02")},
+		{"month", lastPeriods(months, firstOfMonth, addMonths, "2006-01")},
+	}
+	for _, table := range tables {
+		if len(table.periods) == 0 {
+			continue
+		}
+		fmt.Printf("\n%15s %10s\n", "Added per "+table.title, "Words")
+		for _, p := range table.periods {
+			fmt.Printf("%15s %10d\n", p.label, p.added(perDay))
+		}
+	}
+}
+
+// print the pace of the last 30 days, and where it leads from words
+func printGrowth(words int64, perDay map[string]int64) {
+	today := startOfDay(time.Now())
+	last30 := period{start: today.AddDate(0, 0, -29), end: today.AddDate(0, 0, 1)}.added(perDay)
+	pace := float64(last30) / 30
+	fmt.Printf("\ngrowth:    %.1f words per day in the last 30 days", pace)
+	if last30 > 0 {
+		fmt.Printf(", reaching about %d words in 30 days and %d in a year at this pace",
+			words+int64(pace*30), words+int64(pace*365))
+	}
+	fmt.Println()
+}
+
+// words by level from the easiest, like A1 120 (12%), words without a level
+// last
+func formatLevels(levels map[string]int64) string {
+	var total int64
+	for _, n := range levels {
+		total += n
+	}
+	if total == 0 {
+		return "-"
+	}
+	part := func(label string, n int64) string {
+		return fmt.Sprintf("%s %d (%.0f%%)", label, n, float64(n)*100/float64(total))
+	}
+	parts := make([]string, 0, len(wordstore.Levels)+1)
+	for _, level := range wordstore.Levels {
+		parts = append(parts, part(level, levels[level]))
+	}
+	parts = append(parts, part("other", levels[""]))
+	return strings.Join(parts, ", ")
+}
+
+// words by mastery state like "new 3, learning 2, known 0, mastered 1"
+func formatMastery(mastery map[string]int64) string {
+	parts := make([]string, 0, len(wordstore.MasteryStates))
+	for _, state := range wordstore.MasteryStates {
+		parts = append(parts, fmt.Sprintf("%s %d", state, mastery[state]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cells of the heatmap from no words to the most words of a day, the shades
+// still work without colors
+var heatmapLevels = []lipgloss.Style{
+	lipgloss.NewStyle().SetString("·").Foreground(lipgloss.Color("8")),
+	lipgloss.NewStyle().SetString("░").Foreground(lipgloss.Color("22")),
+	lipgloss.NewStyle().SetString("▒").Foreground(lipgloss.Color("28")),
+	lipgloss.NewStyle().SetString("▓").Foreground(lipgloss.Color("34")),
+	lipgloss.NewStyle().SetString("█").Foreground(lipgloss.Color("40")),
+}
+
+// level of heatmapLevels of n words, relative to the most words of a day
+func heatmapLevel(n, most int64) int {
+	if n == 0 || most == 0 {
+		return 0
+	}
+	last := len(heatmapLevels) - 1
+	return int(math.Ceil(float64(n) / float64(most) * float64(last)))
+}
+
+// print a calendar of words added per day like the contribution graph of
+// github, a column for each week and a row for each weekday, for the last
+// year or as many weeks as fit in the terminal
+func (w *WordDB) ShowHeatmap() error {
+	perDay, err := w.addedPerDay(w.Ctx, w.DeckID)
+	if err != nil {
+		return err
+	}
+	// each week is 2 columns wide after the 4 columns of weekday labels
+	weeks := 52
+	if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil && width > 0 {
+		weeks = max(min(weeks, (width-4)/2), 1)
+	}
+
+	today := startOfDay(time.Now())
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	first := monday.AddDate(0, 0, -7*(weeks-1))
+	var total, most int64
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		n := perDay[day.Format(time.DateOnly)]
+		total += n
+		most = max(most, n)
+	}
+
+	// month names above the first week of each month, if there is room
+	months := []byte(strings.Repeat(" ", 4+2*weeks))
+	for week := 0; week < weeks; week++ {
+		start := first.AddDate(0, 0, 7*week)
+		if week > 0 && start.Month() == start.AddDate(0, 0, -7).Month() {
+			continue
+		}
+		col := 4 + 2*week
+		if col+3 <= len(months) && strings.TrimSpace(string(months[max(col-1, 0):col+3])) == "" {
+			copy(months[col:], start.Format("Jan"))
+		}
+	}
+	fmt.Println(strings.TrimRight(string(months), " "))
+
+	labels := []string{"Mon", "", "Wed", "", "Fri", "", ""}
+	for weekday, label := range labels {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%-4s", label)
+		for week := 0; week < weeks; week++ {
+			day := first.AddDate(0, 0, 7*week+weekday)
+			if day.After(today) {
+				break
+			}
+			b.WriteString(heatmapLevels[heatmapLevel(perDay[day.Format(time.DateOnly)], most)].String())
+			b.WriteString(" ")
+		}
+		fmt.Println(strings.TrimRight(b.String(), " "))
+	}
+
+	var legend strings.Builder
+	for _, level := range heatmapLevels {
+		legend.WriteString(level.String() + " ")
+	}
+	fmt.Printf("\n%d words added in %d weeks, at most %d a day    less %smore\n", total, weeks, most, legend.String())
+	return nil
+}
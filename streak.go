@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// progress of the daily goal of config.Goal
+type Streak struct {
+	// consecutive days reaching the goal until today, or yesterday while the
+	// goal of today isn't reached yet
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+	// words added and reviews today
+	Words   int64 `json:"words"`
+	Reviews int64 `json:"reviews"`
+	// the goal
+	GoalWords   int64 `json:"goal_words"`
+	GoalReviews int64 `json:"goal_reviews"`
+}
+
+// whether words added and reviews of a day reach the goal, either is enough
+func goalReached(words, reviews int64) bool {
+	return config.Goal.Words > 0 && words >= config.Goal.Words ||
+		config.Goal.Reviews > 0 && reviews >= config.Goal.Reviews
+}
+
+// the streak of daily goals in all decks, by local days
+func (w *WordDB) streak(ctx context.Context) (Streak, error) {
+	streak := Streak{GoalWords: config.Goal.Words, GoalReviews: config.Goal.Reviews}
+	added, err := w.addedPerDay(ctx, 0)
+	if err != nil {
+		return streak, err
+	}
+	activity, err := w.Store.Queries().ListDailyActivity(ctx)
+	if err != nil {
+		return streak, err
+	}
+	reviews := make(map[string]int64, len(activity))
+	for _, row := range activity {
+		reviews[row.Day] = row.Reviews
+	}
+
+	today := startOfDay(time.Now())
+	first := today
+	for _, days := range []map[string]int64{added, reviews} {
+		for day := range days {
+			if t, err := time.ParseInLocation(time.DateOnly, day, time.Local); err == nil && t.Before(first) {
+				first = t
+			}
+		}
+	}
+
+	// days are counted from the first activity to today, current is the run
+	// ending today, or yesterday if today isn't done yet
+	run := 0
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		key := day.Format(time.DateOnly)
+		if goalReached(added[key], reviews[key]) {
+			run++
+			streak.Longest = max(streak.Longest, run)
+		} else if day.Before(today) {
+			run = 0
+		}
+	}
+	streak.Current = run
+
+	key := today.Format(time.DateOnly)
+	streak.Words, streak.Reviews = added[key], reviews[key]
+	return streak, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// formatting of subtitle text, like <i> or {\an8}
+	subtitleFormatting = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+	// the time of a cue up to the second, of 00:01:02,500 or 01:02.500
+	cueTime = regexp.MustCompile(`^(?:\d+:)?\d+:\d+`)
+)
+
+// cues of .srt or .vtt subtitles, with the file name and the start time of
+// each cue as its source, like "movie.srt 00:12:34". The numbers or ids of
+// the cues, the vtt header, notes and styles are left out.
+func parseSubtitles(text, name string) []textPart {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	var parts []textPart
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		// blocks without timing aren't cues
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+		cue := subtitleFormatting.ReplaceAllString(strings.Join(lines[timing+1:], " "), "")
+		if strings.TrimSpace(cue) == "" {
+			continue
+		}
+		source := name
+		if start := cueTime.FindString(strings.TrimSpace(lines[timing])); start != "" {
+			source += " " + start
+		}
+		parts = append(parts, textPart{Text: cue, Source: source})
+	}
+	return parts
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notsobad/w2r/worddb"
+)
+
+// split comma separated tags, empty tags are dropped
+func parseTags(s string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// show all tags and the number of words
+func (w *WordDB) ShowTags() error {
+	queries := worddb.New(w.Db)
+	tags, err := queries.ListTags(w.Ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%15s %10s\n", "Tag", "Words")
+	for _, tag := range tags {
+		fmt.Printf("%15s %10d\n", tag.Name, tag.WordCount)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// telegram bot of the web server, it polls the bot api for messages, see
+// https://core.telegram.org/bots/api
+type telegramBot struct {
+	w *WordDB
+	// like https://api.telegram.org/bot<token>
+	api     string
+	allowed []int64
+	// translates new words, may be nil
+	translator Translator
+}
+
+func newTelegramBot(w *WordDB, c TelegramConfig) (*telegramBot, error) {
+	bot := &telegramBot{w: w, api: strings.TrimRight(c.APIURL, "/") + "/bot" + c.Token, allowed: c.AllowedChats}
+	if c.Translate {
+		t, err := getTranslator(config.Translate.Provider)
+		if err != nil {
+			return nil, err
+		}
+		bot.translator = t
+	}
+	return bot, nil
+}
+
+// html of the telegram parse mode
+type telegramMarkup struct{}
+
+func (telegramMarkup) escape(s string) string { return html.EscapeString(s) }
+func (telegramMarkup) bold(s string) string   { return "<b>" + html.EscapeString(s) + "</b>" }
+func (telegramMarkup) spoiler(s string) string {
+	return "<tg-spoiler>" + html.EscapeString(s) + "</tg-spoiler>"
+}
+
+type telegramMessage struct {
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text string `json:"text"`
+}
+
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+// call method of the bot api with params, and decode its result into v
+func (b *telegramBot) call(ctx context.Context, method string, params, v any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.api+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// the url has the token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram %s: %s", method, resp.Status)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram %s: %s", method, result.Description)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(result.Result, v)
+}
+
+func (b *telegramBot) send(ctx context.Context, chatID int64, text string) error {
+	return b.call(ctx, "sendMessage", map[string]any{"chat_id": chatID, "text": text, "parse_mode": "HTML"}, nil)
+}
+
+// reply a message, only chats in allowed_chats may use the bot, others are
+// told their chat id to add to the config
+func (b *telegramBot) handle(ctx context.Context, msg *telegramMessage) error {
+	if msg.Text == "" {
+		return nil
+	}
+	if !slices.Contains(b.allowed, msg.Chat.ID) {
+		log.Printf("telegram: message from chat %d which is not in allowed_chats", msg.Chat.ID)
+		return b.send(ctx, msg.Chat.ID, fmt.Sprintf("This chat is not allowed, add its id %d to allowed_chats in [telegram] of the w2r config file.", msg.Chat.ID))
+	}
+	reply, err := b.w.botReply(ctx, b.translator, b.w.DeckID, "/", msg.Text, telegramMarkup{})
+	if err != nil {
+		log.Printf("telegram: %v", err)
+		reply = html.EscapeString("error: " + err.Error())
+	}
+	if reply == "" {
+		return nil
+	}
+	return b.send(ctx, msg.Chat.ID, reply)
+}
+
+// poll and handle messages until ctx is done
+func (b *telegramBot) run(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		var updates []telegramUpdate
+		err := b.call(ctx, "getUpdates", map[string]any{"offset": offset, "timeout": 50, "allowed_updates": []string{"message"}}, &updates)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("%v", err)
+			}
+			// don't hammer the api while it's down
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Second):
+			}
+			continue
+		}
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message == nil {
+				continue
+			}
+			if err := b.handle(ctx, update.Message); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+	}
+}
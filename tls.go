@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// https of the web server, with certificate files or certificates of let's
+// encrypt, it's disabled if nothing is set
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// domains to get certificates of let's encrypt for, instead of the files
+	Autocert []string
+	// where the certificates are kept, next to the database if empty
+	AutocertDir string
+	// contact of the let's encrypt account, for expiry notices
+	AutocertEmail string
+	// address like :80 to answer http-01 challenges and redirect http to
+	// https, otherwise challenges are answered over tls on the listen
+	// address, which must be port 443 from outside
+	AutocertHTTP string
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || len(c.Autocert) > 0
+}
+
+func (c TLSConfig) check() error {
+	if len(c.Autocert) > 0 {
+		if c.CertFile != "" || c.KeyFile != "" {
+			return errors.New("use either -tls-cert and -tls-key or -autocert")
+		}
+		return nil
+	}
+	if c.AutocertHTTP != "" {
+		return errors.New("-autocert-http needs -autocert")
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("-tls-cert and -tls-key must be set together")
+	}
+	return nil
+}
+
+// tls config of the server, and the handler of the http server of autocert
+// if it has one
+func (w *WordDB) tlsConfig(c TLSConfig) (*tls.Config, http.Handler, error) {
+	if len(c.Autocert) == 0 {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}}, nil, nil
+	}
+
+	dir := c.AutocertDir
+	if dir == "" {
+		dir = w.Path + ".autocert"
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(dir),
+		HostPolicy: autocert.HostWhitelist(c.Autocert...),
+		Email:      c.AutocertEmail,
+	}
+	var challenges http.Handler
+	if c.AutocertHTTP != "" {
+		challenges = m.HTTPHandler(nil)
+	}
+	return m.TLSConfig(), challenges, nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// translate a word into chinese with an online dictionary
+type Translator interface {
+	Translate(ctx context.Context, word string) (string, error)
+}
+
+var httpClient = &http.Client{}
+
+var (
+	errNoTranslation = errors.New("no translation found")
+	// a provider needs an api key in the config
+	errNoAPIKey = errors.New("api key is missing")
+	// a provider doesn't translate into the language of the config
+	errUnsupportedLang = errors.New("language not supported")
+)
+
+// an http response which is not 200 OK
+type httpStatusError struct {
+	method, url string
+	code        int
+	status      string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.method, e.url, e.status)
+}
+
+// available translation providers, selected by name
+var translators = map[string]Translator{
+	"youdao":   youdaoTranslator{},
+	"mymemory": myMemoryTranslator{},
+	"offline":  &offlineTranslator{},
+	"deepl":    deepLTranslator{},
+	"google":   googleTranslator{},
+	"baidu":    baiduTranslator{},
+}
+
+func translatorNames() string {
+	names := make([]string, 0, len(translators))
+	for name := range translators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+func getTranslator(name string) (Translator, error) {
+	t, ok := translators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown translation provider %q, available: %s", name, translatorNames())
+	}
+	return t, nil
+}
+
+// GET url and decode json response into v
+func getJSON(ctx context.Context, u string, v any) error {
+	return doJSON(ctx, http.MethodGet, u, nil, nil, v)
+}
+
+// POST form to url with header and decode json response into v
+func postFormJSON(ctx context.Context, u string, form url.Values, header http.Header, v any) error {
+	header = header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doJSON(ctx, http.MethodPost, u, strings.NewReader(form.Encode()), header, v)
+}
+
+func doJSON(ctx context.Context, method, u string, body io.Reader, header http.Header, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, config.Translate.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	for k, values := range header {
+		req.Header[k] = values
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &httpStatusError{method: method, url: u, code: resp.StatusCode, status: resp.Status}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// youdao code of the language of words, youdao has dictionaries of a few
+// languages into chinese
+func youdaoLang() (string, bool) {
+	le, ok := map[string]string{"en": "eng", "fr": "fr", "ja": "jap", "ko": "ko"}[config.Language]
+	return le, ok
+}
+
+// youdao dictionary suggest api, no api key needed
+type youdaoTranslator struct{}
+
+func (youdaoTranslator) Translate(ctx context.Context, word string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(config.Translate.Lang), "zh") {
+		return "", fmt.Errorf("youdao %w: %s, it only translates into chinese", errUnsupportedLang, config.Translate.Lang)
+	}
+	var result struct {
+		Data struct {
+			Entries []struct {
+				Entry   string `json:"entry"`
+				Explain string `json:"explain"`
+			} `json:"entries"`
+		} `json:"data"`
+	}
+	le, ok := youdaoLang()
+	if !ok {
+		return "", fmt.Errorf("%w for '%s', youdao has no %s dictionary", errNoTranslation, word, config.Language)
+	}
+	u := "https://dict.youdao.com/suggest?num=1&doctype=json&le=" + le + "&q=" + url.QueryEscape(word)
+	if err := getJSON(ctx, u, &result); err != nil {
+		return "", err
+	}
+	for _, entry := range result.Data.Entries {
+		if strings.EqualFold(entry.Entry, word) {
+			return entry.Explain, nil
+		}
+	}
+	return "", fmt.Errorf("%w for '%s'", errNoTranslation, word)
+}
+
+// mymemory translation memory api, no api key needed
+type myMemoryTranslator struct{}
+
+func (myMemoryTranslator) Translate(ctx context.Context, word string) (string, error) {
+	var result struct {
+		ResponseData struct {
+			TranslatedText string `json:"translatedText"`
+		} `json:"responseData"`
+	}
+	u := "https://api.mymemory.translated.net/get?langpair=" + url.QueryEscape(config.Language+"|"+config.Translate.Lang) + "&q=" + url.QueryEscape(word)
+	if err := getJSON(ctx, u, &result); err != nil {
+		return "", err
+	}
+	if result.ResponseData.TranslatedText == "" {
+		return "", fmt.Errorf("%w for '%s'", errNoTranslation, word)
+	}
+	return result.ResponseData.TranslatedText, nil
+}
+
+// deepl api, free keys end with :fx and use the free api host
+type deepLTranslator struct{}
+
+func (deepLTranslator) Translate(ctx context.Context, word string) (string, error) {
+	key := config.Translate.DeepLKey
+	if key == "" {
+		return "", fmt.Errorf("deepl %w, set deepl_key in [translate] of the config file, or W2R_DEEPL_KEY", errNoAPIKey)
+	}
+	u := "https://api.deepl.com/v2/translate"
+	if strings.HasSuffix(key, ":fx") {
+		u = "https://api-free.deepl.com/v2/translate"
+	}
+	// deepl has no variants of source languages, like en-us
+	source, _, _ := strings.Cut(strings.ToUpper(config.Language), "-")
+	// deepl names chinese variants by script
+	target := strings.ToUpper(config.Translate.Lang)
+	switch target {
+	case "ZH-CN", "ZH":
+		target = "ZH-HANS"
+	case "ZH-TW", "ZH-HK":
+		target = "ZH-HANT"
+	}
+	var result struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	form := url.Values{"text": {word}, "source_lang": {source}, "target_lang": {target}}
+	header := http.Header{"Authorization": {"DeepL-Auth-Key " + key}}
+	if err := postFormJSON(ctx, u, form, header, &result); err != nil {
+		return "", err
+	}
+	if len(result.Translations) == 0 || result.Translations[0].Text == "" {
+		return "", fmt.Errorf("%w for '%s'", errNoTranslation, word)
+	}
+	return result.Translations[0].Text, nil
+}
+
+// google cloud translation api v2 with an api key
+type googleTranslator struct{}
+
+func (googleTranslator) Translate(ctx context.Context, word string) (string, error) {
+	key := config.Translate.GoogleKey
+	if key == "" {
+		return "", fmt.Errorf("google %w, set google_key in [translate] of the config file, or W2R_GOOGLE_KEY", errNoAPIKey)
+	}
+	var result struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	form := url.Values{"q": {word}, "source": {config.Language}, "target": {config.Translate.Lang}, "format": {"text"}}
+	// the key in a header, so it's not in errors with the url
+	header := http.Header{"X-Goog-Api-Key": {key}}
+	if err := postFormJSON(ctx, "https://translation.googleapis.com/language/translate/v2", form, header, &result); err != nil {
+		return "", err
+	}
+	if len(result.Data.Translations) == 0 || result.Data.Translations[0].TranslatedText == "" {
+		return "", fmt.Errorf("%w for '%s'", errNoTranslation, word)
+	}
+	return result.Data.Translations[0].TranslatedText, nil
+}
+
+// baidu code of lang, baidu has its own language codes
+func baiduLang(lang string) string {
+	lang = strings.ToLower(lang)
+	switch lang {
+	case "zh-cn":
+		return "zh"
+	case "zh-tw", "zh-hk":
+		return "cht"
+	case "ja":
+		return "jp"
+	case "ko":
+		return "kor"
+	case "fr":
+		return "fra"
+	case "es":
+		return "spa"
+	}
+	return lang
+}
+
+// baidu fanyi general translation api, requests are signed with the secret
+// key, see https://fanyi-api.baidu.com/doc/21
+type baiduTranslator struct{}
+
+func (baiduTranslator) Translate(ctx context.Context, word string) (string, error) {
+	appID, key := config.Translate.BaiduAppID, config.Translate.BaiduKey
+	if appID == "" || key == "" {
+		return "", fmt.Errorf("baidu %w, set baidu_app_id and baidu_key in [translate] of the config file", errNoAPIKey)
+	}
+	salt := strconv.FormatInt(time.Now().UnixNano(), 10)
+	sum := md5.Sum([]byte(appID + word + salt + key))
+	var result struct {
+		ErrorCode string `json:"error_code"`
+		ErrorMsg  string `json:"error_msg"`
+		Result    []struct {
+			Dst string `json:"dst"`
+		} `json:"trans_result"`
+	}
+	form := url.Values{"q": {word}, "from": {baiduLang(config.Language)}, "to": {baiduLang(config.Translate.Lang)}, "appid": {appID}, "salt": {salt}, "sign": {hex.EncodeToString(sum[:])}}
+	if err := postFormJSON(ctx, "https://fanyi-api.baidu.com/api/trans/vip/translate", form, nil, &result); err != nil {
+		return "", err
+	}
+	// errors are replied with 200
+	if result.ErrorCode != "" && result.ErrorCode != "52000" {
+		return "", fmt.Errorf("baidu translate: %s %s", result.ErrorCode, result.ErrorMsg)
+	}
+	if len(result.Result) == 0 || result.Result[0].Dst == "" {
+		return "", fmt.Errorf("%w for '%s'", errNoTranslation, word)
+	}
+	return result.Result[0].Dst, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+var (
+	tuiWordStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12")).Padding(1, 4).Border(lipgloss.RoundedBorder())
+	tuiTransStyle = lipgloss.NewStyle().Padding(1, 0)
+	tuiDimStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	tuiErrStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// full-screen flashcard review, shows a word, reveals the translation on
+// space or enter, and records the grade on 1~4
+type flashcardModel struct {
+	w        *WordDB
+	words    []worddb.ListDueWordsRow
+	index    int
+	revealed bool
+	// when the current word was shown
+	shown    time.Time
+	reviewed int
+	message  string
+	err      error
+	width    int
+	height   int
+}
+
+func (m flashcardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m flashcardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		key := msg.String()
+		if key == "q" || key == "ctrl+c" || key == "esc" {
+			return m, tea.Quit
+		}
+		if m.index >= len(m.words) {
+			return m, tea.Quit
+		}
+		if !m.revealed {
+			if key == " " || key == "enter" {
+				m.revealed = true
+			}
+			return m, nil
+		}
+
+		g, ok := parseGrade(key)
+		if !ok {
+			return m, nil
+		}
+		word := m.words[m.index]
+		next, err := m.w.gradeWord(m.w.Ctx, word.Word, cardOf(word), g, time.Since(m.shown))
+		if err != nil {
+			m.err = err
+			return m, tea.Quit
+		}
+		m.message = fmt.Sprintf("'%s' %s", word.Word, next.describe())
+		if next.Mastery() == wordstore.MasteryMastered {
+			m.message = fmt.Sprintf("'%s' mastered, archived", word.Word)
+		}
+		m.reviewed++
+		m.index++
+		m.revealed = false
+		m.shown = time.Now()
+	}
+	return m, nil
+}
+
+func (m flashcardModel) View() string {
+	var b strings.Builder
+	if m.index >= len(m.words) {
+		fmt.Fprintf(&b, "All done, %d word(s) reviewed.\n\n", m.reviewed)
+		b.WriteString(tuiDimStyle.Render("press any key to quit"))
+	} else {
+		word := m.words[m.index]
+		b.WriteString(tuiDimStyle.Render(fmt.Sprintf("%d / %d", m.index+1, len(m.words))))
+		b.WriteString("\n\n")
+		b.WriteString(tuiWordStyle.Render(word.Word))
+		b.WriteString("\n")
+		if m.revealed {
+			zhTrans := "(no translation)"
+			if word.ZhTrans.Valid {
+				zhTrans = word.ZhTrans.String
+			}
+			b.WriteString(tuiTransStyle.Render(zhTrans))
+			b.WriteString("\n\n")
+			b.WriteString("[1] again  [2] hard  [3] good  [4] easy")
+		} else {
+			b.WriteString("\n\n\n")
+			b.WriteString(tuiDimStyle.Render("space / enter to reveal"))
+		}
+		b.WriteString("\n\n")
+		if m.message != "" {
+			b.WriteString(tuiDimStyle.Render(m.message))
+		}
+		b.WriteString("\n")
+		b.WriteString(tuiDimStyle.Render("q to quit"))
+	}
+	if m.err != nil {
+		b.WriteString("\n" + tuiErrStyle.Render(m.err.Error()))
+	}
+
+	if m.width == 0 {
+		return b.String()
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, b.String(), lipgloss.WithWhitespaceChars(" "))
+}
+
+// review due words in a full-screen terminal ui
+func (w *WordDB) ReviewTUI(limit int) error {
+	words, err := w.dueWords(limit)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		fmt.Println("no words due for review")
+		return nil
+	}
+
+	final, err := tea.NewProgram(flashcardModel{w: w, words: words, shown: time.Now()}, tea.WithAltScreen()).Run()
+	if err != nil {
+		return err
+	}
+	m := final.(flashcardModel)
+	fmt.Printf("%d word(s) reviewed\n", m.reviewed)
+	return m.err
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// read a password from the terminal without echo, or a line of stdin if it's
+// piped, for scripts
+func readPassword(prompt string) (string, error) {
+	if stdinIsPipe() {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
+
+// read a new password, typed twice on a terminal
+func readNewPassword() (string, error) {
+	password, err := readPassword("Password: ")
+	if err != nil || stdinIsPipe() {
+		return password, err
+	}
+	again, err := readPassword("Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if again != password {
+		return "", errors.New("passwords don't match")
+	}
+	return password, nil
+}
+
+// show the users of serve -users and their databases
+func (w *WordDB) ShowUsers() error {
+	users, err := w.Store.Users(w.Ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%15s %6s %10s  %s\n", "User", "Tokens", "Created", "Database")
+	for _, user := range users {
+		fmt.Printf("%15s %6d %10s  %s\n", user.Name, user.Tokens, user.CreatedAt.Local().Format("2006-01-02"), user.DbPath)
+	}
+	return nil
+}
+
+// add a user with a password read from the terminal, whose words are in the
+// database at dbPath, users/<name>.sqlite next to the database if empty, and
+// print the first api token of the user
+func (w *WordDB) AddUser(name, dbPath string) error {
+	if dbPath == "" {
+		if wordstore.IsRemote(w.Path) {
+			return errors.New("the database is remote, set the database of the user with -db")
+		}
+		dbPath = filepath.Join(filepath.Dir(w.Path), "users", name+".sqlite")
+	}
+	if !wordstore.IsRemote(dbPath) {
+		var err error
+		if dbPath, err = filepath.Abs(dbPath); err != nil {
+			return err
+		}
+	}
+	password, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Store.AddUser(w.Ctx, name, password, dbPath); err != nil {
+		return err
+	}
+	// create the database of the user now, so a bad path shows up at once
+	if err := initUserDB(w.Ctx, dbPath); err != nil {
+		w.Store.DeleteUser(w.Ctx, name)
+		return fmt.Errorf("create database of user '%s': %w", name, err)
+	}
+	log.Printf("add user '%s', words in %s", name, dbPath)
+	return w.NewUserToken(name)
+}
+
+func initUserDB(ctx context.Context, path string) error {
+	if !wordstore.IsRemote(path) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+	}
+	source, err := dbSource(path)
+	if err != nil {
+		return err
+	}
+	store, err := wordstore.Open(ctx, source)
+	if err != nil {
+		return err
+	}
+	return store.Close()
+}
+
+// change the password of a user to one read from the terminal
+func (w *WordDB) SetUserPassword(name string) error {
+	if _, err := w.Store.User(w.Ctx, name); err != nil {
+		return err
+	}
+	password, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	if err := w.Store.SetPassword(w.Ctx, name, password); err != nil {
+		return err
+	}
+	log.Printf("set password of user '%s'", name)
+	return nil
+}
+
+// create an api token of a user and print it, it can't be shown again
+func (w *WordDB) NewUserToken(name string) error {
+	token, err := w.Store.NewToken(w.Ctx, name)
+	if err != nil {
+		return err
+	}
+	log.Printf("new api token of user '%s', keep it, it's only shown once:", name)
+	fmt.Println(token)
+	return nil
+}
+
+func (w *WordDB) RevokeUserTokens(name string) error {
+	n, err := w.Store.RevokeTokens(w.Ctx, name)
+	if err != nil {
+		return err
+	}
+	log.Printf("revoke %d token(s) of user '%s'", n, name)
+	return nil
+}
+
+// delete a user, its database is kept
+func (w *WordDB) DelUser(name string) error {
+	user, err := w.Store.User(w.Ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := w.Store.DeleteUser(w.Ctx, name); err != nil {
+		return err
+	}
+	log.Printf("del user '%s', the database %s is kept", name, user.DbPath)
+	return nil
+}
+
+// switch w to the database of a user of serve -users, for --user, the
+// database of the users is kept to record the shares of the user
+func (w *WordDB) useUser(name string) error {
+	user, err := w.Store.User(w.Ctx, name)
+	if err != nil {
+		return err
+	}
+	source, err := dbSource(user.DbPath)
+	if err != nil {
+		return err
+	}
+	store, err := wordstore.Open(w.Ctx, source)
+	if err != nil {
+		return fmt.Errorf("open database of user %s: %w", user.Name, err)
+	}
+	w.Users, w.UserID = w.Store, user.ID
+	w.Db, w.Store, w.Path = store.DB(), store, user.DbPath
+	return nil
+}
+
+// the database of a user of serve -users, with its pages and api
+type userSite struct {
+	w       *WordDB
+	handler http.Handler
+}
+
+// serves each user of the database the pages and api of their own database,
+// for serve -users, so several people share a server without mixing their
+// words. Users log in with basic auth and their password, or with one of
+// their tokens like the token of the server. Databases are opened on the
+// first request of their user, and closed with the server.
+type userSites struct {
+	// the database of the users
+	w       *WordDB
+	backups BackupSchedule
+	// pages and api of the database of a user
+	newHandler func(site *WordDB) (http.Handler, *eventHub)
+	// event hubs and scheduled backups of the users stop with it
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	sites map[int64]*userSite
+	// password hashes by hashes of the names and passwords which matched
+	// them, since checking passwords is slow
+	passwords map[[sha256.Size]byte]string
+}
+
+func (w *WordDB) newUserSites(ctx context.Context, backups BackupSchedule, newHandler func(site *WordDB) (http.Handler, *eventHub)) (*userSites, error) {
+	users, err := w.Store.Users(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		log.Printf("Warning: serving users, but there are none yet, add them with '%s user add <name>'", progName())
+	} else {
+		log.Printf("Serve %d user(s), each with their own database", len(users))
+	}
+	s := &userSites{w: w, backups: backups, newHandler: newHandler, sites: make(map[int64]*userSite), passwords: make(map[[sha256.Size]byte]string)}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	return s, nil
+}
+
+// the user of the credentials of r, ErrUserNotFound if there are none or
+// they're wrong
+func (s *userSites) authenticate(r *http.Request) (worddb.User, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return s.w.Store.UserByToken(r.Context(), token)
+	}
+	name, password, ok := r.BasicAuth()
+	if !ok {
+		return worddb.User{}, wordstore.ErrUserNotFound
+	}
+	user, err := s.w.Store.User(r.Context(), name)
+	if err != nil {
+		return user, err
+	}
+	// a token is a password of its user too, so browsers can log in with it
+	if tokenUser, err := s.w.Store.UserByToken(r.Context(), password); err == nil && tokenUser.ID == user.ID {
+		return user, nil
+	}
+	// names have no colon
+	key := sha256.Sum256([]byte(user.Name + ":" + password))
+	s.mu.Lock()
+	hash := s.passwords[key]
+	s.mu.Unlock()
+	if hash == user.PasswordHash {
+		return user, nil
+	}
+	if !wordstore.CheckPassword(user, password) {
+		return worddb.User{}, wordstore.ErrUserNotFound
+	}
+	s.mu.Lock()
+	s.passwords[key] = user.PasswordHash
+	s.mu.Unlock()
+	return user, nil
+}
+
+// the site of user, its database is opened on the first call
+func (s *userSites) site(user worddb.User) (*userSite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if site, ok := s.sites[user.ID]; ok {
+		return site, nil
+	}
+
+	source, err := dbSource(user.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	store, err := wordstore.Open(s.w.Ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("open database of user %s: %w", user.Name, err)
+	}
+	w := &WordDB{Db: store.DB(), Store: store, Ctx: s.w.Ctx, Path: user.DbPath, AutoBackup: s.w.AutoBackup,
+		Translator: s.w.Translator, Phonetics: s.w.Phonetics, TransLang: s.w.TransLang}
+	handler, events := s.newHandler(w)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		events.run(s.ctx)
+	}()
+	if s.backups.Interval > 0 && !wordstore.IsRemote(w.Path) {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			w.runScheduledBackups(s.ctx, s.backups)
+		}()
+	}
+	log.Printf("Open database of user %s at %s", user.Name, user.DbPath)
+	site := &userSite{w: w, handler: handler}
+	s.sites[user.ID] = site
+	return site, nil
+}
+
+func (s *userSites) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	user, err := s.authenticate(r)
+	if errors.Is(err, wordstore.ErrUserNotFound) {
+		unauthorized(rw, r)
+		return
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	site, err := s.site(user)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	site.handler.ServeHTTP(rw, r)
+}
+
+// handler of the user of the token of a request, in the Authorization header
+// or the query, for capture and quick add, which check the token themselves
+func (s *userSites) withToken(handler func(site *WordDB, token string) http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		if token == "" {
+			unauthorized(rw, r)
+			return
+		}
+		user, err := s.w.Store.UserByToken(r.Context(), token)
+		if errors.Is(err, wordstore.ErrUserNotFound) {
+			unauthorized(rw, r)
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		site, err := s.site(user)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		handler(site.w, token).ServeHTTP(rw, r)
+	})
+}
+
+// handler of the user with the share of the token in the path, for the
+// share pages, which need no login. Users share with --user, which records
+// them as the owner of the share.
+func (s *userSites) withShare(handler func(site *WordDB) http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		user, err := s.w.Store.UserByShare(r.Context(), r.PathValue("token"))
+		if errors.Is(err, wordstore.ErrUserNotFound) {
+			http.Error(rw, wordstore.ErrShareNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		site, err := s.site(user)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		handler(site.w).ServeHTTP(rw, r)
+	})
+}
+
+// stop the event hubs and backups of the users, and close their databases
+func (s *userSites) Close() {
+	s.cancel()
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, site := range s.sites {
+		site.w.Store.Close()
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	store, err := wordstore.Open(ctx, filepath.Join(t.TempDir(), "users.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := store.AddUser(ctx, name, name+"-password", name+".sqlite"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	aliceToken, err := store.NewToken(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobToken, err := store.NewToken(ctx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &userSites{w: &WordDB{Store: store}, passwords: make(map[[sha256.Size]byte]string)}
+
+	tests := []struct {
+		name     string
+		bearer   string
+		user     string
+		password string
+		// empty if the credentials are rejected
+		want string
+	}{
+		{"no credentials", "", "", "", ""},
+		{"bearer token", aliceToken, "", "", "alice"},
+		{"wrong bearer token", "0123456789", "", "", ""},
+		{"password", "", "alice", "alice-password", "alice"},
+		{"cached password", "", "alice", "alice-password", "alice"},
+		{"wrong password", "", "alice", "bob-password", ""},
+		{"token as password", "", "bob", bobToken, "bob"},
+		{"token of another user", "", "bob", aliceToken, ""},
+		{"unknown user", "", "carol", "alice-password", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/words", nil)
+		if tt.bearer != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.bearer)
+		}
+		if tt.user != "" {
+			r.SetBasicAuth(tt.user, tt.password)
+		}
+		user, err := s.authenticate(r)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: authenticated as %s, want rejected", tt.name, user.Name)
+			}
+			continue
+		}
+		if err != nil || user.Name != tt.want {
+			t.Errorf("%s: user = %q, %v, want %s", tt.name, user.Name, err, tt.want)
+		}
+	}
+
+	// the cached password is dropped with the password, and tokens with
+	// their revocation
+	if err := store.SetPassword(ctx, "alice", "alice-new-password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.RevokeTokens(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/api/words", nil)
+	r.SetBasicAuth("alice", "alice-password")
+	if user, err := s.authenticate(r); err == nil {
+		t.Errorf("old password: authenticated as %s, want rejected", user.Name)
+	}
+	r = httptest.NewRequest("GET", "/api/words", nil)
+	r.Header.Set("Authorization", "Bearer "+aliceToken)
+	if user, err := s.authenticate(r); err == nil {
+		t.Errorf("revoked token: authenticated as %s, want rejected", user.Name)
+	}
+}
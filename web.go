@@ -0,0 +1,573 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// integer query param, or def if missing or invalid
+func queryInt(query url.Values, name string, def int) int {
+	n, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// online dictionary, {word} in URL is replaced with the word
+type Dictionary struct {
+	Name string
+	URL  string
+}
+
+// available dictionaries, the first one is the default of /lookup/
+var dictionaries = []Dictionary{
+	{"Cambridge", "https://dictionary.cambridge.org/dictionary/english-chinese-simplified/{word}"},
+	{"Youdao", "https://dict.youdao.com/result?lang=en&word={word}"},
+	{"Merriam-Webster", "https://www.merriam-webster.com/dictionary/{word}"},
+	{"Wiktionary", "https://en.wiktionary.org/wiki/{word}"},
+}
+
+func (d Dictionary) Link(word string) string {
+	return strings.ReplaceAll(d.URL, "{word}", url.PathEscape(word))
+}
+
+func dictionaryNames() string {
+	names := make([]string, 0, len(dictionaries))
+	for _, d := range dictionaries {
+		names = append(names, d.Name)
+	}
+	return strings.Join(names, "|")
+}
+
+// find dictionary by name, defaults to the first one
+func findDictionary(name string) Dictionary {
+	for _, d := range dictionaries {
+		if strings.EqualFold(d.Name, name) {
+			return d
+		}
+	}
+	return dictionaries[0]
+}
+
+// make dictionary the default, s is either a dictionary name or a custom URL
+// template containing {word}
+func setDefaultDictionary(s string) error {
+	if strings.Contains(s, "{word}") {
+		dictionaries = append([]Dictionary{{"Custom", s}}, dictionaries...)
+		return nil
+	}
+	for i, d := range dictionaries {
+		if strings.EqualFold(d.Name, s) {
+			dictionaries = append(append([]Dictionary{d}, dictionaries[:i]...), dictionaries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown dictionary %q, available: %s, or a URL template containing {word}", s, dictionaryNames())
+}
+
+// listen on addr, which is either host:port or a unix socket path like
+// unix:/run/w2r.sock or /run/w2r.sock
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok && !strings.Contains(addr, "/") {
+		return net.Listen("tcp", addr)
+	}
+	// remove the socket file left by last run
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// whether a tcp address can only be reached from this machine
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// options of the web server
+type ServeOptions struct {
+	// log each request
+	AccessLog bool
+	// requests per second of each client to the api and quick add, 0 for no
+	// limit, and the most requests at once
+	RateLimit float64
+	RateBurst int
+	// take client addresses from X-Forwarded-For or X-Real-IP of a reverse
+	// proxy
+	TrustProxy bool
+	TLS        TLSConfig
+	// path like /w2r to serve under behind a reverse proxy, empty for the
+	// root, see cleanBasePath
+	BasePath string
+	// serve the users of the database the words of their own databases,
+	// instead of the words of the database
+	Users bool
+}
+
+// base path with a leading slash and without a trailing one, empty for the
+// root, so links are base+"/word/x"
+func cleanBasePath(base string) string {
+	base = strings.Trim(strings.TrimSpace(base), "/")
+	if base == "" {
+		return ""
+	}
+	return path.Clean("/" + base)
+}
+
+// pages and api of the database of w, with the event hub of its open pages,
+// which runs with the server
+func (w *WordDB) webHandler(tmpl *template.Template, base string, spec []byte, requests *requestMetrics, limiter *rateLimiter) (http.Handler, *eventHub) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", w.cached(func(rw http.ResponseWriter, r *http.Request) {
+		queries := worddb.New(w.Db)
+		query := r.URL.Query()
+		tag, source, q := query.Get("tag"), query.Get("source"), strings.TrimSpace(query.Get("q"))
+		page, perPage := queryInt(query, "page", 1), queryInt(query, "per_page", config.Serve.PerPage)
+		page, perPage = max(page, 1), min(max(perPage, 1), config.Serve.MaxPerPage)
+
+		// ?deck=name selects a deck, the deck of the server by default
+		deck, deckID := query.Get("deck"), w.DeckID
+		var err error
+		if deck != "" {
+			deckID, err = w.Store.DeckID(r.Context(), deck)
+			if errors.Is(err, wordstore.ErrDeckNotFound) {
+				http.Error(rw, err.Error(), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				http.Error(rw, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if deckID != 0 {
+			deck = w.DeckName
+		}
+		decks, err := w.Store.Decks(r.Context())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		archived := query.Get("archived") == "1"
+		opts := wordstore.ListOptions{Filter: q, Tag: tag, Source: source, Archived: archived, DeckID: deckID, Limit: perPage, Offset: (page - 1) * perPage}
+		total, err := w.Store.Count(r.Context(), opts)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		words, err := w.Store.List(r.Context(), opts)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tags, err := queries.ListTags(r.Context())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		wordTags, err := w.Store.WordTags(r.Context())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		streak, err := w.streak(r.Context())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// links to the previous and next pages, keeping other params
+		pageURL := func(p int) string {
+			query.Set("page", strconv.Itoa(p))
+			return base + "/?" + query.Encode()
+		}
+		pages := max(int((total+int64(perPage)-1)/int64(perPage)), 1)
+		var prevURL, nextURL string
+		if page > 1 {
+			prevURL = pageURL(page - 1)
+		}
+		if page < pages {
+			nextURL = pageURL(page + 1)
+		}
+
+		data := struct {
+			Words    []worddb.Word
+			WordTags map[string][]string
+			Tags     []worddb.ListTagsRow
+			Tag      string
+			Source   string
+			Archived bool
+			Decks    []worddb.ListDecksRow
+			Deck     string
+			Query    string
+			Total    int64
+			Page     int
+			Pages    int
+			PrevURL  string
+			NextURL  string
+			Streak   Streak
+		}{words, wordTags, tags, tag, source, archived, decks, deck, q, total, page, pages, prevURL, nextURL, streak}
+		err = tmpl.ExecuteTemplate(rw, "words.html", data)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}))
+	// show single word
+	mux.HandleFunc("/word/{word}", w.cached(func(rw http.ResponseWriter, r *http.Request) {
+		queries := worddb.New(w.Db)
+		word, err := w.Store.Get(r.Context(), pathWord(r))
+		if errors.Is(err, wordstore.ErrNotFound) {
+			// inflected forms go to the page of their lemma
+			if lemma, err := queries.GetVariantWord(r.Context(), pathWord(r)); err == nil {
+				http.Redirect(rw, r, base+"/word/"+lemma, http.StatusFound)
+				return
+			}
+			http.Error(rw, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sentences, err := queries.ListSentences(r.Context(), word.Word)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		wordTags, err := w.Store.WordTags(r.Context())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		variants, err := w.Store.Variants(r.Context(), word.Word)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		translations, err := w.Store.Translations(r.Context(), word.Word)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sources, err := w.Store.WordSources(r.Context(), word.Word)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		mastery, archived, err := w.Store.WordMastery(r.Context(), word.Word)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// generated on demand by the enrich button
+		var enrichment *Enrichment
+		if e, err := queries.GetEnrichment(r.Context(), word.Word); err == nil {
+			result := newEnrichment(e)
+			enrichment = &result
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data := struct {
+			worddb.Word
+			Tags         []string
+			Variants     []string
+			Translations map[string]string
+			Sources      []string
+			Mastery      string
+			Archived     bool
+			Sentences    []worddb.Sentence
+			Dictionaries []Dictionary
+			Enrichment   *Enrichment
+		}{word, wordTags[word.Word], variants, translations, sources, mastery, archived, sentences, dictionaries, enrichment}
+		err = tmpl.ExecuteTemplate(rw, "word.html", data)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}))
+	// review due words in browser, with /api/review/*
+	mux.HandleFunc("/review", func(rw http.ResponseWriter, r *http.Request) {
+		err := tmpl.ExecuteTemplate(rw, "review.html", nil)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+	// charts of words added over time, with /api/stats
+	mux.HandleFunc("/stats", func(rw http.ResponseWriter, r *http.Request) {
+		err := tmpl.ExecuteTemplate(rw, "stats.html", nil)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+	// lookup word in online dictionary, select dictionary with ?dict=name
+	mux.HandleFunc("/lookup/", func(rw http.ResponseWriter, r *http.Request) {
+		word := strings.TrimPrefix(r.URL.Path, "/lookup/")
+		word = strings.TrimSuffix(word, "/")
+		if word == "" {
+			http.Error(rw, "word not found", http.StatusNotFound)
+			return
+		}
+
+		queries := worddb.New(w.Db)
+		if err := queries.AddLookupCount(r.Context(), word); err != nil {
+			log.Printf("add lookup count of '%s': %v", word, err)
+		}
+		// redirect to online dictionary, not permanently, so browsers don't
+		// skip the lookup count next time
+		http.Redirect(rw, r, findDictionary(r.URL.Query().Get("dict")).Link(word), http.StatusFound)
+
+	})
+	// for authors of api clients, swagger ui of the openapi document
+	mux.HandleFunc("GET /api/openapi.json", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+		rw.Write(spec)
+	})
+	mux.HandleFunc("GET /api/docs", func(rw http.ResponseWriter, r *http.Request) {
+		err := tmpl.ExecuteTemplate(rw, "apidocs.html", nil)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+	// pronunciation of the play buttons
+	mux.HandleFunc("GET /audio/{file}", serveAudio)
+	// for prometheus, with the same authentication as the rest
+	mux.Handle("GET /metrics", w.metricsHandler(requests))
+	// changes of words for open pages, from the command line too
+	events := newEventHub(w)
+	mux.Handle("GET /api/events", events)
+	// each request over the websocket is limited like one of the api
+	mux.Handle("GET /api/ws", w.websocketHandler(mux, limiter, events))
+	w.registerAPI(mux)
+	return mux, events
+}
+
+// create a http service to show all words, and generate links to online
+// dictionary, it runs until SIGINT or SIGTERM and then shuts down gracefully
+func (w *WordDB) RunWebServer(addr string, auth AuthConfig, backups BackupSchedule, reminders ReminderSchedule, opts ServeOptions) error {
+	// links of the pages start with the base path
+	base := opts.BasePath
+	tmpl, err := template.New("").Funcs(template.FuncMap{"date": formatDate, "base": func() string { return base }}).ParseFS(WordsHTML, "*.html")
+	if err != nil {
+		return err
+	}
+
+	// for authors of api clients, the openapi document of the swagger ui
+	spec, err := openAPIDocument(base)
+	if err != nil {
+		return err
+	}
+	requests := newRequestMetrics()
+	var limiter *rateLimiter
+	if opts.RateLimit > 0 {
+		limiter = newRateLimiter(opts.RateLimit, opts.RateBurst, opts.TrustProxy)
+	}
+	// with -users, each user is served the pages and api of their own
+	// database instead
+	var mux http.Handler
+	var events *eventHub
+	if !opts.Users {
+		mux, events = w.webHandler(tmpl, base, spec, requests, limiter)
+	}
+
+	var tlsConf *tls.Config
+	var challenges http.Handler
+	scheme := "http"
+	if opts.TLS.Enabled() {
+		if tlsConf, challenges, err = w.tlsConfig(opts.TLS); err != nil {
+			return err
+		}
+		scheme = "https"
+	}
+	l, err := listen(addr)
+	if err != nil {
+		return err
+	}
+	if l.Addr().Network() == "unix" {
+		log.Printf("Start web server at unix socket %s", l.Addr())
+	} else {
+		log.Printf("Start web server at %s://%s%s/", scheme, addr, opts.BasePath)
+		if len(opts.TLS.Autocert) > 0 {
+			log.Printf("Get certificates of Let's Encrypt for %s", strings.Join(opts.TLS.Autocert, ", "))
+		}
+		if !isLoopback(addr) && !auth.Enabled() && !opts.Users {
+			log.Printf("Warning: listening on %s without authentication, use -basic-auth or -token", addr)
+		}
+	}
+
+	// the scheduled backups, reminders and bots stop with the server, before the database is closed
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, stop := signal.NotifyContext(w.Ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// event streams end when it stops, or the shutdown would wait for them,
+	// those of users too
+	var users *userSites
+	if opts.Users {
+		if users, err = w.newUserSites(ctx, backups, func(site *WordDB) (http.Handler, *eventHub) {
+			return site.webHandler(tmpl, base, spec, requests, limiter)
+		}); err != nil {
+			return err
+		}
+		defer users.Close()
+	} else {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			events.run(ctx)
+		}(ctx)
+	}
+	if backups.Interval > 0 {
+		log.Printf("Back up database every %s to %s, keeping %d", backups.Interval, w.backupDir(), backups.Keep)
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			w.runScheduledBackups(ctx, backups)
+		}(ctx)
+	}
+	if len(reminders.Notify) > 0 {
+		log.Printf("Remind of due words and the daily goal %d time(s) a day, next at %s", len(reminders.Notify), nextNotifyTime(time.Now(), reminders.Notify).Format("15:04"))
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			w.runReminders(ctx, reminders.Notify)
+		}(ctx)
+	}
+	if config.Telegram.Token != "" {
+		bot, err := newTelegramBot(w, config.Telegram)
+		if err != nil {
+			return err
+		}
+		log.Printf("Start telegram bot for %d allowed chat(s)", len(config.Telegram.AllowedChats))
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			bot.run(ctx)
+		}(ctx)
+	}
+	if len(reminders.Digest) > 0 {
+		log.Printf("Mail the digest of %d day(s) to %s", reminders.DigestDays, strings.Join(config.Digest.To, ", "))
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			w.runDigests(ctx, reminders.Digest, reminders.DigestDays)
+		}(ctx)
+	}
+
+	// discord can't authenticate, its requests are signed instead, the
+	// capture endpoint checks its token after cors preflight requests, quick
+	// add takes its token in the query, and shares are open to anyone with
+	// their link
+	root := http.NewServeMux()
+	if users != nil {
+		// capture and quick add find the user of their token, cors preflight
+		// requests carry none
+		root.Handle("/", users)
+		root.Handle("/api/capture", users.withToken(func(site *WordDB, token string) http.Handler {
+			return site.captureHandler(token)
+		}))
+		root.Handle("OPTIONS /api/capture", w.captureHandler(""))
+		root.Handle("GET /add", users.withToken(func(site *WordDB, token string) http.Handler {
+			return site.quickAddHandler(tmpl, token)
+		}))
+		root.Handle("GET /share/{token}", users.withShare(func(site *WordDB) http.Handler {
+			return site.shareHandler(tmpl)
+		}))
+	} else {
+		root.Handle("/", auth.Wrap(mux))
+		root.Handle("/api/capture", w.captureHandler(auth.Token))
+		root.Handle("GET /add", w.quickAddHandler(tmpl, auth.Token))
+		root.Handle("GET /share/{token}", w.shareHandler(tmpl))
+	}
+	if config.Discord.PublicKey != "" {
+		bot, err := newDiscordBot(w, config.Discord)
+		if err != nil {
+			return err
+		}
+		root.Handle("POST /discord/interactions", bot)
+		log.Printf("Answer discord interactions at /discord/interactions")
+	}
+
+	// panics are recovered inside the access log and metrics, so they
+	// count as 500
+	var handler http.Handler = root
+	if limiter != nil {
+		handler = limiter.Wrap(handler)
+	}
+	// handlers see paths without the base path, the base path without a
+	// trailing slash is redirected by the mux
+	if opts.BasePath != "" {
+		mount := http.NewServeMux()
+		mount.Handle(opts.BasePath+"/", http.StripPrefix(opts.BasePath, handler))
+		handler = mount
+	}
+	handler = recoverPanics(compress(handler))
+	if opts.AccessLog {
+		handler = logRequests(handler)
+	}
+	srv := &http.Server{Handler: requests.Wrap(handler), TLSConfig: tlsConf}
+	errc := make(chan error, 2)
+	go func() {
+		if tlsConf != nil {
+			errc <- srv.ServeTLS(l, "", "")
+		} else {
+			errc <- srv.Serve(l)
+		}
+	}()
+	// answers http-01 challenges of let's encrypt and redirects to https
+	var challengeSrv *http.Server
+	if challenges != nil {
+		challengeSrv = &http.Server{Addr: opts.TLS.AutocertHTTP, Handler: challenges}
+		log.Printf("Answer ACME challenges and redirect to https at http://%s", opts.TLS.AutocertHTTP)
+		go func() {
+			errc <- challengeSrv.ListenAndServe()
+		}()
+	}
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	// wait for in-flight requests, the database is closed by the caller
+	log.Printf("Shutting down web server")
+	ctx, cancel := context.WithTimeout(context.Background(), config.Serve.ShutdownTimeout)
+	defer cancel()
+	if challengeSrv != nil {
+		challengeSrv.Shutdown(ctx)
+	}
+	return srv.Shutdown(ctx)
+}
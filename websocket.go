@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// the largest message a websocket client may send
+const wsMaxMessage = 1 << 20
+
+// a request over the websocket of /api/ws, the method, path with the query
+// and body are those of the rest api, the id is sent back with the reply
+type wsRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// a reply to a request, with its id, the status and body of the rest api,
+// or an event of /api/events pushed without an id
+type wsMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Status int             `json:"status,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Event  string          `json:"event,omitempty"`
+	Data   *event          `json:"data,omitempty"`
+}
+
+func wsError(id json.RawMessage, status int, msg string) wsMessage {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+	return wsMessage{ID: id, Status: status, Body: body}
+}
+
+// a response writer keeping the reply of the api in memory
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// serve req with the handlers of the rest api, as if it was sent by the
+// client of the websocket conn
+func wsDispatch(api http.Handler, conn *http.Request, req wsRequest) wsMessage {
+	u, err := url.Parse(req.Path)
+	if err != nil || !strings.HasPrefix(u.Path, "/api/") {
+		return wsError(req.ID, http.StatusBadRequest, "path must be one of the api, like /api/words")
+	}
+	// streams can't be replied in one message
+	if u.Path == "/api/ws" || u.Path == "/api/events" {
+		return wsError(req.ID, http.StatusBadRequest, "can't request "+u.Path+" over the websocket")
+	}
+	r, err := http.NewRequestWithContext(conn.Context(), cmp.Or(strings.ToUpper(req.Method), http.MethodGet), u.RequestURI(), bytes.NewReader(req.Body))
+	if err != nil {
+		return wsError(req.ID, http.StatusBadRequest, err.Error())
+	}
+	r.Host = conn.Host
+	r.RemoteAddr = conn.RemoteAddr
+	r.Header.Set("Content-Type", "application/json")
+
+	rec := &bufferedResponse{header: make(http.Header)}
+	api.ServeHTTP(rec, r)
+	reply := wsMessage{ID: req.ID, Status: cmp.Or(rec.status, http.StatusOK)}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case strings.HasPrefix(rec.header.Get("Content-Type"), "application/json"):
+		reply.Body = body
+	default:
+		// like errors of http.Error
+		reply.Body, _ = json.Marshal(map[string]string{"error": string(body)})
+	}
+	return reply
+}
+
+// a response writer reaching the connection through the writers of the
+// middleware, which x/net/websocket takes over with http.Hijacker
+type hijackWriter struct {
+	http.ResponseWriter
+}
+
+func (h hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
+
+// only pages of the server may open websockets, browsers send the basic auth
+// credentials of the server with websockets opened by any page
+func wsCheckOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// not a browser
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return errors.New("websocket from another origin")
+	}
+	return nil
+}
+
+// serve the websocket of GET /api/ws: clients send requests of the rest api
+// as messages like {"id": 1, "method": "POST", "path": "/api/words", "body":
+// {"word": "kiwi"}} and get replies like {"id": 1, "status": 201, "body":
+// {...}} in order, the events of /api/events are pushed as {"event":
+// "word-added", "data": {...}}. Requests are rate limited like the api, if
+// limiter is set.
+func (w *WordDB) websocketHandler(api http.Handler, limiter *rateLimiter, events *eventHub) http.Handler {
+	server := websocket.Server{Handshake: wsCheckOrigin, Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		ws.MaxPayloadBytes = wsMaxMessage
+		conn := ws.Request()
+
+		// push the events until the client is gone, close the websocket
+		// when the server shuts down
+		changes := events.subscribe()
+		defer events.unsubscribe(changes)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-events.done:
+					ws.Close()
+					return
+				case e := <-changes:
+					if err := websocket.JSON.Send(ws, wsMessage{Event: e.Type, Data: &e}); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		for {
+			var data []byte
+			if err := websocket.Message.Receive(ws, &data); err != nil {
+				if !errors.Is(err, io.EOF) && !errors.Is(err, websocket.ErrFrameTooLarge) && !errors.Is(err, net.ErrClosed) {
+					log.Printf("receive websocket message: %v", err)
+				}
+				return
+			}
+			var req wsRequest
+			var reply wsMessage
+			if err := json.Unmarshal(data, &req); err != nil {
+				reply = wsError(nil, http.StatusBadRequest, "invalid message: "+err.Error())
+			} else if ok, wait := limiter.allowRequest(conn, time.Now()); !ok {
+				reply = wsError(req.ID, http.StatusTooManyRequests, "too many requests, retry after "+wait.Round(time.Millisecond).String())
+			} else {
+				reply = wsDispatch(api, conn, req)
+			}
+			if err := websocket.JSON.Send(ws, reply); err != nil {
+				return
+			}
+		}
+	}}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(hijackWriter{rw}, r)
+	})
+}
@@ -6,11 +6,188 @@ package worddb
 
 import (
 	"database/sql"
+	"time"
 )
 
+type DailyActivity struct {
+	Day     string
+	Reviews int64
+}
+
+type DataRevision struct {
+	ID        int64
+	Revision  int64
+	UpdatedAt time.Time
+}
+
+type Deck struct {
+	ID   int64
+	Name string
+}
+
+type Enrichment struct {
+	Word       string
+	Definition string
+	Mnemonic   string
+	Examples   string
+	Model      string
+	CreatedAt  time.Time
+}
+
+type Journal struct {
+	ID        int64
+	Op        string
+	CreatedAt time.Time
+}
+
+type JournalWord struct {
+	ID          int64
+	JournalID   int64
+	Word        string
+	Existed     bool
+	ZhTrans     sql.NullString
+	AddedCount  sql.NullInt64
+	LookupCount sql.NullInt64
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Note        sql.NullString
+	Context     sql.NullString
+	DeletedAt   sql.NullTime
+	DeckID      sql.NullInt64
+}
+
+type QuizStat struct {
+	Word     string
+	Attempts int64
+	Correct  int64
+}
+
+type RelatedWord struct {
+	Word    string
+	Related string
+	Kind    string
+	Score   int64
+}
+
+type Review struct {
+	Word         string
+	Ease         float64
+	IntervalDays int64
+	Repetitions  int64
+	DueAt        time.Time
+}
+
+type ReviewLog struct {
+	ID               int64
+	Word             string
+	Grade            int64
+	LatencyMs        sql.NullInt64
+	IntervalDays     int64
+	NextIntervalDays int64
+	ReviewedAt       time.Time
+}
+
+type Sentence struct {
+	ID       int64
+	Word     string
+	Sentence string
+	Source   sql.NullString
+}
+
+type SearchIndex struct {
+	ID        int64
+	Revision  int64
+	RebuiltAt time.Time
+}
+
+type Share struct {
+	Token     string
+	Title     string
+	DeckID    int64
+	Tag       string
+	Words     string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+type SyncState struct {
+	Remote         string
+	LocalSyncedAt  time.Time
+	RemoteSyncedAt time.Time
+}
+
+type Tag struct {
+	ID   int64
+	Name string
+}
+
+type Translation struct {
+	Word      string
+	Lang      string
+	Trans     string
+	UpdatedAt time.Time
+}
+
+type User struct {
+	ID           int64
+	Name         string
+	PasswordHash string
+	DbPath       string
+	CreatedAt    time.Time
+}
+
+type UserShare struct {
+	Token     string
+	UserID    int64
+	CreatedAt time.Time
+}
+
+type UserToken struct {
+	TokenHash string
+	UserID    int64
+	CreatedAt time.Time
+}
+
 type Word struct {
 	Word        string
 	ZhTrans     sql.NullString
 	AddedCount  sql.NullInt64
 	LookupCount sql.NullInt64
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Note        sql.NullString
+	Context     sql.NullString
+	DeletedAt   sql.NullTime
+	DeckID      int64
+	Phonetic    sql.NullString
+	Level       sql.NullString
+}
+
+type WordTag struct {
+	Word  string
+	TagID int64
+}
+
+type WordList struct {
+	Word    string
+	List    string
+	AddedAt time.Time
+}
+
+type WordMastery struct {
+	Word       string
+	State      string
+	ArchivedAt sql.NullTime
+	UpdatedAt  time.Time
+}
+
+type WordSource struct {
+	Word    string
+	Source  string
+	AddedAt time.Time
+}
+
+type WordVariant struct {
+	Variant string
+	Word    string
 }
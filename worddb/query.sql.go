@@ -8,11 +8,140 @@ package worddb
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
+const addDailyReview = `-- name: AddDailyReview :exec
+INSERT INTO daily_activity (day, reviews) VALUES (?, 1)
+ON CONFLICT (day) DO UPDATE SET reviews = reviews + 1
+`
+
+func (q *Queries) AddDailyReview(ctx context.Context, day string) error {
+	_, err := q.db.ExecContext(ctx, addDailyReview, day)
+	return err
+}
+
+const addListedWord = `-- name: AddListedWord :execrows
+INSERT INTO word_list (word, list, added_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (word) DO UPDATE SET list = excluded.list, added_at = excluded.added_at
+WHERE word_list.list != excluded.list
+`
+
+type AddListedWordParams struct {
+	Word string
+	List string
+}
+
+func (q *Queries) AddListedWord(ctx context.Context, arg AddListedWordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addListedWord, arg.Word, arg.List)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addLookupCount = `-- name: AddLookupCount :exec
+UPDATE word
+SET lookup_count = IFNULL(lookup_count, 0) + 1, updated_at = CURRENT_TIMESTAMP
+WHERE word = ?
+`
+
+func (q *Queries) AddLookupCount(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, addLookupCount, word)
+	return err
+}
+
+const addRelatedWord = `-- name: AddRelatedWord :exec
+INSERT OR REPLACE INTO related_word (
+  word, related, kind, score
+) VALUES (
+  ?, ?, ?, ?
+)
+`
+
+type AddRelatedWordParams struct {
+	Word    string
+	Related string
+	Kind    string
+	Score   int64
+}
+
+func (q *Queries) AddRelatedWord(ctx context.Context, arg AddRelatedWordParams) error {
+	_, err := q.db.ExecContext(ctx, addRelatedWord,
+		arg.Word,
+		arg.Related,
+		arg.Kind,
+		arg.Score,
+	)
+	return err
+}
+
+const addReviewLog = `-- name: AddReviewLog :exec
+INSERT INTO review_log (word, grade, latency_ms, interval_days, next_interval_days, reviewed_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+type AddReviewLogParams struct {
+	Word             string
+	Grade            int64
+	LatencyMs        sql.NullInt64
+	IntervalDays     int64
+	NextIntervalDays int64
+	ReviewedAt       time.Time
+}
+
+func (q *Queries) AddReviewLog(ctx context.Context, arg AddReviewLogParams) error {
+	_, err := q.db.ExecContext(ctx, addReviewLog,
+		arg.Word,
+		arg.Grade,
+		arg.LatencyMs,
+		arg.IntervalDays,
+		arg.NextIntervalDays,
+		arg.ReviewedAt,
+	)
+	return err
+}
+
+const addSentence = `-- name: AddSentence :execrows
+INSERT OR IGNORE INTO sentence (
+  word, sentence, source
+) VALUES (
+  ?, ?, ?
+)
+`
+
+type AddSentenceParams struct {
+	Word     string
+	Sentence string
+	Source   sql.NullString
+}
+
+func (q *Queries) AddSentence(ctx context.Context, arg AddSentenceParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, addSentence, arg.Word, arg.Sentence, arg.Source)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addVariant = `-- name: AddVariant :exec
+INSERT INTO word_variant (variant, word) VALUES (?, ?)
+ON CONFLICT (variant) DO UPDATE SET word = excluded.word
+`
+
+type AddVariantParams struct {
+	Variant string
+	Word    string
+}
+
+func (q *Queries) AddVariant(ctx context.Context, arg AddVariantParams) error {
+	_, err := q.db.ExecContext(ctx, addVariant, arg.Variant, arg.Word)
+	return err
+}
+
 const addWordCount = `-- name: AddWordCount :exec
 UPDATE word
-set added_count=added_count+1
+set added_count=added_count+1, updated_at=CURRENT_TIMESTAMP, deleted_at=NULL
 WHERE word = ?
 `
 
@@ -21,6 +150,85 @@ func (q *Queries) AddWordCount(ctx context.Context, word string) error {
 	return err
 }
 
+const addWordSource = `-- name: AddWordSource :exec
+INSERT INTO word_source (word, source, added_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (word, source) DO NOTHING
+`
+
+type AddWordSourceParams struct {
+	Word   string
+	Source string
+}
+
+func (q *Queries) AddWordSource(ctx context.Context, arg AddWordSourceParams) error {
+	_, err := q.db.ExecContext(ctx, addWordSource, arg.Word, arg.Source)
+	return err
+}
+
+const archiveWord = `-- name: ArchiveWord :execrows
+INSERT INTO word_mastery (word, state, archived_at, updated_at)
+VALUES (?, 'new', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+ON CONFLICT (word) DO UPDATE SET
+  archived_at = excluded.archived_at,
+  updated_at = excluded.updated_at
+WHERE word_mastery.archived_at IS NULL
+`
+
+func (q *Queries) ArchiveWord(ctx context.Context, word string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveWord, word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countArchivedWords = `-- name: CountArchivedWords :one
+SELECT COUNT(*)
+FROM word JOIN word_mastery ON word_mastery.word = word.word
+WHERE word.deleted_at IS NULL AND word_mastery.archived_at IS NOT NULL
+  AND (?1 = 0 OR word.deck_id = ?1)
+`
+
+func (q *Queries) CountArchivedWords(ctx context.Context, deckID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countArchivedWords, deckID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countDueWords = `-- name: CountDueWords :one
+SELECT COUNT(*)
+FROM word LEFT JOIN review ON review.word = word.word
+WHERE word.deleted_at IS NULL AND (review.due_at IS NULL OR review.due_at <= ?1)
+  AND (?2 = 0 OR word.deck_id = ?2)
+  AND word.word NOT IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  )
+`
+
+type CountDueWordsParams struct {
+	DueAt  time.Time
+	DeckID int64
+}
+
+func (q *Queries) CountDueWords(ctx context.Context, arg CountDueWordsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDueWords, arg.DueAt, arg.DeckID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countReviews = `-- name: CountReviews :one
+SELECT CAST(IFNULL(SUM(reviews), 0) AS INTEGER) AS reviews FROM daily_activity
+`
+
+func (q *Queries) CountReviews(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countReviews)
+	var reviews int64
+	err := row.Scan(&reviews)
+	return reviews, err
+}
+
 const countWord = `-- name: CountWord :one
 SELECT COUNT(*) FROM word WHERE word = ?
 `
@@ -32,87 +240,2502 @@ func (q *Queries) CountWord(ctx context.Context, word string) (int64, error) {
 	return count, err
 }
 
+const countWordsAddedPerDay = `-- name: CountWordsAddedPerDay :many
+SELECT CAST(date(created_at, 'localtime') AS TEXT) AS day, COUNT(*) AS words
+FROM word
+WHERE deleted_at IS NULL AND created_at IS NOT NULL
+  AND (?1 = 0 OR deck_id = ?1)
+GROUP BY day
+ORDER BY day
+`
+
+type CountWordsAddedPerDayRow struct {
+	Day   string
+	Words int64
+}
+
+func (q *Queries) CountWordsAddedPerDay(ctx context.Context, deckID int64) ([]CountWordsAddedPerDayRow, error) {
+	rows, err := q.db.QueryContext(ctx, countWordsAddedPerDay, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountWordsAddedPerDayRow
+	for rows.Next() {
+		var i CountWordsAddedPerDayRow
+		if err := rows.Scan(&i.Day, &i.Words); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countWordsByLevel = `-- name: CountWordsByLevel :many
+SELECT IFNULL(level, '') AS level, COUNT(*) AS words
+FROM word
+WHERE deleted_at IS NULL AND (?1 = 0 OR deck_id = ?1)
+GROUP BY level
+ORDER BY level
+`
+
+type CountWordsByLevelRow struct {
+	Level string
+	Words int64
+}
+
+func (q *Queries) CountWordsByLevel(ctx context.Context, deckID int64) ([]CountWordsByLevelRow, error) {
+	rows, err := q.db.QueryContext(ctx, countWordsByLevel, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountWordsByLevelRow
+	for rows.Next() {
+		var i CountWordsByLevelRow
+		if err := rows.Scan(&i.Level, &i.Words); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countWordsByMastery = `-- name: CountWordsByMastery :many
+SELECT IFNULL(word_mastery.state, 'new') AS state, COUNT(*) AS words
+FROM word LEFT JOIN word_mastery ON word_mastery.word = word.word
+WHERE word.deleted_at IS NULL AND (?1 = 0 OR word.deck_id = ?1)
+GROUP BY 1
+ORDER BY 1
+`
+
+type CountWordsByMasteryRow struct {
+	State string
+	Words int64
+}
+
+func (q *Queries) CountWordsByMastery(ctx context.Context, deckID int64) ([]CountWordsByMasteryRow, error) {
+	rows, err := q.db.QueryContext(ctx, countWordsByMastery, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountWordsByMasteryRow
+	for rows.Next() {
+		var i CountWordsByMasteryRow
+		if err := rows.Scan(&i.State, &i.Words); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countWordsFiltered = `-- name: CountWordsFiltered :one
+SELECT count(*) FROM word
+WHERE deleted_at IS NULL
+  AND (word LIKE '%' || ?1 || '%' ESCAPE '\' OR IFNULL(zh_trans, '') LIKE '%' || ?1 || '%' ESCAPE '\')
+  AND (CAST(?2 AS TEXT) = '' OR word IN (
+    SELECT word_tag.word FROM word_tag JOIN tag ON tag.id = word_tag.tag_id
+    WHERE tag.name = ?2
+  ))
+  AND (?3 = 0 OR deck_id = ?3)
+  AND (CAST(?4 AS TEXT) = '' OR level = ?4)
+  AND (CAST(?5 AS TEXT) = '' OR word IN (
+    SELECT word_source.word FROM word_source
+    WHERE word_source.source LIKE ?5 || '%' ESCAPE '\'
+  ))
+  AND (word IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  )) = CAST(?6 AS BOOLEAN)
+  AND (CAST(?7 AS TEXT) = '' OR IFNULL((
+    SELECT word_mastery.state FROM word_mastery WHERE word_mastery.word = word.word
+  ), 'new') = ?7)
+`
+
+type CountWordsFilteredParams struct {
+	Filter   string
+	Tag      string
+	DeckID   int64
+	Level    string
+	Source   string
+	Archived bool
+	Mastery  string
+}
+
+func (q *Queries) CountWordsFiltered(ctx context.Context, arg CountWordsFilteredParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWordsFiltered,
+		arg.Filter,
+		arg.Tag,
+		arg.DeckID,
+		arg.Level,
+		arg.Source,
+		arg.Archived,
+		arg.Mastery,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createDeck = `-- name: CreateDeck :one
+INSERT INTO deck (name) VALUES (?)
+RETURNING id
+`
+
+func (q *Queries) CreateDeck(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createDeck, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createJournal = `-- name: CreateJournal :one
+INSERT INTO journal (op, created_at) VALUES (?, CURRENT_TIMESTAMP)
+RETURNING id
+`
+
+func (q *Queries) CreateJournal(ctx context.Context, op string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createJournal, op)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createShare = `-- name: CreateShare :exec
+INSERT INTO share (token, title, deck_id, tag, words, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)
+`
+
+type CreateShareParams struct {
+	Token     string
+	Title     string
+	DeckID    int64
+	Tag       string
+	Words     string
+	ExpiresAt sql.NullTime
+}
+
+func (q *Queries) CreateShare(ctx context.Context, arg CreateShareParams) error {
+	_, err := q.db.ExecContext(ctx, createShare,
+		arg.Token,
+		arg.Title,
+		arg.DeckID,
+		arg.Tag,
+		arg.Words,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createTag = `-- name: CreateTag :one
+INSERT INTO tag (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = name
+RETURNING id
+`
+
+func (q *Queries) CreateTag(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createTag, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createUser = `-- name: CreateUser :one
+INSERT INTO user (name, password_hash, db_path, created_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING id
+`
+
+type CreateUserParams struct {
+	Name         string
+	PasswordHash string
+	DbPath       string
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.Name, arg.PasswordHash, arg.DbPath)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createUserShare = `-- name: CreateUserShare :exec
+INSERT INTO user_share (token, user_id, created_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+`
+
+type CreateUserShareParams struct {
+	Token  string
+	UserID int64
+}
+
+func (q *Queries) CreateUserShare(ctx context.Context, arg CreateUserShareParams) error {
+	_, err := q.db.ExecContext(ctx, createUserShare, arg.Token, arg.UserID)
+	return err
+}
+
+const createUserToken = `-- name: CreateUserToken :exec
+INSERT INTO user_token (token_hash, user_id, created_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+`
+
+type CreateUserTokenParams struct {
+	TokenHash string
+	UserID    int64
+}
+
+func (q *Queries) CreateUserToken(ctx context.Context, arg CreateUserTokenParams) error {
+	_, err := q.db.ExecContext(ctx, createUserToken, arg.TokenHash, arg.UserID)
+	return err
+}
+
 const createWord = `-- name: CreateWord :one
 INSERT INTO word (
-  word, zh_trans, added_count, lookup_count
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, deck_id, level
 ) VALUES (
-  ?, ?, 0, 0
+  ?, ?, 0, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, ?, ?
 )
-RETURNING word, zh_trans, added_count, lookup_count
+RETURNING word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, phonetic, level
 `
 
 type CreateWordParams struct {
 	Word    string
 	ZhTrans sql.NullString
+	DeckID  int64
+	Level   sql.NullString
 }
 
 func (q *Queries) CreateWord(ctx context.Context, arg CreateWordParams) (Word, error) {
-	row := q.db.QueryRowContext(ctx, createWord, arg.Word, arg.ZhTrans)
+	row := q.db.QueryRowContext(ctx, createWord,
+		arg.Word,
+		arg.ZhTrans,
+		arg.DeckID,
+		arg.Level,
+	)
 	var i Word
 	err := row.Scan(
 		&i.Word,
 		&i.ZhTrans,
 		&i.AddedCount,
 		&i.LookupCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Note,
+		&i.Context,
+		&i.DeletedAt,
+		&i.DeckID,
+		&i.Phonetic,
+		&i.Level,
 	)
 	return i, err
 }
 
-const deleteWord = `-- name: DeleteWord :exec
-DELETE FROM word
+const deleteDeck = `-- name: DeleteDeck :exec
+DELETE FROM deck
+WHERE id = ?
+`
+
+func (q *Queries) DeleteDeck(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteDeck, id)
+	return err
+}
+
+const deleteEnrichment = `-- name: DeleteEnrichment :exec
+DELETE FROM enrichment
 WHERE word = ?
 `
 
-func (q *Queries) DeleteWord(ctx context.Context, word string) error {
-	_, err := q.db.ExecContext(ctx, deleteWord, word)
+func (q *Queries) DeleteEnrichment(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteEnrichment, word)
 	return err
 }
 
-const getWord = `-- name: GetWord :one
-SELECT word, zh_trans, added_count, lookup_count FROM word
-WHERE word = ? LIMIT 1
+const deleteJournal = `-- name: DeleteJournal :exec
+DELETE FROM journal
+WHERE id = ?
 `
 
-func (q *Queries) GetWord(ctx context.Context, word string) (Word, error) {
-	row := q.db.QueryRowContext(ctx, getWord, word)
-	var i Word
-	err := row.Scan(
-		&i.Word,
-		&i.ZhTrans,
-		&i.AddedCount,
-		&i.LookupCount,
-	)
-	return i, err
+func (q *Queries) DeleteJournal(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteJournal, id)
+	return err
 }
 
-const listword = `-- name: Listword :many
-SELECT word, zh_trans, added_count, lookup_count FROM word
+const deleteJournalWords = `-- name: DeleteJournalWords :exec
+DELETE FROM journal_word
+WHERE journal_id = ?
+`
+
+func (q *Queries) DeleteJournalWords(ctx context.Context, journalID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteJournalWords, journalID)
+	return err
+}
+
+const deleteLangTranslation = `-- name: DeleteLangTranslation :execrows
+DELETE FROM translation
+WHERE word = ? AND lang = ?
 `
 
-func (q *Queries) Listword(ctx context.Context) ([]Word, error) {
-	rows, err := q.db.QueryContext(ctx, listword)
+type DeleteLangTranslationParams struct {
+	Word string
+	Lang string
+}
+
+func (q *Queries) DeleteLangTranslation(ctx context.Context, arg DeleteLangTranslationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteLangTranslation, arg.Word, arg.Lang)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []Word
-	for rows.Next() {
-		var i Word
-		if err := rows.Scan(
-			&i.Word,
-			&i.ZhTrans,
-			&i.AddedCount,
-			&i.LookupCount,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+	return result.RowsAffected()
+}
+
+const deleteListedWord = `-- name: DeleteListedWord :execrows
+DELETE FROM word_list
+WHERE word = ? AND list = ?
+`
+
+type DeleteListedWordParams struct {
+	Word string
+	List string
+}
+
+func (q *Queries) DeleteListedWord(ctx context.Context, arg DeleteListedWordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteListedWord, arg.Word, arg.List)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
+	return result.RowsAffected()
+}
+
+const deleteMastery = `-- name: DeleteMastery :exec
+DELETE FROM word_mastery
+WHERE word = ?
+`
+
+func (q *Queries) DeleteMastery(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteMastery, word)
+	return err
+}
+
+const deleteQuizStat = `-- name: DeleteQuizStat :exec
+DELETE FROM quiz_stat
+WHERE word = ?
+`
+
+func (q *Queries) DeleteQuizStat(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteQuizStat, word)
+	return err
+}
+
+const deleteRelatedWords = `-- name: DeleteRelatedWords :exec
+DELETE FROM related_word
+WHERE word = ?
+`
+
+func (q *Queries) DeleteRelatedWords(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteRelatedWords, word)
+	return err
+}
+
+const deleteReview = `-- name: DeleteReview :exec
+DELETE FROM review
+WHERE word = ?
+`
+
+func (q *Queries) DeleteReview(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteReview, word)
+	return err
+}
+
+const deleteReviewLog = `-- name: DeleteReviewLog :exec
+DELETE FROM review_log
+WHERE word = ?
+`
+
+func (q *Queries) DeleteReviewLog(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteReviewLog, word)
+	return err
+}
+
+const deleteSentence = `-- name: DeleteSentence :execrows
+DELETE FROM sentence
+WHERE id = ?
+`
+
+func (q *Queries) DeleteSentence(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSentence, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSentences = `-- name: DeleteSentences :exec
+DELETE FROM sentence
+WHERE word = ?
+`
+
+func (q *Queries) DeleteSentences(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteSentences, word)
+	return err
+}
+
+const deleteShare = `-- name: DeleteShare :execrows
+DELETE FROM share
+WHERE token = ?
+`
+
+func (q *Queries) DeleteShare(ctx context.Context, token string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteShare, token)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTranslations = `-- name: DeleteTranslations :exec
+DELETE FROM translation
+WHERE word = ?
+`
+
+func (q *Queries) DeleteTranslations(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteTranslations, word)
+	return err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM user
+WHERE id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const deleteUserShare = `-- name: DeleteUserShare :exec
+DELETE FROM user_share
+WHERE token = ?
+`
+
+func (q *Queries) DeleteUserShare(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserShare, token)
+	return err
+}
+
+const deleteUserShares = `-- name: DeleteUserShares :exec
+DELETE FROM user_share
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserShares(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserShares, userID)
+	return err
+}
+
+const deleteUserTokens = `-- name: DeleteUserTokens :execrows
+DELETE FROM user_token
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteUserTokens(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteVariants = `-- name: DeleteVariants :exec
+DELETE FROM word_variant
+WHERE word = ?
+`
+
+func (q *Queries) DeleteVariants(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteVariants, word)
+	return err
+}
+
+const deleteWord = `-- name: DeleteWord :execrows
+DELETE FROM word
+WHERE word = ?
+`
+
+func (q *Queries) DeleteWord(ctx context.Context, word string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWord, word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteWordSources = `-- name: DeleteWordSources :exec
+DELETE FROM word_source
+WHERE word = ?
+`
+
+func (q *Queries) DeleteWordSources(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteWordSources, word)
+	return err
+}
+
+const deleteWordTags = `-- name: DeleteWordTags :exec
+DELETE FROM word_tag
+WHERE word = ?
+`
+
+func (q *Queries) DeleteWordTags(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, deleteWordTags, word)
+	return err
+}
+
+const forgetJournalWord = `-- name: ForgetJournalWord :exec
+DELETE FROM journal_word
+WHERE word = ?
+`
+
+func (q *Queries) ForgetJournalWord(ctx context.Context, word string) error {
+	_, err := q.db.ExecContext(ctx, forgetJournalWord, word)
+	return err
+}
+
+const getDataRevision = `-- name: GetDataRevision :one
+SELECT revision, updated_at FROM data_revision
+WHERE id = 1
+`
+
+type GetDataRevisionRow struct {
+	Revision  int64
+	UpdatedAt time.Time
+}
+
+func (q *Queries) GetDataRevision(ctx context.Context) (GetDataRevisionRow, error) {
+	row := q.db.QueryRowContext(ctx, getDataRevision)
+	var i GetDataRevisionRow
+	err := row.Scan(&i.Revision, &i.UpdatedAt)
+	return i, err
+}
+
+const getDeckID = `-- name: GetDeckID :one
+SELECT id FROM deck
+WHERE name = ?
+`
+
+func (q *Queries) GetDeckID(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getDeckID, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getEnrichment = `-- name: GetEnrichment :one
+SELECT word, definition, mnemonic, examples, model, created_at FROM enrichment
+WHERE word = ?
+`
+
+func (q *Queries) GetEnrichment(ctx context.Context, word string) (Enrichment, error) {
+	row := q.db.QueryRowContext(ctx, getEnrichment, word)
+	var i Enrichment
+	err := row.Scan(
+		&i.Word,
+		&i.Definition,
+		&i.Mnemonic,
+		&i.Examples,
+		&i.Model,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLastJournal = `-- name: GetLastJournal :one
+SELECT id, op, created_at FROM journal
+ORDER BY id DESC LIMIT 1
+`
+
+func (q *Queries) GetLastJournal(ctx context.Context) (Journal, error) {
+	row := q.db.QueryRowContext(ctx, getLastJournal)
+	var i Journal
+	err := row.Scan(&i.ID, &i.Op, &i.CreatedAt)
+	return i, err
+}
+
+const getMastery = `-- name: GetMastery :one
+SELECT word, state, archived_at, updated_at FROM word_mastery
+WHERE word = ?
+`
+
+func (q *Queries) GetMastery(ctx context.Context, word string) (WordMastery, error) {
+	row := q.db.QueryRowContext(ctx, getMastery, word)
+	var i WordMastery
+	err := row.Scan(
+		&i.Word,
+		&i.State,
+		&i.ArchivedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRandomSentence = `-- name: GetRandomSentence :one
+SELECT id, word, sentence, source FROM sentence
+WHERE word = ?
+ORDER BY random()
+LIMIT 1
+`
+
+func (q *Queries) GetRandomSentence(ctx context.Context, word string) (Sentence, error) {
+	row := q.db.QueryRowContext(ctx, getRandomSentence, word)
+	var i Sentence
+	err := row.Scan(
+		&i.ID,
+		&i.Word,
+		&i.Sentence,
+		&i.Source,
+	)
+	return i, err
+}
+
+const getReview = `-- name: GetReview :one
+SELECT word, ease, interval_days, repetitions, due_at FROM review
+WHERE word = ?
+`
+
+func (q *Queries) GetReview(ctx context.Context, word string) (Review, error) {
+	row := q.db.QueryRowContext(ctx, getReview, word)
+	var i Review
+	err := row.Scan(
+		&i.Word,
+		&i.Ease,
+		&i.IntervalDays,
+		&i.Repetitions,
+		&i.DueAt,
+	)
+	return i, err
+}
+
+const getSearchRevision = `-- name: GetSearchRevision :one
+SELECT revision FROM search_index
+WHERE id = 1
+`
+
+func (q *Queries) GetSearchRevision(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getSearchRevision)
+	var revision int64
+	err := row.Scan(&revision)
+	return revision, err
+}
+
+const getShare = `-- name: GetShare :one
+SELECT token, title, deck_id, tag, words, created_at, expires_at FROM share
+WHERE token = ?
+`
+
+func (q *Queries) GetShare(ctx context.Context, token string) (Share, error) {
+	row := q.db.QueryRowContext(ctx, getShare, token)
+	var i Share
+	err := row.Scan(
+		&i.Token,
+		&i.Title,
+		&i.DeckID,
+		&i.Tag,
+		&i.Words,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getSyncState = `-- name: GetSyncState :one
+SELECT remote, local_synced_at, remote_synced_at FROM sync_state
+WHERE remote = ?
+`
+
+func (q *Queries) GetSyncState(ctx context.Context, remote string) (SyncState, error) {
+	row := q.db.QueryRowContext(ctx, getSyncState, remote)
+	var i SyncState
+	err := row.Scan(&i.Remote, &i.LocalSyncedAt, &i.RemoteSyncedAt)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, name, password_hash, db_path, created_at FROM user
+WHERE name = ?
+`
+
+func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, name)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PasswordHash,
+		&i.DbPath,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserByShare = `-- name: GetUserByShare :one
+SELECT user.id, user.name, user.password_hash, user.db_path, user.created_at FROM user
+JOIN user_share ON user_share.user_id = user.id
+WHERE user_share.token = ?
+`
+
+func (q *Queries) GetUserByShare(ctx context.Context, token string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByShare, token)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PasswordHash,
+		&i.DbPath,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserByToken = `-- name: GetUserByToken :one
+SELECT user.id, user.name, user.password_hash, user.db_path, user.created_at FROM user
+JOIN user_token ON user_token.user_id = user.id
+WHERE user_token.token_hash = ?
+`
+
+func (q *Queries) GetUserByToken(ctx context.Context, tokenHash string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByToken, tokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PasswordHash,
+		&i.DbPath,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getVariantWord = `-- name: GetVariantWord :one
+SELECT word FROM word_variant
+WHERE variant = ?
+`
+
+func (q *Queries) GetVariantWord(ctx context.Context, variant string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getVariantWord, variant)
+	var word string
+	err := row.Scan(&word)
+	return word, err
+}
+
+const getWord = `-- name: GetWord :one
+SELECT word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, phonetic, level FROM word
+WHERE word = ? AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetWord(ctx context.Context, word string) (Word, error) {
+	row := q.db.QueryRowContext(ctx, getWord, word)
+	var i Word
+	err := row.Scan(
+		&i.Word,
+		&i.ZhTrans,
+		&i.AddedCount,
+		&i.LookupCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Note,
+		&i.Context,
+		&i.DeletedAt,
+		&i.DeckID,
+		&i.Phonetic,
+		&i.Level,
+	)
+	return i, err
+}
+
+const getWordStats = `-- name: GetWordStats :one
+SELECT
+  COUNT(*) AS words,
+  CAST(IFNULL(SUM(zh_trans IS NOT NULL AND zh_trans != ''), 0) AS INTEGER) AS translated,
+  CAST(IFNULL(SUM(added_count), 0) AS INTEGER) AS added_count,
+  CAST(IFNULL(SUM(lookup_count), 0) AS INTEGER) AS lookup_count
+FROM word
+WHERE deleted_at IS NULL AND (?1 = 0 OR deck_id = ?1)
+`
+
+type GetWordStatsRow struct {
+	Words       int64
+	Translated  int64
+	AddedCount  int64
+	LookupCount int64
+}
+
+func (q *Queries) GetWordStats(ctx context.Context, deckID int64) (GetWordStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getWordStats, deckID)
+	var i GetWordStatsRow
+	err := row.Scan(
+		&i.Words,
+		&i.Translated,
+		&i.AddedCount,
+		&i.LookupCount,
+	)
+	return i, err
+}
+
+const getWordUpdatedAt = `-- name: GetWordUpdatedAt :one
+SELECT updated_at FROM word
+WHERE word = ?
+`
+
+func (q *Queries) GetWordUpdatedAt(ctx context.Context, word string) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getWordUpdatedAt, word)
+	var updated_at sql.NullTime
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
+
+const journalWord = `-- name: JournalWord :exec
+INSERT INTO journal_word (
+  journal_id, word, existed, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id
+)
+SELECT ?1, ?2, word.word IS NOT NULL, word.zh_trans, word.added_count, word.lookup_count,
+  word.created_at, word.updated_at, word.note, word.context, word.deleted_at, word.deck_id
+FROM (SELECT 1) LEFT JOIN word ON word.word = ?2
+`
+
+type JournalWordParams struct {
+	JournalID int64
+	Word      string
+}
+
+func (q *Queries) JournalWord(ctx context.Context, arg JournalWordParams) error {
+	_, err := q.db.ExecContext(ctx, journalWord, arg.JournalID, arg.Word)
+	return err
+}
+
+const listChangedWords = `-- name: ListChangedWords :many
+SELECT word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, phonetic, level FROM word
+WHERE ?1 IS NULL OR datetime(updated_at) >= datetime(?1)
+ORDER BY word
+`
+
+func (q *Queries) ListChangedWords(ctx context.Context, since sql.NullTime) ([]Word, error) {
+	rows, err := q.db.QueryContext(ctx, listChangedWords, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Word
+	for rows.Next() {
+		var i Word
+		if err := rows.Scan(
+			&i.Word,
+			&i.ZhTrans,
+			&i.AddedCount,
+			&i.LookupCount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.Context,
+			&i.DeletedAt,
+			&i.DeckID,
+			&i.Phonetic,
+			&i.Level,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDailyActivity = `-- name: ListDailyActivity :many
+SELECT day, reviews FROM daily_activity
+ORDER BY day
+`
+
+func (q *Queries) ListDailyActivity(ctx context.Context) ([]DailyActivity, error) {
+	rows, err := q.db.QueryContext(ctx, listDailyActivity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DailyActivity
+	for rows.Next() {
+		var i DailyActivity
+		if err := rows.Scan(&i.Day, &i.Reviews); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDecks = `-- name: ListDecks :many
+SELECT deck.id, deck.name, COUNT(word.word) AS word_count
+FROM deck LEFT JOIN word ON word.deck_id = deck.id AND word.deleted_at IS NULL
+GROUP BY deck.id
+ORDER BY deck.id
+`
+
+type ListDecksRow struct {
+	ID        int64
+	Name      string
+	WordCount int64
+}
+
+func (q *Queries) ListDecks(ctx context.Context) ([]ListDecksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDecks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDecksRow
+	for rows.Next() {
+		var i ListDecksRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.WordCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDistractors = `-- name: ListDistractors :many
+SELECT DISTINCT zh_trans FROM word
+WHERE deleted_at IS NULL AND word != ?1 AND zh_trans IS NOT NULL AND zh_trans != '' AND zh_trans != ?2
+  AND (?3 = 0 OR deck_id = ?3)
+ORDER BY random()
+LIMIT ?4
+`
+
+type ListDistractorsParams struct {
+	Word    string
+	ZhTrans sql.NullString
+	DeckID  int64
+	Limit   int64
+}
+
+func (q *Queries) ListDistractors(ctx context.Context, arg ListDistractorsParams) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, listDistractors,
+		arg.Word,
+		arg.ZhTrans,
+		arg.DeckID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []sql.NullString
+	for rows.Next() {
+		var zh_trans sql.NullString
+		if err := rows.Scan(&zh_trans); err != nil {
+			return nil, err
+		}
+		items = append(items, zh_trans)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueWords = `-- name: ListDueWords :many
+SELECT word.word, word.zh_trans, review.ease, review.interval_days, review.repetitions
+FROM word LEFT JOIN review ON review.word = word.word
+WHERE word.deleted_at IS NULL AND (review.due_at IS NULL OR review.due_at <= ?1)
+  AND (?2 = 0 OR word.deck_id = ?2)
+  AND word.word NOT IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  )
+ORDER BY review.due_at
+LIMIT ?3
+`
+
+type ListDueWordsParams struct {
+	DueAt  time.Time
+	DeckID int64
+	Limit  int64
+}
+
+type ListDueWordsRow struct {
+	Word         string
+	ZhTrans      sql.NullString
+	Ease         sql.NullFloat64
+	IntervalDays sql.NullInt64
+	Repetitions  sql.NullInt64
+}
+
+func (q *Queries) ListDueWords(ctx context.Context, arg ListDueWordsParams) ([]ListDueWordsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDueWords, arg.DueAt, arg.DeckID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDueWordsRow
+	for rows.Next() {
+		var i ListDueWordsRow
+		if err := rows.Scan(
+			&i.Word,
+			&i.ZhTrans,
+			&i.Ease,
+			&i.IntervalDays,
+			&i.Repetitions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJournalWords = `-- name: ListJournalWords :many
+SELECT id, journal_id, word, existed, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id FROM journal_word
+WHERE journal_id = ?
+ORDER BY id DESC
+`
+
+func (q *Queries) ListJournalWords(ctx context.Context, journalID int64) ([]JournalWord, error) {
+	rows, err := q.db.QueryContext(ctx, listJournalWords, journalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []JournalWord
+	for rows.Next() {
+		var i JournalWord
+		if err := rows.Scan(
+			&i.ID,
+			&i.JournalID,
+			&i.Word,
+			&i.Existed,
+			&i.ZhTrans,
+			&i.AddedCount,
+			&i.LookupCount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.Context,
+			&i.DeletedAt,
+			&i.DeckID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listListedWords = `-- name: ListListedWords :many
+SELECT word, list FROM word_list
+ORDER BY list, word
+`
+
+type ListListedWordsRow struct {
+	Word string
+	List string
+}
+
+func (q *Queries) ListListedWords(ctx context.Context) ([]ListListedWordsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listListedWords)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListListedWordsRow
+	for rows.Next() {
+		var i ListListedWordsRow
+		if err := rows.Scan(&i.Word, &i.List); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQuizWords = `-- name: ListQuizWords :many
+SELECT word, zh_trans FROM word
+WHERE deleted_at IS NULL AND zh_trans IS NOT NULL AND zh_trans != ''
+  AND word NOT IN (SELECT word_list.word FROM word_list)
+  AND word NOT IN (SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL)
+  AND (?1 = 0 OR deck_id = ?1)
+ORDER BY random()
+LIMIT ?2
+`
+
+type ListQuizWordsParams struct {
+	DeckID int64
+	Limit  int64
+}
+
+type ListQuizWordsRow struct {
+	Word    string
+	ZhTrans sql.NullString
+}
+
+func (q *Queries) ListQuizWords(ctx context.Context, arg ListQuizWordsParams) ([]ListQuizWordsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listQuizWords, arg.DeckID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListQuizWordsRow
+	for rows.Next() {
+		var i ListQuizWordsRow
+		if err := rows.Scan(&i.Word, &i.ZhTrans); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRelatedWords = `-- name: ListRelatedWords :many
+SELECT related_word.related, related_word.kind,
+  EXISTS (SELECT 1 FROM word WHERE word.word = related_word.related AND word.deleted_at IS NULL) AS added
+FROM related_word
+WHERE related_word.word = ?
+ORDER BY related_word.kind DESC, related_word.score DESC, related_word.related
+`
+
+type ListRelatedWordsRow struct {
+	Related string
+	Kind    string
+	Added   int64
+}
+
+func (q *Queries) ListRelatedWords(ctx context.Context, word string) ([]ListRelatedWordsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRelatedWords, word)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRelatedWordsRow
+	for rows.Next() {
+		var i ListRelatedWordsRow
+		if err := rows.Scan(&i.Related, &i.Kind, &i.Added); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReviewLog = `-- name: ListReviewLog :many
+SELECT id, word, grade, latency_ms, interval_days, next_interval_days, reviewed_at FROM review_log
+WHERE reviewed_at >= ?1
+  AND (CAST(?2 AS TEXT) = '' OR word = ?2)
+  AND (?3 = 0 OR word IN (
+    SELECT word.word FROM word WHERE word.deck_id = ?3
+  ))
+ORDER BY reviewed_at DESC, id DESC
+LIMIT ?4
+`
+
+type ListReviewLogParams struct {
+	Since  time.Time
+	Word   string
+	DeckID int64
+	Limit  int64
+}
+
+func (q *Queries) ListReviewLog(ctx context.Context, arg ListReviewLogParams) ([]ReviewLog, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewLog,
+		arg.Since,
+		arg.Word,
+		arg.DeckID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReviewLog
+	for rows.Next() {
+		var i ReviewLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Word,
+			&i.Grade,
+			&i.LatencyMs,
+			&i.IntervalDays,
+			&i.NextIntervalDays,
+			&i.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSentences = `-- name: ListSentences :many
+SELECT id, word, sentence, source FROM sentence
+WHERE word = ?
+ORDER BY id
+`
+
+func (q *Queries) ListSentences(ctx context.Context, word string) ([]Sentence, error) {
+	rows, err := q.db.QueryContext(ctx, listSentences, word)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sentence
+	for rows.Next() {
+		var i Sentence
+		if err := rows.Scan(
+			&i.ID,
+			&i.Word,
+			&i.Sentence,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShares = `-- name: ListShares :many
+SELECT token, title, deck_id, tag, words, created_at, expires_at FROM share
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListShares(ctx context.Context) ([]Share, error) {
+	rows, err := q.db.QueryContext(ctx, listShares)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Share
+	for rows.Next() {
+		var i Share
+		if err := rows.Scan(
+			&i.Token,
+			&i.Title,
+			&i.DeckID,
+			&i.Tag,
+			&i.Words,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSources = `-- name: ListSources :many
+SELECT word_source.source, COUNT(word.word) AS word_count
+FROM word_source JOIN word ON word.word = word_source.word AND word.deleted_at IS NULL
+GROUP BY word_source.source
+ORDER BY word_source.source
+`
+
+type ListSourcesRow struct {
+	Source    string
+	WordCount int64
+}
+
+func (q *Queries) ListSources(ctx context.Context) ([]ListSourcesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSourcesRow
+	for rows.Next() {
+		var i ListSourcesRow
+		if err := rows.Scan(&i.Source, &i.WordCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT tag.name, COUNT(word.word) AS word_count
+FROM tag LEFT JOIN word_tag ON word_tag.tag_id = tag.id
+  LEFT JOIN word ON word.word = word_tag.word AND word.deleted_at IS NULL
+GROUP BY tag.id
+ORDER BY tag.name
+`
+
+type ListTagsRow struct {
+	Name      string
+	WordCount int64
+}
+
+func (q *Queries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsRow
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(&i.Name, &i.WordCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopLookups = `-- name: ListTopLookups :many
+SELECT word, zh_trans, lookup_count FROM word
+WHERE deleted_at IS NULL AND lookup_count > 0
+  AND (?1 = 0 OR deck_id = ?1)
+ORDER BY lookup_count DESC, word
+LIMIT ?2
+`
+
+type ListTopLookupsParams struct {
+	DeckID int64
+	Limit  int64
+}
+
+type ListTopLookupsRow struct {
+	Word        string
+	ZhTrans     sql.NullString
+	LookupCount sql.NullInt64
+}
+
+func (q *Queries) ListTopLookups(ctx context.Context, arg ListTopLookupsParams) ([]ListTopLookupsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTopLookups, arg.DeckID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopLookupsRow
+	for rows.Next() {
+		var i ListTopLookupsRow
+		if err := rows.Scan(&i.Word, &i.ZhTrans, &i.LookupCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranslations = `-- name: ListTranslations :many
+SELECT lang, trans FROM translation
+WHERE word = ?
+ORDER BY lang
+`
+
+type ListTranslationsRow struct {
+	Lang  string
+	Trans string
+}
+
+func (q *Queries) ListTranslations(ctx context.Context, word string) ([]ListTranslationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTranslations, word)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTranslationsRow
+	for rows.Next() {
+		var i ListTranslationsRow
+		if err := rows.Scan(&i.Lang, &i.Trans); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranslationsByLang = `-- name: ListTranslationsByLang :many
+SELECT word, trans FROM translation
+WHERE lang = ?
+`
+
+type ListTranslationsByLangRow struct {
+	Word  string
+	Trans string
+}
+
+func (q *Queries) ListTranslationsByLang(ctx context.Context, lang string) ([]ListTranslationsByLangRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTranslationsByLang, lang)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTranslationsByLangRow
+	for rows.Next() {
+		var i ListTranslationsByLangRow
+		if err := rows.Scan(&i.Word, &i.Trans); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrash = `-- name: ListTrash :many
+SELECT word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, phonetic, level FROM word
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, word
+`
+
+func (q *Queries) ListTrash(ctx context.Context) ([]Word, error) {
+	rows, err := q.db.QueryContext(ctx, listTrash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Word
+	for rows.Next() {
+		var i Word
+		if err := rows.Scan(
+			&i.Word,
+			&i.ZhTrans,
+			&i.AddedCount,
+			&i.LookupCount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.Context,
+			&i.DeletedAt,
+			&i.DeckID,
+			&i.Phonetic,
+			&i.Level,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUntranslatedWords = `-- name: ListUntranslatedWords :many
+SELECT word FROM word
+WHERE deleted_at IS NULL AND (zh_trans IS NULL OR zh_trans = '')
+  AND (?1 = 0 OR deck_id = ?1)
+ORDER BY created_at, word
+LIMIT ?2
+`
+
+type ListUntranslatedWordsParams struct {
+	DeckID int64
+	Limit  int64
+}
+
+func (q *Queries) ListUntranslatedWords(ctx context.Context, arg ListUntranslatedWordsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUntranslatedWords, arg.DeckID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		items = append(items, word)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT user.id, user.name, user.db_path, user.created_at,
+  (SELECT COUNT(*) FROM user_token WHERE user_token.user_id = user.id) AS tokens
+FROM user
+ORDER BY user.name
+`
+
+type ListUsersRow struct {
+	ID        int64
+	Name      string
+	DbPath    string
+	CreatedAt time.Time
+	Tokens    int64
+}
+
+func (q *Queries) ListUsers(ctx context.Context) ([]ListUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.DbPath,
+			&i.CreatedAt,
+			&i.Tokens,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVariants = `-- name: ListVariants :many
+SELECT variant FROM word_variant
+WHERE word = ?
+ORDER BY variant
+`
+
+func (q *Queries) ListVariants(ctx context.Context, word string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listVariants, word)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var variant string
+		if err := rows.Scan(&variant); err != nil {
+			return nil, err
+		}
+		items = append(items, variant)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWordNames = `-- name: ListWordNames :many
+SELECT word FROM word
+WHERE deleted_at IS NULL
+`
+
+func (q *Queries) ListWordNames(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listWordNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		items = append(items, word)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWordSources = `-- name: ListWordSources :many
+SELECT source FROM word_source
+WHERE word = ?
+ORDER BY added_at, source
+`
+
+func (q *Queries) ListWordSources(ctx context.Context, word string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listWordSources, word)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var source string
+		if err := rows.Scan(&source); err != nil {
+			return nil, err
+		}
+		items = append(items, source)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWordTags = `-- name: ListWordTags :many
+SELECT word_tag.word, tag.name
+FROM word_tag JOIN tag ON tag.id = word_tag.tag_id
+ORDER BY word_tag.word, tag.name
+`
+
+type ListWordTagsRow struct {
+	Word string
+	Name string
+}
+
+func (q *Queries) ListWordTags(ctx context.Context) ([]ListWordTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWordTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWordTagsRow
+	for rows.Next() {
+		var i ListWordTagsRow
+		if err := rows.Scan(&i.Word, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWordsSorted = `-- name: ListWordsSorted :many
+SELECT word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, phonetic, level FROM word
+WHERE deleted_at IS NULL
+  AND (word LIKE '%' || ?1 || '%' ESCAPE '\' OR IFNULL(zh_trans, '') LIKE '%' || ?1 || '%' ESCAPE '\')
+  AND (CAST(?2 AS TEXT) = '' OR word IN (
+    SELECT word_tag.word FROM word_tag JOIN tag ON tag.id = word_tag.tag_id
+    WHERE tag.name = ?2
+  ))
+  AND (?3 = 0 OR deck_id = ?3)
+  AND (CAST(?4 AS TEXT) = '' OR level = ?4)
+  AND (CAST(?5 AS TEXT) = '' OR word IN (
+    SELECT word_source.word FROM word_source
+    WHERE word_source.source LIKE ?5 || '%' ESCAPE '\'
+  ))
+  AND (word IN (
+    SELECT word_mastery.word FROM word_mastery WHERE word_mastery.archived_at IS NOT NULL
+  )) = CAST(?6 AS BOOLEAN)
+  AND (CAST(?7 AS TEXT) = '' OR IFNULL((
+    SELECT word_mastery.state FROM word_mastery WHERE word_mastery.word = word.word
+  ), 'new') = ?7)
+ORDER BY
+  CASE WHEN CAST(?8 AS TEXT) = 'alpha' THEN word END ASC,
+  CASE WHEN CAST(?8 AS TEXT) = 'added' THEN added_count END DESC,
+  CASE WHEN CAST(?8 AS TEXT) = 'lookup' THEN lookup_count END DESC,
+  CASE WHEN CAST(?8 AS TEXT) = 'date' THEN created_at END DESC,
+  CASE WHEN CAST(?8 AS TEXT) = 'level' THEN level IS NULL END ASC,
+  CASE WHEN CAST(?8 AS TEXT) = 'level' THEN level END ASC,
+  word
+LIMIT ?9 OFFSET ?10
+`
+
+type ListWordsSortedParams struct {
+	Filter   string
+	Tag      string
+	DeckID   int64
+	Level    string
+	Source   string
+	Archived bool
+	Mastery  string
+	Sort     string
+	Limit    int64
+	Offset   int64
+}
+
+func (q *Queries) ListWordsSorted(ctx context.Context, arg ListWordsSortedParams) ([]Word, error) {
+	rows, err := q.db.QueryContext(ctx, listWordsSorted,
+		arg.Filter,
+		arg.Tag,
+		arg.DeckID,
+		arg.Level,
+		arg.Source,
+		arg.Archived,
+		arg.Mastery,
+		arg.Sort,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Word
+	for rows.Next() {
+		var i Word
+		if err := rows.Scan(
+			&i.Word,
+			&i.ZhTrans,
+			&i.AddedCount,
+			&i.LookupCount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.Context,
+			&i.DeletedAt,
+			&i.DeckID,
+			&i.Phonetic,
+			&i.Level,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
+
+const listWordsWithoutLangTranslation = `-- name: ListWordsWithoutLangTranslation :many
+SELECT word FROM word
+WHERE deleted_at IS NULL
+  AND word NOT IN (SELECT translation.word FROM translation WHERE translation.lang = ?1)
+  AND (?2 = 0 OR deck_id = ?2)
+ORDER BY created_at, word
+LIMIT ?3
+`
+
+type ListWordsWithoutLangTranslationParams struct {
+	Lang   string
+	DeckID int64
+	Limit  int64
+}
+
+func (q *Queries) ListWordsWithoutLangTranslation(ctx context.Context, arg ListWordsWithoutLangTranslationParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listWordsWithoutLangTranslation, arg.Lang, arg.DeckID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		items = append(items, word)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWordsWithoutPhonetic = `-- name: ListWordsWithoutPhonetic :many
+SELECT word FROM word
+WHERE deleted_at IS NULL AND (phonetic IS NULL OR phonetic = '')
+  AND (?1 = 0 OR deck_id = ?1)
+ORDER BY created_at, word
+LIMIT ?2
+`
+
+type ListWordsWithoutPhoneticParams struct {
+	DeckID int64
+	Limit  int64
+}
+
+func (q *Queries) ListWordsWithoutPhonetic(ctx context.Context, arg ListWordsWithoutPhoneticParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listWordsWithoutPhonetic, arg.DeckID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		items = append(items, word)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listword = `-- name: Listword :many
+SELECT word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, phonetic, level FROM word
+WHERE deleted_at IS NULL AND (?1 = 0 OR deck_id = ?1)
+`
+
+func (q *Queries) Listword(ctx context.Context, deckID int64) ([]Word, error) {
+	rows, err := q.db.QueryContext(ctx, listword, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Word
+	for rows.Next() {
+		var i Word
+		if err := rows.Scan(
+			&i.Word,
+			&i.ZhTrans,
+			&i.AddedCount,
+			&i.LookupCount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Note,
+			&i.Context,
+			&i.DeletedAt,
+			&i.DeckID,
+			&i.Phonetic,
+			&i.Level,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mergeWord = `-- name: MergeWord :exec
+INSERT INTO word (
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deck_id, level
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  zh_trans = COALESCE(NULLIF(word.zh_trans, ''), excluded.zh_trans),
+  added_count = IFNULL(word.added_count, 0) + IFNULL(excluded.added_count, 0),
+  lookup_count = IFNULL(word.lookup_count, 0) + IFNULL(excluded.lookup_count, 0),
+  created_at = COALESCE(MIN(word.created_at, excluded.created_at), word.created_at, excluded.created_at),
+  updated_at = COALESCE(MAX(word.updated_at, excluded.updated_at), word.updated_at, excluded.updated_at),
+  note = COALESCE(NULLIF(word.note, ''), excluded.note),
+  context = COALESCE(NULLIF(word.context, ''), excluded.context)
+`
+
+type MergeWordParams struct {
+	Word        string
+	ZhTrans     sql.NullString
+	AddedCount  sql.NullInt64
+	LookupCount sql.NullInt64
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Note        sql.NullString
+	Context     sql.NullString
+	DeckID      int64
+	Level       sql.NullString
+}
+
+func (q *Queries) MergeWord(ctx context.Context, arg MergeWordParams) error {
+	_, err := q.db.ExecContext(ctx, mergeWord,
+		arg.Word,
+		arg.ZhTrans,
+		arg.AddedCount,
+		arg.LookupCount,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Note,
+		arg.Context,
+		arg.DeckID,
+		arg.Level,
+	)
+	return err
+}
+
+const moveDeckWords = `-- name: MoveDeckWords :execrows
+UPDATE word
+SET deck_id = ?1, updated_at = CURRENT_TIMESTAMP
+WHERE deck_id = ?2
+`
+
+type MoveDeckWordsParams struct {
+	ToDeckID   int64
+	FromDeckID int64
+}
+
+func (q *Queries) MoveDeckWords(ctx context.Context, arg MoveDeckWordsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveDeckWords, arg.ToDeckID, arg.FromDeckID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const pruneJournal = `-- name: PruneJournal :exec
+DELETE FROM journal
+WHERE id <= (SELECT MAX(id) FROM journal) - ?1
+`
+
+func (q *Queries) PruneJournal(ctx context.Context, keep int64) error {
+	_, err := q.db.ExecContext(ctx, pruneJournal, keep)
+	return err
+}
+
+const pruneJournalWords = `-- name: PruneJournalWords :exec
+DELETE FROM journal_word
+WHERE journal_id NOT IN (SELECT id FROM journal)
+`
+
+func (q *Queries) PruneJournalWords(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, pruneJournalWords)
+	return err
+}
+
+const recordQuizAnswer = `-- name: RecordQuizAnswer :one
+INSERT INTO quiz_stat (
+  word, attempts, correct
+) VALUES (
+  ?, 1, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  attempts = attempts + 1,
+  correct = correct + excluded.correct
+RETURNING word, attempts, correct
+`
+
+type RecordQuizAnswerParams struct {
+	Word    string
+	Correct int64
+}
+
+func (q *Queries) RecordQuizAnswer(ctx context.Context, arg RecordQuizAnswerParams) (QuizStat, error) {
+	row := q.db.QueryRowContext(ctx, recordQuizAnswer, arg.Word, arg.Correct)
+	var i QuizStat
+	err := row.Scan(&i.Word, &i.Attempts, &i.Correct)
+	return i, err
+}
+
+const renameDeck = `-- name: RenameDeck :execrows
+UPDATE deck
+SET name = ?1
+WHERE name = ?2
+`
+
+type RenameDeckParams struct {
+	NewName string
+	Name    string
+}
+
+func (q *Queries) RenameDeck(ctx context.Context, arg RenameDeckParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, renameDeck, arg.NewName, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreWord = `-- name: RestoreWord :execrows
+UPDATE word
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreWord(ctx context.Context, word string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreWord, word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revertWord = `-- name: RevertWord :exec
+INSERT INTO word (
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, level, deck_id
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5,
+  ?6, ?7, ?8, ?9, ?10,
+  IFNULL((SELECT deck.id FROM deck WHERE deck.id = ?11), 1)
+)
+ON CONFLICT (word) DO UPDATE SET
+  zh_trans = excluded.zh_trans,
+  added_count = excluded.added_count,
+  lookup_count = excluded.lookup_count,
+  created_at = excluded.created_at,
+  updated_at = excluded.updated_at,
+  note = excluded.note,
+  context = excluded.context,
+  deleted_at = excluded.deleted_at,
+  deck_id = IFNULL((SELECT deck.id FROM deck WHERE deck.id = ?11), word.deck_id)
+`
+
+type RevertWordParams struct {
+	Word        string
+	ZhTrans     sql.NullString
+	AddedCount  sql.NullInt64
+	LookupCount sql.NullInt64
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Note        sql.NullString
+	Context     sql.NullString
+	DeletedAt   sql.NullTime
+	Level       sql.NullString
+	DeckID      sql.NullInt64
+}
+
+func (q *Queries) RevertWord(ctx context.Context, arg RevertWordParams) error {
+	_, err := q.db.ExecContext(ctx, revertWord,
+		arg.Word,
+		arg.ZhTrans,
+		arg.AddedCount,
+		arg.LookupCount,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Note,
+		arg.Context,
+		arg.DeletedAt,
+		arg.Level,
+		arg.DeckID,
+	)
+	return err
+}
+
+const setEnrichment = `-- name: SetEnrichment :exec
+INSERT INTO enrichment (
+  word, definition, mnemonic, examples, model, created_at
+) VALUES (
+  ?, ?, ?, ?, ?, CURRENT_TIMESTAMP
+)
+ON CONFLICT (word) DO UPDATE SET
+  definition = excluded.definition,
+  mnemonic = excluded.mnemonic,
+  examples = excluded.examples,
+  model = excluded.model,
+  created_at = excluded.created_at
+`
+
+type SetEnrichmentParams struct {
+	Word       string
+	Definition string
+	Mnemonic   string
+	Examples   string
+	Model      string
+}
+
+func (q *Queries) SetEnrichment(ctx context.Context, arg SetEnrichmentParams) error {
+	_, err := q.db.ExecContext(ctx, setEnrichment,
+		arg.Word,
+		arg.Definition,
+		arg.Mnemonic,
+		arg.Examples,
+		arg.Model,
+	)
+	return err
+}
+
+const setLangTranslation = `-- name: SetLangTranslation :execrows
+INSERT INTO translation (word, lang, trans, updated_at)
+SELECT word, ?1, ?2, CURRENT_TIMESTAMP FROM word
+WHERE word = ?3 AND deleted_at IS NULL
+ON CONFLICT (word, lang) DO UPDATE SET trans = excluded.trans, updated_at = excluded.updated_at
+`
+
+type SetLangTranslationParams struct {
+	Lang  string
+	Trans string
+	Word  string
+}
+
+func (q *Queries) SetLangTranslation(ctx context.Context, arg SetLangTranslationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setLangTranslation, arg.Lang, arg.Trans, arg.Word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setSearchRevision = `-- name: SetSearchRevision :exec
+INSERT INTO search_index (id, revision, rebuilt_at) VALUES (1, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (id) DO UPDATE SET revision = excluded.revision, rebuilt_at = excluded.rebuilt_at
+`
+
+func (q *Queries) SetSearchRevision(ctx context.Context, revision int64) error {
+	_, err := q.db.ExecContext(ctx, setSearchRevision, revision)
+	return err
+}
+
+const setSyncState = `-- name: SetSyncState :exec
+INSERT INTO sync_state (
+  remote, local_synced_at, remote_synced_at
+) VALUES (
+  ?, ?, ?
+)
+ON CONFLICT (remote) DO UPDATE SET
+  local_synced_at = excluded.local_synced_at,
+  remote_synced_at = excluded.remote_synced_at
+`
+
+type SetSyncStateParams struct {
+	Remote         string
+	LocalSyncedAt  time.Time
+	RemoteSyncedAt time.Time
+}
+
+func (q *Queries) SetSyncState(ctx context.Context, arg SetSyncStateParams) error {
+	_, err := q.db.ExecContext(ctx, setSyncState, arg.Remote, arg.LocalSyncedAt, arg.RemoteSyncedAt)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :execrows
+UPDATE user SET password_hash = ?
+WHERE name = ?
+`
+
+type SetUserPasswordParams struct {
+	PasswordHash string
+	Name         string
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserPassword, arg.PasswordHash, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const syncWord = `-- name: SyncWord :exec
+INSERT INTO word (
+  word, zh_trans, added_count, lookup_count, created_at, updated_at, note, context, deleted_at, deck_id, level
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  zh_trans = excluded.zh_trans,
+  added_count = excluded.added_count,
+  lookup_count = excluded.lookup_count,
+  created_at = excluded.created_at,
+  updated_at = excluded.updated_at,
+  note = excluded.note,
+  context = excluded.context,
+  deleted_at = excluded.deleted_at,
+  deck_id = excluded.deck_id
+`
+
+type SyncWordParams struct {
+	Word        string
+	ZhTrans     sql.NullString
+	AddedCount  sql.NullInt64
+	LookupCount sql.NullInt64
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Note        sql.NullString
+	Context     sql.NullString
+	DeletedAt   sql.NullTime
+	DeckID      int64
+	Level       sql.NullString
+}
+
+func (q *Queries) SyncWord(ctx context.Context, arg SyncWordParams) error {
+	_, err := q.db.ExecContext(ctx, syncWord,
+		arg.Word,
+		arg.ZhTrans,
+		arg.AddedCount,
+		arg.LookupCount,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Note,
+		arg.Context,
+		arg.DeletedAt,
+		arg.DeckID,
+		arg.Level,
+	)
+	return err
+}
+
+const tagWord = `-- name: TagWord :exec
+INSERT OR IGNORE INTO word_tag (
+  word, tag_id
+) VALUES (
+  ?, ?
+)
+`
+
+type TagWordParams struct {
+	Word  string
+	TagID int64
+}
+
+func (q *Queries) TagWord(ctx context.Context, arg TagWordParams) error {
+	_, err := q.db.ExecContext(ctx, tagWord, arg.Word, arg.TagID)
+	return err
+}
+
+const trashWord = `-- name: TrashWord :execrows
+UPDATE word
+SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) TrashWord(ctx context.Context, word string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, trashWord, word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unarchiveWord = `-- name: UnarchiveWord :execrows
+UPDATE word_mastery
+SET archived_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND archived_at IS NOT NULL
+`
+
+func (q *Queries) UnarchiveWord(ctx context.Context, word string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unarchiveWord, word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateNote = `-- name: UpdateNote :exec
+UPDATE word
+SET note = COALESCE(?1, note),
+  context = COALESCE(?2, context),
+  updated_at = CURRENT_TIMESTAMP
+WHERE word = ?3
+`
+
+type UpdateNoteParams struct {
+	Note    sql.NullString
+	Context sql.NullString
+	Word    string
+}
+
+func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) error {
+	_, err := q.db.ExecContext(ctx, updateNote, arg.Note, arg.Context, arg.Word)
+	return err
+}
+
+const updatePhonetic = `-- name: UpdatePhonetic :execrows
+UPDATE word
+SET phonetic = ?
+WHERE word = ? AND deleted_at IS NULL
+`
+
+type UpdatePhoneticParams struct {
+	Phonetic sql.NullString
+	Word     string
+}
+
+func (q *Queries) UpdatePhonetic(ctx context.Context, arg UpdatePhoneticParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updatePhonetic, arg.Phonetic, arg.Word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateTranslation = `-- name: UpdateTranslation :execrows
+UPDATE word
+SET zh_trans = ?, updated_at = CURRENT_TIMESTAMP
+WHERE word = ? AND deleted_at IS NULL
+`
+
+type UpdateTranslationParams struct {
+	ZhTrans sql.NullString
+	Word    string
+}
+
+func (q *Queries) UpdateTranslation(ctx context.Context, arg UpdateTranslationParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTranslation, arg.ZhTrans, arg.Word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertMastery = `-- name: UpsertMastery :exec
+INSERT INTO word_mastery (word, state, archived_at, updated_at)
+VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (word) DO UPDATE SET
+  state = excluded.state,
+  archived_at = COALESCE(word_mastery.archived_at, excluded.archived_at),
+  updated_at = excluded.updated_at
+`
+
+type UpsertMasteryParams struct {
+	Word       string
+	State      string
+	ArchivedAt sql.NullTime
+}
+
+func (q *Queries) UpsertMastery(ctx context.Context, arg UpsertMasteryParams) error {
+	_, err := q.db.ExecContext(ctx, upsertMastery, arg.Word, arg.State, arg.ArchivedAt)
+	return err
+}
+
+const upsertReview = `-- name: UpsertReview :exec
+INSERT INTO review (
+  word, ease, interval_days, repetitions, due_at
+) VALUES (
+  ?, ?, ?, ?, ?
+)
+ON CONFLICT (word) DO UPDATE SET
+  ease = excluded.ease,
+  interval_days = excluded.interval_days,
+  repetitions = excluded.repetitions,
+  due_at = excluded.due_at
+`
+
+type UpsertReviewParams struct {
+	Word         string
+	Ease         float64
+	IntervalDays int64
+	Repetitions  int64
+	DueAt        time.Time
+}
+
+func (q *Queries) UpsertReview(ctx context.Context, arg UpsertReviewParams) error {
+	_, err := q.db.ExecContext(ctx, upsertReview,
+		arg.Word,
+		arg.Ease,
+		arg.IntervalDays,
+		arg.Repetitions,
+		arg.DueAt,
+	)
+	return err
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// print the words on word list, one per line
+func (w *WordDB) ShowListedWords(list string) error {
+	words, err := w.Store.ListWords(w.Ctx, list)
+	if err != nil {
+		return err
+	}
+	for _, word := range words {
+		fmt.Println(word)
+	}
+	return nil
+}
+
+func (w *WordDB) AddListedWords(list string, words []string) error {
+	n, err := w.Store.AddListedWords(w.Ctx, list, words)
+	if err != nil {
+		return err
+	}
+	log.Printf("add %d word(s) to the %s list", n, list)
+	return nil
+}
+
+func (w *WordDB) RemoveListedWords(list string, words []string) error {
+	n, err := w.Store.RemoveListedWords(w.Ctx, list, words)
+	if err != nil {
+		return err
+	}
+	log.Printf("remove %d word(s) from the %s list", n, list)
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/notsobad/w2r/pkg/wordstore"
+	"github.com/notsobad/w2r/worddb"
+)
+
+// word of the day of a deck
+type WordOfTheDay struct {
+	Word    string `json:"word"`
+	ZhTrans string `json:"zh_trans"`
+	// local date like 2006-01-02
+	Day string `json:"day"`
+	// whether the word is due for review today, otherwise it's one of the
+	// least practiced words
+	Due bool `json:"due"`
+}
+
+// rank of word on day, the same word has the same rank all day
+func wotdRank(day, word string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(day + "\x00" + word))
+	return h.Sum64()
+}
+
+// pick the word of the day among the words due by the end of today, or the
+// least practiced words if nothing is due. The pick only depends on the day
+// and the candidates, so it stays the same all day unless the word is
+// reviewed, and it's wordstore.ErrNotFound if the deck has no words.
+func (w *WordDB) wordOfTheDay(ctx context.Context, deckID int64) (WordOfTheDay, error) {
+	now := time.Now()
+	day := now.Format(time.DateOnly)
+	endOfDay := startOfDay(now).AddDate(0, 0, 1)
+	queries := w.Store.Queries()
+	words, err := queries.ListDueWords(ctx, worddb.ListDueWordsParams{DueAt: endOfDay.UTC(), DeckID: deckID, Limit: -1})
+	if err != nil {
+		return WordOfTheDay{}, err
+	}
+	due := len(words) > 0
+	if !due {
+		// every word, keeping the ones with the fewest repetitions
+		all, err := queries.ListDueWords(ctx, worddb.ListDueWordsParams{DueAt: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), DeckID: deckID, Limit: -1})
+		if err != nil {
+			return WordOfTheDay{}, err
+		}
+		fewest := int64(math.MaxInt64)
+		for _, word := range all {
+			fewest = min(fewest, word.Repetitions.Int64)
+		}
+		for _, word := range all {
+			if word.Repetitions.Int64 == fewest {
+				words = append(words, word)
+			}
+		}
+	}
+	if len(words) == 0 {
+		return WordOfTheDay{}, fmt.Errorf("no words to pick from: %w", wordstore.ErrNotFound)
+	}
+
+	best := words[0]
+	for _, word := range words[1:] {
+		if wotdRank(day, word.Word) < wotdRank(day, best.Word) {
+			best = word
+		}
+	}
+	return WordOfTheDay{Word: best.Word, ZhTrans: best.ZhTrans.String, Day: day, Due: due}, nil
+}
+
+// print the word of the day, only the word if short, for shell prompts
+func (w *WordDB) ShowWordOfTheDay(short bool) error {
+	wotd, err := w.wordOfTheDay(w.Ctx, w.DeckID)
+	if err != nil {
+		return err
+	}
+	if short || wotd.ZhTrans == "" {
+		fmt.Println(wotd.Word)
+		return nil
+	}
+	fmt.Printf("%s: %s\n", wotd.Word, wotd.ZhTrans)
+	return nil
+}
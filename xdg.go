@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// database in $HOME of older versions
+const legacyDbName = ".word.sqlite"
+
+// base directory from an XDG environment variable like XDG_DATA_HOME, or
+// fallback in $HOME if it's unset or relative, as the spec requires
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, fallback), nil
+}
+
+// $XDG_DATA_HOME/w2r/word.sqlite, the database in $HOME of older versions is
+// moved there on first run
+func defaultDbPath() (string, error) {
+	dataDir, err := xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dataDir, "w2r", DbName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if err := migrateLegacyDb(filepath.Join(homeDir, legacyDbName), path); err != nil {
+		return "", fmt.Errorf("move database to %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// move the database at legacy to path, with its WAL files and backups,
+// unless there's already a database at path
+func migrateLegacyDb(legacy, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(legacy); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	// the database first, so the WAL files are never left without it
+	for _, suffix := range []string{"", "-wal", "-shm", ".backups"} {
+		err := os.Rename(legacy+suffix, path+suffix)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	log.Printf("moved database %s to %s", legacy, path)
+	return nil
+}